@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"wb-L0/modules/monitoring"
+	"wb-L0/services/composer/orders"
+)
+
+// PushBrokerMessage
+// @Tags admin
+// @Summary Publish a raw message to the broker
+// @Description The body is consumed as is, decoded by its Content-Type like a broker message.
+// @ID push-broker-message
+// @Param topic query string false "Topic, the order topic by default"
+// @Param key query string false "Message key"
+// @Accept json
+// @Produce json
+// @Success 202 {object} structs.PushedMessage "Message published for consumption"
+// @Failure 400 {object} structs.ApiError "Empty body"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Failure 501 {object} structs.ApiError "Broker does not accept pushed messages"
+// @Router /admin/broker/messages [post]
+func PushBrokerMessage(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	payload, err := ctx.GetRawData()
+	if err != nil {
+		ctx.ApiError(http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(payload) == 0 {
+		ctx.ApiError(http.StatusBadRequest, "message body is empty")
+		return
+	}
+
+	pushed, err := orders.PushMessage(ctx, ctx.Query("topic"), ctx.Query("key"), ctx.ContentType(), payload)
+	if err != nil {
+		if orders.IsErrPushUnsupported(err) {
+			ctx.ApiError(http.StatusNotImplemented, err.Error())
+			return
+		}
+		logger.Error("Failed to push broker message", zap.Error(err))
+		ctx.ApiError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logger.Info("Broker message pushed",
+		zap.String("topic", pushed.Topic),
+		zap.Int64("offset", pushed.Offset))
+	ctx.JSON(http.StatusAccepted, pushed)
+}
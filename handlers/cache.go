@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"wb-L0/modules/monitoring"
+	"wb-L0/services/composer/orders"
+	"wb-L0/structs"
+)
+
+// GetCacheStats
+// @Tags admin
+// @Summary Describe the contents of the order cache
+// @ID get-cache-stats
+// @Produce json
+// @Success 200 {object} structs.CacheStats "Cache statistics"
+// @Failure 401 {object} structs.ApiError "Missing or wrong admin token"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /admin/cache [get]
+func GetCacheStats(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	stats, err := orders.CacheStats(ctx)
+	if err != nil {
+		logger.Error("Failed to read cache stats", zap.Error(err))
+		ctx.ApiError(http.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, stats)
+}
+
+// ListCacheKeys
+// @Tags admin
+// @Summary List cached order ids
+// @ID list-cache-keys
+// @Param pattern query string false "Glob pattern the ids match"
+// @Param limit query int false "Maximum number of ids, 100 by default"
+// @Produce json
+// @Success 200 {object} structs.CacheKeys "Cached order ids"
+// @Failure 400 {object} structs.ApiError "Invalid pattern or limit"
+// @Failure 401 {object} structs.ApiError "Missing or wrong admin token"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /admin/cache/keys [get]
+func ListCacheKeys(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	limit := 0
+	if raw := ctx.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			ctx.ApiError(http.StatusBadRequest, "limit must be an integer")
+			return
+		}
+		limit = parsed
+	}
+
+	keys, err := orders.ListCachedOrderIds(ctx, ctx.Query("pattern"), limit)
+	if err != nil {
+		if orders.IsErrInvalidRequest(err) {
+			ctx.ApiError(http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Error("Failed to list cache keys", zap.Error(err))
+		ctx.ApiError(http.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, structs.CacheKeys{Keys: keys})
+}
+
+// InvalidateCachedOrder
+// @Tags admin
+// @Summary Delete an order from the cache
+// @ID invalidate-cached-order
+// @Param order_id path string true "Order ID"
+// @Produce json
+// @Success 200 {object} structs.CacheInvalidateReport "Number of deleted entries"
+// @Failure 401 {object} structs.ApiError "Missing or wrong admin token"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /admin/cache/orders/{order_id} [delete]
+func InvalidateCachedOrder(c *gin.Context) {
+	invalidateCachedOrders(c, []string{c.Param("order_id")})
+}
+
+// InvalidateCachedOrders
+// @Tags admin
+// @Summary Delete orders from the cache
+// @ID invalidate-cached-orders
+// @Param request body structs.CacheInvalidateRequest true "Order ids"
+// @Accept json
+// @Produce json
+// @Success 200 {object} structs.CacheInvalidateReport "Number of deleted entries"
+// @Failure 400 {object} structs.ApiError "Invalid request"
+// @Failure 401 {object} structs.ApiError "Missing or wrong admin token"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /admin/cache/invalidate [post]
+func InvalidateCachedOrders(c *gin.Context) {
+	ctx := GetApiContext(c)
+
+	var request structs.CacheInvalidateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.ApiError(http.StatusBadRequest, err.Error())
+		return
+	}
+	invalidateCachedOrders(c, request.Ids)
+}
+
+func invalidateCachedOrders(c *gin.Context, orderIds []string) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	deleted, err := orders.InvalidateCachedOrders(ctx, orderIds)
+	if err != nil {
+		if orders.IsErrInvalidRequest(err) {
+			ctx.ApiError(http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Error("Failed to invalidate cached orders", zap.Error(err))
+		ctx.ApiError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logger.Info("Cached orders invalidated", zap.Int("requested", len(orderIds)), zap.Int("deleted", deleted))
+	ctx.JSON(http.StatusOK, structs.CacheInvalidateReport{Deleted: deleted})
+}
+
+// FlushCache
+// @Tags admin
+// @Summary Delete every order from the cache
+// @ID flush-cache
+// @Success 204 "Cache flushed"
+// @Failure 401 {object} structs.ApiError "Missing or wrong admin token"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /admin/cache [delete]
+func FlushCache(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	err := orders.FlushCache(ctx)
+	if err != nil {
+		logger.Error("Failed to flush cache", zap.Error(err))
+		ctx.ApiError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logger.Info("Cache flushed")
+	ctx.Status(http.StatusNoContent)
+}
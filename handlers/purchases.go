@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,6 +10,8 @@ import (
 	"wb-L0/modules/monitoring"
 	"wb-L0/services/composer/orders"
 	"wb-L0/services/database"
+	"wb-L0/services/validation"
+	"wb-L0/structs"
 )
 
 // GetPurchase
@@ -53,3 +56,247 @@ func GetPurchase(c *gin.Context) {
 		zap.String("order_id", orderId))
 	ctx.JSON(http.StatusOK, order)
 }
+
+// CreatePurchase
+// @Tags purchases
+// @Summary Create order
+// @ID create-order
+// @Param order body structs.Order true "Order"
+// @Accept json
+// @Produce json
+// @Success 200 {object} structs.Order "Identical order already stored, as stored"
+// @Success 201 {object} structs.Order "Order created or replaced, as stored"
+// @Failure 400 {object} structs.ApiError "Malformed request body"
+// @Failure 409 {object} structs.ApiError "Order already exists"
+// @Failure 422 {object} structs.ValidationError "Order is invalid"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Failure 503 {object} structs.ApiError "Database unavailable"
+// @Router /api/order [post]
+func CreatePurchase(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	var order structs.Order
+	if err := ctx.ShouldBindJSON(&order); err != nil {
+		logger.Warn("Malformed order body", zap.Error(err))
+		ctx.ApiError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logger.Info("Processing order creation request",
+		zap.String("order_id", order.OrderUid))
+
+	stored, err := orders.CreateOrder(ctx, &order)
+	if database.IsErrOrderUnchanged(err) {
+		logger.Info("Order already stored unchanged",
+			zap.String("order_id", order.OrderUid))
+		stored, err = orders.GetOrderById(ctx, order.OrderUid)
+		if err == nil {
+			ctx.Header("Location", "/api/order/"+order.OrderUid)
+			ctx.JSON(http.StatusOK, stored)
+			return
+		}
+	}
+	if err != nil {
+		switch {
+		case validation.IsErrValidation(err):
+			logger.Info("Order rejected by validation",
+				zap.String("order_id", order.OrderUid), zap.Error(err))
+			ctx.JSON(http.StatusUnprocessableEntity, structs.ValidationError{
+				Message: "order validation failed",
+				Fields:  validation.FieldErrors(err),
+			})
+		case database.IsErrOrderExists(err):
+			logger.Info("Order already exists",
+				zap.String("order_id", order.OrderUid))
+			ctx.ApiError(http.StatusConflict, err.Error())
+		case database.IsErrDataInvalid(err):
+			logger.Warn("Order rejected by database",
+				zap.String("order_id", order.OrderUid), zap.Error(err))
+			ctx.ApiError(http.StatusUnprocessableEntity, err.Error())
+		case database.IsErrUnavailable(err):
+			logger.Error("Database unavailable for order creation",
+				zap.String("order_id", order.OrderUid), zap.Error(err))
+			ctx.ApiError(http.StatusServiceUnavailable, "database unavailable, retry later")
+		default:
+			logger.Error("Failed to create order",
+				zap.String("order_id", order.OrderUid), zap.Error(err))
+			ctx.ApiError(http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	logger.Info("Order created successfully",
+		zap.String("order_id", order.OrderUid))
+	ctx.Header("Location", "/api/order/"+order.OrderUid)
+	ctx.JSON(http.StatusCreated, stored)
+}
+
+// LookupPurchases
+// @Tags purchases
+// @Summary Get several orders by uid
+// @ID lookup-orders
+// @Param request body structs.OrdersLookupRequest true "Order uids"
+// @Accept json
+// @Produce json
+// @Success 200 {object} structs.OrdersLookupResponse "Found orders and missing uids"
+// @Failure 400 {object} structs.ApiError "Invalid request"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /api/orders/lookup [post]
+func LookupPurchases(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	var request structs.OrdersLookupRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.ApiError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logger.Info("Processing order lookup request",
+		zap.Int("order_count", len(request.OrderIds)))
+
+	found, missing, err := orders.GetOrdersByIds(ctx, request.OrderIds)
+	if err != nil {
+		if orders.IsErrInvalidRequest(err) {
+			ctx.ApiError(http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Error("Failed to look up orders", zap.Error(err))
+		ctx.ApiError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logger.Info("Orders looked up successfully",
+		zap.Int("found", len(found)), zap.Int("missing", len(missing)))
+	ctx.JSON(http.StatusOK, structs.OrdersLookupResponse{
+		Orders:  found,
+		Missing: missing,
+	})
+}
+
+// ListPurchases
+// @Tags purchases
+// @Summary List orders
+// @ID list-orders
+// @Param customer_id query string false "Customer id"
+// @Param track_number query string false "Order track number"
+// @Param delivery_service query string false "Delivery service"
+// @Param locale query string false "Locale"
+// @Param date_from query string false "Created at or after (RFC3339)"
+// @Param date_to query string false "Created at or before (RFC3339)"
+// @Param provider query string false "Payment provider"
+// @Param bank query string false "Payment bank"
+// @Param brand query string false "Item brand"
+// @Param nm_id query int false "Item nm_id"
+// @Param status query string false "Order status" Enums(created, paid, assembling, shipped, delivered, cancelled, returned)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size, up to 100"
+// @Produce json
+// @Success 200 {object} structs.OrderPage "Page of orders"
+// @Failure 400 {object} structs.ApiError "Invalid filter or cursor"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /api/orders [get]
+func ListPurchases(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	var filter structs.OrderFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.ApiError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := orders.ListOrders(ctx, &filter)
+	if err != nil {
+		if orders.IsErrInvalidRequest(err) || database.IsErrInvalidCursor(err) {
+			ctx.ApiError(http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Error("Failed to list orders", zap.Error(err))
+		ctx.ApiError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logger.Info("Orders listed successfully",
+		zap.Int("count", len(page.Orders)))
+	ctx.JSON(http.StatusOK, page)
+}
+
+// GetPurchasesByTrackNumber
+// @Tags purchases
+// @Summary Get orders by order or item track number
+// @ID get-orders-by-track-number
+// @Param track_number path string true "Track number"
+// @Produce json
+// @Success 200 {array} structs.Order "Orders obtained"
+// @Failure 404 {object} structs.ApiError "No order found"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /api/orders/by-track/{track_number} [get]
+func GetPurchasesByTrackNumber(c *gin.Context) {
+	getPurchasesByReference(c, "track_number", orders.GetOrdersByTrackNumber)
+}
+
+// GetPurchasesByTransaction
+// @Tags purchases
+// @Summary Get orders by payment transaction
+// @ID get-orders-by-transaction
+// @Param transaction path string true "Payment transaction"
+// @Produce json
+// @Success 200 {array} structs.Order "Orders obtained"
+// @Failure 404 {object} structs.ApiError "No order found"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /api/orders/by-transaction/{transaction} [get]
+func GetPurchasesByTransaction(c *gin.Context) {
+	getPurchasesByReference(c, "transaction", orders.GetOrdersByTransaction)
+}
+
+// GetPurchasesByRequestId
+// @Tags purchases
+// @Summary Get orders by payment request id
+// @ID get-orders-by-request-id
+// @Param request_id path string true "Payment request id"
+// @Produce json
+// @Success 200 {array} structs.Order "Orders obtained"
+// @Failure 404 {object} structs.ApiError "No order found"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /api/orders/by-request/{request_id} [get]
+func GetPurchasesByRequestId(c *gin.Context) {
+	getPurchasesByReference(c, "request_id", orders.GetOrdersByRequestId)
+}
+
+func getPurchasesByReference(c *gin.Context, param string,
+	lookup func(context.Context, string) ([]*structs.Order, error)) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	reference, has := ctx.Params.Get(param)
+	if !has {
+		logger.Warn("Missing reference parameter", zap.String("param", param))
+		ctx.ApiError(http.StatusBadRequest, param+" is required")
+		return
+	}
+
+	logger.Info("Processing order reference lookup",
+		zap.String(param, reference))
+
+	found, err := lookup(ctx, reference)
+	if err != nil {
+		switch {
+		case database.IsErrOrderNotFound(err):
+			logger.Info("Order not found", zap.String(param, reference))
+			ctx.ApiError(http.StatusNotFound, err.Error())
+		case orders.IsErrInvalidRequest(err):
+			ctx.ApiError(http.StatusBadRequest, err.Error())
+		default:
+			logger.Error("Failed to retrieve orders",
+				zap.String(param, reference), zap.Error(err))
+			ctx.ApiError(http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	logger.Info("Orders retrieved successfully",
+		zap.String(param, reference), zap.Int("count", len(found)))
+	ctx.JSON(http.StatusOK, found)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"wb-L0/modules/monitoring"
+	"wb-L0/services/composer/orders"
+	"wb-L0/structs"
+)
+
+// ListDeadLetters
+// @Tags admin
+// @Summary List dead-lettered messages
+// @ID list-dead-letters
+// @Param limit query int false "Maximum number of letters"
+// @Produce json
+// @Success 200 {array} structs.DeadLetter "Pending dead letters"
+// @Failure 400 {object} structs.ApiError "Invalid limit"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /admin/dlq [get]
+func ListDeadLetters(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	limit := 0
+	if raw := ctx.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			ctx.ApiError(http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		limit = parsed
+	}
+
+	letters, err := orders.ListDeadLetters(ctx, limit)
+	if err != nil {
+		logger.Error("Failed to list dead letters", zap.Error(err))
+		ctx.ApiError(http.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, letters)
+}
+
+// ReplayDeadLetters
+// @Tags admin
+// @Summary Replay dead-lettered messages through the ingestion pipeline
+// @ID replay-dead-letters
+// @Param request body structs.DeadLetterReplayRequest true "Letter ids or limit"
+// @Accept json
+// @Produce json
+// @Success 200 {object} structs.ReplayReport "Replay report"
+// @Failure 400 {object} structs.ApiError "Invalid request"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /admin/dlq/replay [post]
+func ReplayDeadLetters(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	var request structs.DeadLetterReplayRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.ApiError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := orders.ReplayDeadLetters(ctx, request.Ids, request.Limit)
+	if err != nil {
+		if orders.IsErrInvalidRequest(err) {
+			ctx.ApiError(http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Error("Failed to replay dead letters", zap.Error(err))
+		ctx.ApiError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logger.Info("Dead letters replayed", zap.Int("replayed", report.Replayed))
+	ctx.JSON(http.StatusOK, report)
+}
+
+// ReplayOffsets
+// @Tags admin
+// @Summary Replay a broker offset range through the ingestion pipeline
+// @ID replay-offsets
+// @Param request body structs.OffsetReplayRequest true "Partition and inclusive offset range"
+// @Accept json
+// @Produce json
+// @Success 200 {object} structs.ReplayReport "Replay report"
+// @Failure 400 {object} structs.ApiError "Invalid request"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Failure 501 {object} structs.ApiError "Broker does not support replay"
+// @Router /admin/replay/offsets [post]
+func ReplayOffsets(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	var request structs.OffsetReplayRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.ApiError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := orders.ReplayOffsets(ctx, &request)
+	if err != nil {
+		switch {
+		case orders.IsErrInvalidRequest(err):
+			ctx.ApiError(http.StatusBadRequest, err.Error())
+		case orders.IsErrReplayUnsupported(err):
+			ctx.ApiError(http.StatusNotImplemented, err.Error())
+		default:
+			logger.Error("Failed to replay offsets", zap.Error(err))
+			ctx.ApiError(http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	logger.Info("Offsets replayed",
+		zap.Int("partition", request.Partition),
+		zap.Int64("from_offset", request.FromOffset),
+		zap.Int64("to_offset", request.ToOffset),
+		zap.Int("replayed", report.Replayed))
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"wb-L0/modules/monitoring"
+	"wb-L0/services/composer/orders"
+	"wb-L0/services/database"
+	"wb-L0/services/orderstatus"
+	"wb-L0/services/validation"
+	"wb-L0/structs"
+)
+
+// UpdatePurchaseStatus
+// @Tags purchases
+// @Summary Change order status
+// @ID update-order-status
+// @Param order_id path string true "Order uid"
+// @Param change body structs.OrderStatusChange true "New status"
+// @Accept json
+// @Produce json
+// @Success 200 {object} structs.OrderStatusHistory "Status changed or already current"
+// @Failure 400 {object} structs.ApiError "Malformed request body"
+// @Failure 404 {object} structs.ApiError "Order not found"
+// @Failure 409 {object} structs.ApiError "Transition not allowed from the current status"
+// @Failure 422 {object} structs.ValidationError "Unknown status or invalid reason"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /api/order/{order_id}/status [patch]
+func UpdatePurchaseStatus(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	orderId, has := ctx.Params.Get("order_id")
+	if !has {
+		logger.Warn("Missing order_id parameter")
+		ctx.ApiError(http.StatusBadRequest, "order_id is required")
+		return
+	}
+
+	var change structs.OrderStatusChange
+	if err := ctx.ShouldBindJSON(&change); err != nil {
+		logger.Warn("Malformed status change body", zap.Error(err))
+		ctx.ApiError(http.StatusBadRequest, err.Error())
+		return
+	}
+	change.OrderUid = orderId
+	change.Source = orderstatus.SourceApi
+
+	logger.Info("Processing order status change",
+		zap.String("order_id", orderId), zap.String("status", change.Status))
+
+	history, err := orders.UpdateOrderStatus(ctx, orderId, &change)
+	if err != nil {
+		switch {
+		case validation.IsErrValidation(err):
+			ctx.JSON(http.StatusUnprocessableEntity, structs.ValidationError{
+				Message: "status change validation failed",
+				Fields:  validation.FieldErrors(err),
+			})
+		case database.IsErrOrderNotFound(err):
+			logger.Info("Order not found", zap.String("order_id", orderId))
+			ctx.ApiError(http.StatusNotFound, err.Error())
+		case orderstatus.IsErrInvalidTransition(err):
+			logger.Info("Order status transition rejected",
+				zap.String("order_id", orderId), zap.Error(err))
+			ctx.ApiError(http.StatusConflict, err.Error())
+		default:
+			logger.Error("Failed to change order status",
+				zap.String("order_id", orderId), zap.Error(err))
+			ctx.ApiError(http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	logger.Info("Order status changed successfully",
+		zap.String("order_id", orderId), zap.String("status", history.Status))
+	ctx.JSON(http.StatusOK, history)
+}
+
+// GetPurchaseStatus
+// @Tags purchases
+// @Summary Get order status history
+// @ID get-order-status
+// @Param order_id path string true "Order uid"
+// @Produce json
+// @Success 200 {object} structs.OrderStatusHistory "Current status and transitions"
+// @Failure 404 {object} structs.ApiError "Order not found"
+// @Failure 500 {object} structs.ApiError "Internal server error"
+// @Router /api/order/{order_id}/status [get]
+func GetPurchaseStatus(c *gin.Context) {
+	ctx := GetApiContext(c)
+	logger := monitoring.LogWithContext(c)
+
+	orderId, has := ctx.Params.Get("order_id")
+	if !has {
+		logger.Warn("Missing order_id parameter")
+		ctx.ApiError(http.StatusBadRequest, "order_id is required")
+		return
+	}
+
+	history, err := orders.GetOrderStatusHistory(ctx, orderId)
+	if err != nil {
+		if database.IsErrOrderNotFound(err) {
+			logger.Info("Order not found", zap.String("order_id", orderId))
+			ctx.ApiError(http.StatusNotFound, err.Error())
+			return
+		}
+		logger.Error("Failed to retrieve order status",
+			zap.String("order_id", orderId), zap.Error(err))
+		ctx.ApiError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
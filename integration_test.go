@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -20,13 +21,16 @@ import (
 
 	"github.com/IBM/sarama"
 
-	"wb-L0/models/pg_models"
+	"wb-L0/modules/migrations"
 	"wb-L0/modules/monitoring"
 	"wb-L0/modules/pg"
 	redispkg "wb-L0/modules/redis"
 	"wb-L0/routing"
 	"wb-L0/services/cache"
+	"wb-L0/services/codec"
 	"wb-L0/services/database"
+	"wb-L0/services/events"
+	"wb-L0/services/orderstatus"
 	"wb-L0/structs"
 )
 
@@ -83,15 +87,10 @@ func (suite *IntegrationTestSuite) setupTestDatabase() {
 	dsn := "host=localhost user=test_user password=test_pass dbname=test_db port=5433 sslmode=disable TimeZone=UTC"
 
 	var err error
-	suite.db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	suite.db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	require.NoError(suite.T(), err)
 
-	err = suite.db.AutoMigrate(
-		&pg_models.Order{},
-		&pg_models.OrderDelivery{},
-		&pg_models.OrderPayment{},
-		&pg_models.OrderItem{},
-	)
+	err = migrations.Run(context.Background(), suite.db)
 	require.NoError(suite.T(), err)
 }
 
@@ -152,7 +151,7 @@ func (suite *IntegrationTestSuite) setupTestServices() {
 
 	pgWrapper := &pg.Postgres{Db: suite.db}
 
-	dbService := database.NewPostgres(pgWrapper)
+	dbService := database.NewPostgres(pgWrapper, database.DuplicateReject)
 	database.SetDatabase(dbService)
 
 	redisWrapper := &redispkg.Redis{Client: suite.redisClient}
@@ -168,6 +167,8 @@ func (suite *IntegrationTestSuite) setupRouter() {
 }
 
 func (suite *IntegrationTestSuite) cleanupDatabase() {
+	suite.db.Exec("DELETE FROM outbox_event")
+	suite.db.Exec("DELETE FROM order_status_history")
 	suite.db.Exec("DELETE FROM order_item")
 	suite.db.Exec("DELETE FROM order_payment")
 	suite.db.Exec("DELETE FROM order_delivery")
@@ -582,6 +583,252 @@ func (suite *IntegrationTestSuite) TestPerformance() {
 	assert.Less(suite.T(), cacheTime, 50*time.Millisecond, "Cache hit took too long")
 }
 
+// TestCreateOrderEndpoint tests order creation over HTTP
+func (suite *IntegrationTestSuite) TestCreateOrderEndpoint() {
+	order := structs.Order{
+		OrderUid:        "http-created-order",
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerId:      "test_customer",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmId:            99,
+		DateCreated:     "2021-11-26T06:22:19Z",
+		OofShard:        "1",
+		Delivery: structs.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: structs.Payment{
+			Transaction:  "http-created-order",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []structs.Item{
+			{
+				ChrtId:      9934930,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       453,
+				Rid:         "ab4219087a764ae0btest",
+				Name:        "Mascaras",
+				Sale:        30,
+				Size:        "0",
+				TotalPrice:  317,
+				NmId:        2389212,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+		},
+	}
+	body, err := json.Marshal(order)
+	require.NoError(suite.T(), err)
+
+	// First submission creates the order and warms the cache
+	w1 := httptest.NewRecorder()
+	req1, _ := http.NewRequest("POST", "/api/order", bytes.NewReader(body))
+	req1.Header.Set("Content-Type", "application/json")
+	suite.router.ServeHTTP(w1, req1)
+	assert.Equal(suite.T(), http.StatusCreated, w1.Code)
+
+	// The response and the cache hold the order as stored, with its status
+	var created structs.Order
+	require.NoError(suite.T(), json.Unmarshal(w1.Body.Bytes(), &created))
+	assert.Equal(suite.T(), orderstatus.Created, created.Status)
+	cachedOrder, err := cache.GetCache().GetOrder(suite.ctx, order.OrderUid)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), order.OrderUid, cachedOrder.OrderUid)
+	assert.Equal(suite.T(), orderstatus.Created, cachedOrder.Status)
+
+	// Second submission is a duplicate
+	w2 := httptest.NewRecorder()
+	req2, _ := http.NewRequest("POST", "/api/order", bytes.NewReader(body))
+	req2.Header.Set("Content-Type", "application/json")
+	suite.router.ServeHTTP(w2, req2)
+	assert.Equal(suite.T(), http.StatusConflict, w2.Code)
+
+	// Invalid order is rejected with field errors
+	order.OrderUid = "http-invalid-order"
+	order.Delivery.Phone = "+7900123456789"
+	body, err = json.Marshal(order)
+	require.NoError(suite.T(), err)
+	w3 := httptest.NewRecorder()
+	req3, _ := http.NewRequest("POST", "/api/order", bytes.NewReader(body))
+	req3.Header.Set("Content-Type", "application/json")
+	suite.router.ServeHTTP(w3, req3)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w3.Code)
+
+	var validationErr structs.ValidationError
+	require.NoError(suite.T(), json.Unmarshal(w3.Body.Bytes(), &validationErr))
+	require.Len(suite.T(), validationErr.Fields, 1)
+	assert.Equal(suite.T(), "delivery.phone", validationErr.Fields[0].Field)
+}
+
+// TestListOrdersPagination tests cursor pagination and filtering of the order listing
+func (suite *IntegrationTestSuite) TestListOrdersPagination() {
+	suite.createTestOrder("list-order-1")
+	suite.createTestOrder("list-order-2")
+	suite.createTestOrder("list-order-3")
+
+	seen := make([]string, 0)
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/orders?customer_id=test_customer&brand=Vivienne%20Sabo&limit=2&cursor="+cursor, nil)
+		suite.router.ServeHTTP(w, req)
+		require.Equal(suite.T(), http.StatusOK, w.Code)
+
+		var orderPage structs.OrderPage
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &orderPage))
+		for _, order := range orderPage.Orders {
+			seen = append(seen, order.OrderUid)
+			assert.Len(suite.T(), order.Items, 1)
+		}
+		if orderPage.NextCursor == "" {
+			break
+		}
+		cursor = orderPage.NextCursor
+	}
+	// Orders share date_created, so the newest insert comes first
+	assert.Equal(suite.T(), []string{"list-order-3", "list-order-2", "list-order-1"}, seen)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/orders?provider=unknown", nil)
+	suite.router.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var emptyPage structs.OrderPage
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &emptyPage))
+	assert.Empty(suite.T(), emptyPage.Orders)
+}
+
+// TestGetOrdersByReference tests resolving orders by track number and payment transaction
+func (suite *IntegrationTestSuite) TestGetOrdersByReference() {
+	order := suite.createTestOrder("reference-order")
+
+	for _, path := range []string{
+		"/api/orders/by-track/" + order.TrackNumber,
+		"/api/orders/by-track/" + order.Items[0].TrackNumber,
+		"/api/orders/by-transaction/" + order.Payment.Transaction,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		suite.router.ServeHTTP(w, req)
+		require.Equal(suite.T(), http.StatusOK, w.Code, path)
+
+		var found []structs.Order
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &found))
+		require.Len(suite.T(), found, 1, path)
+		assert.Equal(suite.T(), order.OrderUid, found[0].OrderUid)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/orders/by-request/unknown-request", nil)
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// TestOrderStatusLifecycle tests status transitions and their history over HTTP
+func (suite *IntegrationTestSuite) TestOrderStatusLifecycle() {
+	order := suite.createTestOrder("status-order")
+	patch := func(status string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		body := []byte(`{"status":"` + status + `"}`)
+		req, _ := http.NewRequest("PATCH", "/api/order/"+order.OrderUid+"/status", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch("paid")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var history structs.OrderStatusHistory
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Equal(suite.T(), "paid", history.Status)
+	require.Len(suite.T(), history.History, 2)
+	assert.Equal(suite.T(), "created", history.History[0].To)
+	assert.Equal(suite.T(), "paid", history.History[1].To)
+
+	assert.Equal(suite.T(), http.StatusOK, patch("paid").Code)
+	assert.Equal(suite.T(), http.StatusConflict, patch("delivered").Code)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, patch("lost").Code)
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/order/"+order.OrderUid, nil)
+	suite.router.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var stored structs.Order
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &stored))
+	assert.Equal(suite.T(), "paid", stored.Status)
+}
+
+// TestOutboxEvents tests that order changes are published from the outbox in order
+func (suite *IntegrationTestSuite) TestOutboxEvents() {
+	order := suite.createTestOrder("outbox-order")
+	err := database.GetDatabase().UpdateOrderStatus(suite.ctx, order.OrderUid, &structs.OrderStatusChange{
+		Status: "paid",
+		Source: "api",
+	})
+	require.NoError(suite.T(), err)
+
+	publisher := events.NewMemoryPublisher(10)
+	published, err := database.GetDatabase().PublishOutbox(suite.ctx, 10, publisher.Publish)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, published)
+
+	delivered := publisher.Events()
+	require.Len(suite.T(), delivered, 2)
+	assert.Equal(suite.T(), structs.EventOrderCreated, delivered[0].Type)
+	require.NotNil(suite.T(), delivered[0].Order)
+	assert.Equal(suite.T(), order.OrderUid, delivered[0].Order.OrderUid)
+	assert.Equal(suite.T(), structs.EventOrderUpdated, delivered[1].Type)
+	assert.Equal(suite.T(), "paid", delivered[1].Status)
+
+	published, err = database.GetDatabase().PublishOutbox(suite.ctx, 10, publisher.Publish)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, published)
+}
+
+// TestKafkaMessageCodecs tests producing orders as Protobuf and Avro with a content-type header
+func (suite *IntegrationTestSuite) TestKafkaMessageCodecs() {
+	if suite.kafkaProducer == nil {
+		suite.T().Skip("Kafka not available, skipping Kafka codec test")
+		return
+	}
+
+	for _, contentType := range []string{codec.ContentTypeProtobuf, codec.ContentTypeAvro} {
+		messageCodec, err := codec.GetRegistry().Lookup(contentType)
+		require.NoError(suite.T(), err)
+		order := suite.createTestOrder("codec-test-order")
+		payload, err := messageCodec.Encode(order)
+		require.NoError(suite.T(), err)
+
+		decoded, err := codec.GetRegistry().Decode(contentType, payload)
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), order.OrderUid, decoded.OrderUid)
+
+		msg := &sarama.ProducerMessage{
+			Topic: "orders",
+			Key:   sarama.StringEncoder(order.OrderUid),
+			Value: sarama.ByteEncoder(payload),
+			Headers: []sarama.RecordHeader{
+				{Key: []byte(codec.ContentTypeHeader), Value: []byte(contentType)},
+			},
+		}
+		_, _, err = suite.kafkaProducer.SendMessage(msg)
+		require.NoError(suite.T(), err)
+	}
+}
+
 // TestKafkaIntegration tests Kafka messaging functionality
 func (suite *IntegrationTestSuite) TestKafkaIntegration() {
 	if suite.kafkaProducer == nil {
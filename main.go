@@ -1,17 +1,15 @@
 package main
 
 import (
-	"context"
-	"time"
+	"log"
+	"os"
 
-	"wb-L0/modules/graceful"
-	"wb-L0/modules/initializer"
+	"wb-L0/modules/cli"
 )
 
 func main() {
-	initializer.Init()
-	<-graceful.GetContext().Done()
-	ctx, cancel := context.WithTimeout(graceful.GetContext(), time.Second*5)
-	defer cancel()
-	initializer.Shutdown(ctx)
+	err := cli.Run(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -2,26 +2,31 @@ package pg_models
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"wb-L0/modules/pg"
 )
 
 type Order struct {
-	Id                int64          `gorm:"primaryKey;autoIncrement"`
+	Id                int64          `gorm:"primaryKey;autoIncrement;index:idx_order_date_created_id,priority:2"`
 	Uid               string         `gorm:"type:varchar(50);not null;unique"`
-	TrackNumber       string         `gorm:"type:varchar(100)"`
+	TrackNumber       string         `gorm:"type:varchar(100);index"`
 	Entry             string         `gorm:"type:varchar(50)"`
-	Delivery          *OrderDelivery `gorm:"-"`
-	Payment           *OrderPayment  `gorm:"-"`
-	Items             []*OrderItem   `gorm:"-"`
-	Locale            string         `gorm:"type:varchar(5)"`
+	Delivery          *OrderDelivery `gorm:"foreignKey:OrderId;constraint:OnDelete:CASCADE"`
+	Payment           *OrderPayment  `gorm:"foreignKey:OrderId;constraint:OnDelete:CASCADE"`
+	Items             []*OrderItem   `gorm:"foreignKey:OrderId;constraint:OnDelete:CASCADE"`
+	Locale            string         `gorm:"type:varchar(5);index"`
 	InternalSignature string         `gorm:"type:text"`
-	CustomerId        string         `gorm:"type:varchar(50)"`
-	DeliveryService   string         `gorm:"type:varchar(50)"`
+	CustomerId        string         `gorm:"type:varchar(50);index"`
+	DeliveryService   string         `gorm:"type:varchar(50);index"`
 	Shardkey          string         `gorm:"type:varchar(50)"`
 	SmId              int            `gorm:"type:integer"`
-	DateCreated       int64          `gorm:"type:bigint"`
+	DateCreated       int64          `gorm:"type:bigint;index:idx_order_date_created_id,priority:1"`
 	OofShard          string         `gorm:"type:varchar(5)"`
+	ContentHash       string         `gorm:"type:varchar(64)"`
+	Status            string         `gorm:"type:varchar(20);not null;default:'created';index"`
+	// History is only written on insert; it is never loaded with the order
+	History []*OrderStatusHistory `gorm:"foreignKey:OrderId;constraint:OnDelete:CASCADE"`
 }
 
 func (*Order) TableName() string {
@@ -32,63 +37,134 @@ func init() {
 	pg.RegisterModel(new(Order))
 }
 
+// InsertOrder creates the order row and then its associations: one INSERT
+// each for the delivery, the payment and the status history, and one
+// multi-row INSERT for all items
 func InsertOrder(db *gorm.DB, order *Order) error {
 	err := db.Create(order).Error
 	return err
 }
 
-func GetOrderById(db *gorm.DB, uid string) (*Order, error) {
+// InsertOrders creates the orders and their associations with multi-row
+// inserts of at most batchSize rows per statement
+func InsertOrders(db *gorm.DB, orders []*Order, batchSize int) error {
+	err := db.CreateInBatches(orders, batchSize).Error
+	return err
+}
+
+// GetOrderForUpdate returns the bare order row and locks it until the end of
+// the transaction
+func GetOrderForUpdate(db *gorm.DB, uid string) (*Order, error) {
 	order := new(Order)
-	err := db.Where(&Order{Uid: uid}).First(order).Error
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&Order{Uid: uid}).First(order).Error
 	if err != nil {
 		return nil, err
 	}
-	if order.Id == 0 {
-		return nil, gorm.ErrRecordNotFound
-	}
 	return order, nil
 }
 
-func (order *Order) loadPayment(db *gorm.DB) error {
-	payment, err := GetOrderPaymentByOrderId(db, order.Id)
+// GetOrdersForUpdate returns the bare rows of the stored orders among uids and
+// locks them until the end of the transaction
+func GetOrdersForUpdate(db *gorm.DB, uids []string) ([]*Order, error) {
+	orders := make([]*Order, 0, len(uids))
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uid IN ?", uids).Find(&orders).Error
 	if err != nil {
-		return err
+		return nil, err
 	}
-	order.Payment = payment
-	return nil
+	return orders, nil
 }
 
-func (order *Order) loadDelivery(db *gorm.DB) error {
-	delivery, err := GetDeliveryByOrderId(db, order.Id)
+// ReplaceOrder overwrites the stored order with id and recreates its delivery,
+// payment and items from order
+func ReplaceOrder(db *gorm.DB, id int64, order *Order) error {
+	err := db.Where(&OrderDelivery{OrderId: id}).Delete(&OrderDelivery{}).Error
+	if err != nil {
+		return err
+	}
+	err = db.Where(&OrderPayment{OrderId: id}).Delete(&OrderPayment{}).Error
+	if err != nil {
+		return err
+	}
+	err = db.Where(&OrderItem{OrderId: id}).Delete(&OrderItem{}).Error
 	if err != nil {
 		return err
 	}
-	order.Delivery = delivery
-	return nil
+	order.Id = id
+	return db.Save(order).Error
 }
 
-func (order *Order) loadItems(db *gorm.DB) error {
-	items, err := GetOrderItemsByOrderId(db, order.Id)
+// GetOrderByUid returns the bare order row without its attributes
+func GetOrderByUid(db *gorm.DB, uid string) (*Order, error) {
+	order := new(Order)
+	err := db.Where(&Order{Uid: uid}).First(order).Error
 	if err != nil {
-		return err
+		return nil, err
 	}
-	order.Items = items
-	return nil
+	return order, nil
+}
+
+func UpdateOrderStatus(db *gorm.DB, id int64, status string) error {
+	err := db.Model(&Order{Id: id}).Update("status", status).Error
+	return err
 }
 
-func (order *Order) LoadAttributes(db *gorm.DB) error {
-	var err error
-	err = order.loadPayment(db)
+// withAttributes loads delivery and payment in the same query as the order
+// through LEFT JOINs and items with one extra query for all selected orders
+func withAttributes(db *gorm.DB) *gorm.DB {
+	return db.Joins("Delivery").Joins("Payment").Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+}
+
+// GetOrderById returns the order with its delivery, payment and items in two
+// round-trips
+func GetOrderById(db *gorm.DB, uid string) (*Order, error) {
+	order := new(Order)
+	err := withAttributes(db).Where(`"order".uid = ?`, uid).First(order).Error
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = order.loadDelivery(db)
+	if order.Id == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return order, nil
+}
+
+// GetOrdersByUids returns the orders with their attributes in two round-trips
+// regardless of how many uids are requested
+func GetOrdersByUids(db *gorm.DB, uids []string) ([]*Order, error) {
+	orders := make([]*Order, 0, len(uids))
+	err := withAttributes(db).Where(`"order".uid IN ?`, uids).Find(&orders).Error
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = order.loadItems(db)
+	return orders, nil
+}
+
+// GetOrderUidsByTrackNumber returns uids of orders whose own or item track
+// number matches
+func GetOrderUidsByTrackNumber(db *gorm.DB, trackNumber string) ([]string, error) {
+	uids := make([]string, 0)
+	err := db.Model(&Order{}).
+		Where(`track_number = ? OR id IN (SELECT order_id FROM order_item WHERE track_number = ?)`,
+			trackNumber, trackNumber).
+		Order("id").
+		Pluck("uid", &uids).Error
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return uids, nil
+}
+
+// GetOrderUidsByPayment returns uids of orders whose payment matches
+func GetOrderUidsByPayment(db *gorm.DB, payment *OrderPayment) ([]string, error) {
+	uids := make([]string, 0)
+	err := db.Model(&Order{}).
+		Where("id IN (?)", db.Model(&OrderPayment{}).Select("order_id").Where(payment)).
+		Order("id").
+		Pluck("uid", &uids).Error
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return uids, nil
 }
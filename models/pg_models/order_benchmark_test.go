@@ -0,0 +1,106 @@
+package pg_models
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// benchmarkDSN points at the integration test database from docker-compose.test.yml
+const benchmarkDSN = "host=localhost user=test_user password=test_pass dbname=test_db port=5433 sslmode=disable TimeZone=UTC"
+
+const benchmarkOrderUid = "benchmark-order"
+
+func openBenchmarkDatabase(b *testing.B) *gorm.DB {
+	dsn := os.Getenv("BENCH_DB_DSN")
+	if dsn == "" {
+		dsn = benchmarkDSN
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		b.Skipf("Postgres not available, skipping benchmark: %v", err)
+	}
+	err = db.AutoMigrate(&Order{}, &OrderDelivery{}, &OrderPayment{}, &OrderItem{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	db.Where(&Order{Uid: benchmarkOrderUid}).Delete(&Order{})
+
+	items := make([]*OrderItem, 5)
+	for i := range items {
+		items[i] = &OrderItem{
+			ChrtId: int64(i + 1), TrackNumber: "BENCHTRACK", Price: 100, Rid: fmt.Sprintf("rid-%d", i),
+			Name: "Item", Size: "0", TotalPrice: 100, NmId: int64(i + 1), Brand: "Brand", Status: 202,
+		}
+	}
+	err = db.Create(&Order{
+		Uid:         benchmarkOrderUid,
+		TrackNumber: "BENCHTRACK",
+		Delivery: &OrderDelivery{
+			Name: "Test", Phone: "+9720000000", Zip: "2639809", City: "City",
+			Address: "Address", Region: "Region", Email: "test@gmail.com",
+		},
+		Payment: &OrderPayment{
+			Transaction: benchmarkOrderUid, Currency: "USD", Provider: "wbpay",
+			Amount: 500, Bank: "alpha", GoodsTotal: 500,
+		},
+		Items: items,
+	}).Error
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		db.Where(&Order{Uid: benchmarkOrderUid}).Delete(&Order{})
+	})
+	return db
+}
+
+// loadSequentially is the previous loading path: the order row followed by one
+// query each for payment, delivery and items
+func loadSequentially(db *gorm.DB, uid string) (*Order, error) {
+	order := new(Order)
+	err := db.Where(&Order{Uid: uid}).First(order).Error
+	if err != nil {
+		return nil, err
+	}
+	order.Payment = new(OrderPayment)
+	err = db.Where(&OrderPayment{OrderId: order.Id}).First(order.Payment).Error
+	if err != nil {
+		return nil, err
+	}
+	order.Delivery = new(OrderDelivery)
+	err = db.Where(&OrderDelivery{OrderId: order.Id}).First(order.Delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	order.Items = make([]*OrderItem, 0)
+	err = db.Where(&OrderItem{OrderId: order.Id}).Find(&order.Items).Error
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func BenchmarkGetOrderSequential(b *testing.B) {
+	db := openBenchmarkDatabase(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := loadSequentially(db, benchmarkOrderUid); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetOrderJoined(b *testing.B) {
+	db := openBenchmarkDatabase(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := GetOrderById(db, benchmarkOrderUid); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,14 +1,10 @@
 package pg_models
 
-import (
-	"gorm.io/gorm"
-
-	"wb-L0/modules/pg"
-)
+import "wb-L0/modules/pg"
 
 type OrderDelivery struct {
 	Id      int64  `gorm:"primaryKey;autoIncrement"`
-	OrderId int64  `gorm:"type:int;not null"`
+	OrderId int64  `gorm:"type:bigint;not null;index"`
 	Name    string `gorm:"type:varchar(50);not null"`
 	Phone   string `gorm:"type:varchar(12);not null"`
 	Zip     string `gorm:"type:varchar(12);not null"`
@@ -25,14 +21,3 @@ func (OrderDelivery) TableName() string {
 func init() {
 	pg.RegisterModel(new(OrderDelivery))
 }
-
-func InsertOrderDelivery(db *gorm.DB, delivery *OrderDelivery) error {
-	err := db.Create(delivery).Error
-	return err
-}
-
-func GetDeliveryByOrderId(db *gorm.DB, oid int64) (*OrderDelivery, error) {
-	delivery := new(OrderDelivery)
-	err := db.Where(&OrderDelivery{OrderId: oid}).First(delivery).Error
-	return delivery, err
-}
@@ -0,0 +1,77 @@
+package pg_models
+
+import (
+	"gorm.io/gorm"
+)
+
+// OrderListFilter narrows an order listing. Zero values disable a filter.
+// Results are ordered newest first by (DateCreated, Id); when AfterId is set
+// only orders strictly after the (AfterDateCreated, AfterId) position are
+// returned.
+type OrderListFilter struct {
+	CustomerId       string
+	TrackNumber      string
+	DeliveryService  string
+	Locale           string
+	DateFrom         int64
+	DateTo           int64
+	Provider         string
+	Bank             string
+	Brand            string
+	NmId             int64
+	Status           string
+	AfterDateCreated int64
+	AfterId          int64
+	Limit            int
+}
+
+func ListOrders(db *gorm.DB, filter *OrderListFilter) ([]*Order, error) {
+	query := db.Model(&Order{})
+	if filter.CustomerId != "" {
+		query = query.Where(`"order".customer_id = ?`, filter.CustomerId)
+	}
+	if filter.TrackNumber != "" {
+		query = query.Where(`"order".track_number = ?`, filter.TrackNumber)
+	}
+	if filter.DeliveryService != "" {
+		query = query.Where(`"order".delivery_service = ?`, filter.DeliveryService)
+	}
+	if filter.Locale != "" {
+		query = query.Where(`"order".locale = ?`, filter.Locale)
+	}
+	if filter.Status != "" {
+		query = query.Where(`"order".status = ?`, filter.Status)
+	}
+	if filter.DateFrom != 0 {
+		query = query.Where(`"order".date_created >= ?`, filter.DateFrom)
+	}
+	if filter.DateTo != 0 {
+		query = query.Where(`"order".date_created <= ?`, filter.DateTo)
+	}
+	if filter.Provider != "" {
+		query = query.Where(`EXISTS (SELECT 1 FROM order_payment p WHERE p.order_id = "order".id AND p.provider = ?)`,
+			filter.Provider)
+	}
+	if filter.Bank != "" {
+		query = query.Where(`EXISTS (SELECT 1 FROM order_payment p WHERE p.order_id = "order".id AND p.bank = ?)`,
+			filter.Bank)
+	}
+	if filter.Brand != "" {
+		query = query.Where(`EXISTS (SELECT 1 FROM order_item i WHERE i.order_id = "order".id AND i.brand = ?)`,
+			filter.Brand)
+	}
+	if filter.NmId != 0 {
+		query = query.Where(`EXISTS (SELECT 1 FROM order_item i WHERE i.order_id = "order".id AND i.nm_id = ?)`,
+			filter.NmId)
+	}
+	if filter.AfterId != 0 {
+		query = query.Where(`("order".date_created, "order".id) < (?, ?)`, filter.AfterDateCreated, filter.AfterId)
+	}
+
+	orders := make([]*Order, 0, filter.Limit)
+	err := withAttributes(query).Order(`"order".date_created DESC, "order".id DESC`).Limit(filter.Limit).Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
@@ -1,21 +1,17 @@
 package pg_models
 
-import (
-	"gorm.io/gorm"
-
-	"wb-L0/modules/pg"
-)
+import "wb-L0/modules/pg"
 
 type OrderPayment struct {
 	Id           int64  `gorm:"primaryKey;autoIncrement"`
-	OrderId      int64  `gorm:"type:int;not null"`
-	Transaction  string `gorm:"type:varchar(50);not null"`
-	RequestId    string `gorm:"type:varchar(50)"`
+	OrderId      int64  `gorm:"type:bigint;not null;index"`
+	Transaction  string `gorm:"type:varchar(50);not null;index"`
+	RequestId    string `gorm:"type:varchar(50);index"`
 	Currency     string `gorm:"type:varchar(5);not null"`
-	Provider     string `gorm:"type:varchar(50);not null"`
+	Provider     string `gorm:"type:varchar(50);not null;index"`
 	Amount       int    `gorm:"type:int;not null"`
 	PaymentDt    int64  `gorm:"type:int;not null"`
-	Bank         string `gorm:"type:varchar(50);not null"`
+	Bank         string `gorm:"type:varchar(50);not null;index"`
 	DeliveryCost int    `gorm:"type:int;not null"`
 	GoodsTotal   int    `gorm:"type:int;not null"`
 	CustomFee    int    `gorm:"type:int"`
@@ -28,14 +24,3 @@ func (OrderPayment) TableName() string {
 func init() {
 	pg.RegisterModel(new(OrderPayment))
 }
-
-func InsertOrderPayment(db *gorm.DB, orderPayment *OrderPayment) error {
-	err := db.Create(orderPayment).Error
-	return err
-}
-
-func GetOrderPaymentByOrderId(db *gorm.DB, orderId int64) (*OrderPayment, error) {
-	orderPayment := new(OrderPayment)
-	err := db.Where(&OrderPayment{OrderId: orderId}).First(orderPayment).Error
-	return orderPayment, err
-}
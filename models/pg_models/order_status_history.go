@@ -0,0 +1,40 @@
+package pg_models
+
+import (
+	"gorm.io/gorm"
+
+	"wb-L0/modules/pg"
+)
+
+type OrderStatusHistory struct {
+	Id         int64  `gorm:"primaryKey;autoIncrement"`
+	OrderId    int64  `gorm:"type:bigint;not null;index"`
+	FromStatus string `gorm:"type:varchar(20)"`
+	ToStatus   string `gorm:"type:varchar(20);not null"`
+	Reason     string `gorm:"type:varchar(255)"`
+	Source     string `gorm:"type:varchar(20)"`
+	ChangedAt  int64  `gorm:"type:bigint"`
+}
+
+func (*OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+func init() {
+	pg.RegisterModel(new(OrderStatusHistory))
+}
+
+func InsertOrderStatusHistory(db *gorm.DB, entry *OrderStatusHistory) error {
+	err := db.Create(entry).Error
+	return err
+}
+
+// GetOrderStatusHistory returns the status changes of an order, oldest first
+func GetOrderStatusHistory(db *gorm.DB, oid int64) ([]*OrderStatusHistory, error) {
+	entries := make([]*OrderStatusHistory, 0)
+	err := db.Where(&OrderStatusHistory{OrderId: oid}).Order("id").Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,73 @@
+package pg_models
+
+import (
+	"gorm.io/gorm"
+
+	"wb-L0/modules/pg"
+)
+
+// OutboxEvent is an event written in the same transaction as the change it
+// describes. PublishedAt stays nil until the relay has delivered it.
+type OutboxEvent struct {
+	Id          int64  `gorm:"primaryKey;autoIncrement"`
+	EventId     string `gorm:"type:varchar(36);not null;unique"`
+	EventType   string `gorm:"type:varchar(50);not null"`
+	AggregateId string `gorm:"type:varchar(50);not null;index"`
+	Payload     []byte `gorm:"type:jsonb;not null"`
+	CreatedAt   int64  `gorm:"type:bigint;not null"`
+	PublishedAt *int64 `gorm:"type:bigint;index"`
+}
+
+func (*OutboxEvent) TableName() string {
+	return "outbox_event"
+}
+
+func init() {
+	pg.RegisterModel(new(OutboxEvent))
+}
+
+func InsertOutboxEvents(db *gorm.DB, events []*OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	err := db.Create(events).Error
+	return err
+}
+
+// GetUnpublishedOutboxEvents returns the oldest undelivered events in the
+// order they were written
+func GetUnpublishedOutboxEvents(db *gorm.DB, limit int) ([]*OutboxEvent, error) {
+	events := make([]*OutboxEvent, 0, limit)
+	err := db.Where("published_at IS NULL").Order("id").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func MarkOutboxEventsPublished(db *gorm.DB, ids []int64, publishedAt int64) error {
+	err := db.Model(&OutboxEvent{}).Where("id IN ?", ids).Update("published_at", publishedAt).Error
+	return err
+}
+
+// DeletePublishedOutboxEvents removes events delivered before the given time
+func DeletePublishedOutboxEvents(db *gorm.DB, before int64) (int64, error) {
+	result := db.Where("published_at < ?", before).Delete(&OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// TryOutboxLock takes a transaction-scoped advisory lock so only one relay
+// publishes at a time and events keep their order. It reports false when
+// another relay holds the lock. SQLite has no advisory locks but runs one
+// transaction at a time, so the lock is always granted there.
+func TryOutboxLock(db *gorm.DB) (bool, error) {
+	if db.Dialector.Name() != "postgres" {
+		return true, nil
+	}
+	var locked bool
+	err := db.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxLockKey).Scan(&locked).Error
+	return locked, err
+}
+
+// outboxLockKey identifies the relay advisory lock
+const outboxLockKey = 7310901
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wb-L0/modules/graceful"
+	"wb-L0/modules/initializer"
+)
+
+const usage = `usage: wb-L0 [command] [arguments]
+
+commands:
+  serve                     run the APP_ROLE parts, the HTTP API and the consumer
+                            by default
+  api                       serve the HTTP API only
+  consume                   consume orders only
+  migrate [up|down [steps]|status]
+                            manage the database schema
+  produce --file orders.jsonl [--topic topic]
+                            publish one order per line to the broker
+  get <order_uid>           print a stored order as JSON
+  healthcheck [--ready] [--url url]
+                            probe a running service, for container health checks`
+
+// shutdownTimeout bounds the graceful shutdown of a serving process
+const shutdownTimeout = 5 * time.Second
+
+// Run executes the command named by the first argument
+func Run(args []string) error {
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	switch command {
+	case "serve":
+		return serve("")
+	case "api":
+		return serve(initializer.RoleApi)
+	case "consume":
+		return serve(initializer.RoleConsumer)
+	case "migrate":
+		return initializer.Migrate(args)
+	case "produce":
+		return produce(args)
+	case "get":
+		return get(args)
+	case "healthcheck":
+		return healthcheck(args)
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %s\n%s", command, usage)
+	}
+}
+
+// serve runs the units of the role until a shutdown signal; an empty role
+// uses APP_ROLE
+func serve(role initializer.Role) error {
+	initializer.Init(role)
+	<-graceful.GetContext().Done()
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	initializer.Shutdown(ctx)
+	return nil
+}
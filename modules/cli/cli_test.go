@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMessages(t *testing.T) {
+	input := strings.NewReader("{\"order_uid\": \"a\", \"track_number\": \"T1\"}\n\n  \n{\"track_number\": \"T2\"}\n")
+	messages, err := readMessages(input, "orders")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "a", string(messages[0].Key))
+	assert.Equal(t, `{"order_uid": "a", "track_number": "T1"}`, string(messages[0].Value))
+	assert.Equal(t, "orders", messages[0].Topic)
+	assert.Nil(t, messages[1].Key)
+
+	_, err = readMessages(strings.NewReader("{\"order_uid\": \"a\"}\nnot json\n"), "")
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestProbe(t *testing.T) {
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(t, probe(server.URL))
+	healthy = false
+	assert.Error(t, probe(server.URL))
+	server.Close()
+	assert.Error(t, probe(server.URL))
+}
+
+func TestProbeFirst(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer healthy.Close()
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+	closed := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	closed.Close()
+
+	assert.NoError(t, probeFirst([]string{closed.URL, healthy.URL}))
+	assert.Error(t, probeFirst([]string{unhealthy.URL, healthy.URL}))
+	assert.Error(t, probeFirst([]string{closed.URL}))
+}
+
+func TestRunRejectsUnknownCommand(t *testing.T) {
+	assert.ErrorContains(t, Run([]string{"deploy"}), "unknown command deploy")
+	assert.ErrorContains(t, Run([]string{"get"}), "usage: get")
+	assert.ErrorContains(t, Run([]string{"produce"}), "requires --file")
+}
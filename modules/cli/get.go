@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"wb-L0/modules/initializer"
+	"wb-L0/services/database"
+)
+
+// get prints a stored order as indented JSON
+func get(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: get <order_uid>")
+	}
+	err := initializer.LoadConfig()
+	if err != nil {
+		return err
+	}
+	db, closeDatabase, err := initializer.OpenDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase()
+	order, err := db.GetOrderById(context.Background(), args[0])
+	if err != nil {
+		if database.IsErrOrderNotFound(err) {
+			return fmt.Errorf("order %s not found", args[0])
+		}
+		return err
+	}
+	data, err := json.MarshalIndent(order, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
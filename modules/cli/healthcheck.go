@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"syscall"
+	"time"
+
+	"wb-L0/modules/config"
+	"wb-L0/modules/initializer"
+)
+
+// healthcheckTimeout bounds a single probe
+const healthcheckTimeout = 3 * time.Second
+
+// defaultMetricsPort matches the metrics server default
+const defaultMetricsPort = 8081
+
+// healthcheck probes the liveness, or with --ready the readiness, endpoint of
+// a local service and fails unless it answers 200. A consumer-only service
+// (APP_ROLE=consumer) is probed on METRICS_PORT, any other on APP_PORT and,
+// when nothing listens there, on METRICS_PORT: the consume subcommand runs
+// without the HTTP server whatever APP_ROLE says.
+func healthcheck(args []string) error {
+	flags := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	ready := flags.Bool("ready", false, "probe readiness instead of liveness")
+	url := flags.String("url", "", "endpoint to probe, the local service by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *url == "" {
+		err := initializer.LoadConfig()
+		if err != nil {
+			return err
+		}
+		path := "/health/live"
+		if *ready {
+			path = "/health/ready"
+		}
+		ports, err := probePorts()
+		if err != nil {
+			return err
+		}
+		urls := make([]string, len(ports))
+		for i, port := range ports {
+			urls[i] = fmt.Sprintf("http://127.0.0.1:%d%s", port, path)
+		}
+		return probeFirst(urls)
+	}
+	return probe(*url)
+}
+
+// probePorts returns the ports that may serve the health endpoints of the
+// configured role, in the order to probe them
+func probePorts() ([]int, error) {
+	role, err := initializer.ParseRole(config.GetConfig().AppRole)
+	if err != nil {
+		return nil, err
+	}
+	metricsPort := config.GetConfig().MetricsPort
+	if metricsPort <= 0 {
+		metricsPort = defaultMetricsPort
+	}
+	if role == initializer.RoleConsumer {
+		return []int{metricsPort}, nil
+	}
+	return []int{config.GetConfig().AppPort, metricsPort}, nil
+}
+
+// probeFirst probes the urls in order and moves on to the next only while
+// nothing listens at the current one. A service that answers is never
+// skipped, whatever its answer.
+func probeFirst(urls []string) error {
+	var err error
+	for _, url := range urls {
+		err = probe(url)
+		if !errors.Is(err, syscall.ECONNREFUSED) {
+			return err
+		}
+	}
+	return err
+}
+
+func probe(url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthcheckTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed: %s returned %s", url, response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"wb-L0/modules/initializer"
+	"wb-L0/services/broker"
+)
+
+// maxLineSize bounds a single order in a produce file
+const maxLineSize = 10 << 20
+
+// produce publishes the orders of a JSON lines file, keyed by order uid
+func produce(args []string) error {
+	flags := flag.NewFlagSet("produce", flag.ContinueOnError)
+	file := flags.String("file", "", "JSON lines file with one order per line, - for stdin")
+	topic := flags.String("topic", "", "topic to publish to, the order topic by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("produce requires --file")
+	}
+	input := os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+	messages, err := readMessages(input, *topic)
+	if err != nil {
+		return err
+	}
+
+	err = initializer.LoadConfig()
+	if err != nil {
+		return err
+	}
+	instance, closeBroker, err := initializer.OpenBroker()
+	if err != nil {
+		return err
+	}
+	defer closeBroker()
+	pusher, ok := instance.(broker.Pusher)
+	if !ok {
+		return fmt.Errorf("broker does not accept published messages")
+	}
+	ctx := context.Background()
+	for i, message := range messages {
+		_, err = pusher.Push(ctx, message)
+		if err != nil {
+			return fmt.Errorf("failed to publish message %d of %d: %v", i+1, len(messages), err)
+		}
+	}
+	fmt.Printf("published %d messages\n", len(messages))
+	return nil
+}
+
+// readMessages turns every non-blank line into a message keyed by its
+// order_uid
+func readMessages(input io.Reader, topic string) ([]broker.Message, error) {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	messages := make([]broker.Message, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		value := bytes.TrimSpace(scanner.Bytes())
+		if len(value) == 0 {
+			continue
+		}
+		var order struct {
+			OrderUid string `json:"order_uid"`
+		}
+		if err := json.Unmarshal(value, &order); err != nil {
+			return nil, fmt.Errorf("line %d is not a JSON order: %v", line, err)
+		}
+		message := broker.Message{
+			Value: append([]byte(nil), value...),
+			Topic: topic,
+		}
+		if order.OrderUid != "" {
+			message.Key = []byte(order.OrderUid)
+		}
+		messages = append(messages, message)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
@@ -13,17 +13,50 @@ var conf *Config
 type Config struct {
 	AppPort            int    `mapstructure:"APP_PORT"`
 	RunMode            string `mapstructure:"RUN_MODE"`
+	AppRole            string `mapstructure:"APP_ROLE"`
+	AdminToken         string `mapstructure:"ADMIN_TOKEN"`
 	DbType             string `mapstructure:"DB_TYPE"`
 	DbHost             string `mapstructure:"DB_HOST"`
 	DbPort             int    `mapstructure:"DB_PORT"`
 	DbUser             string `mapstructure:"DB_USER"`
 	DbPass             string `mapstructure:"DB_PASS"`
 	DbName             string `mapstructure:"DB_NAME"`
+	SqlitePath         string `mapstructure:"SQLITE_PATH"`
+	DbSkipMigrations   bool   `mapstructure:"DB_SKIP_MIGRATIONS"`
 	BrokerType         string `mapstructure:"BROKER_TYPE"`
 	KafkaUrl           string `mapstructure:"KAFKA_URL"`
 	KafkaConsumerGroup string `mapstructure:"KAFKA_CONSUMER_GROUP"`
 	KafkaTopic         string `mapstructure:"KAFKA_TOPIC"`
+	KafkaDlqTopic      string `mapstructure:"KAFKA_DLQ_TOPIC"`
+	KafkaStatusTopic   string `mapstructure:"KAFKA_STATUS_TOPIC"`
+	KafkaEventsTopic   string `mapstructure:"KAFKA_EVENTS_TOPIC"`
+	NatsUrl            string `mapstructure:"NATS_URL"`
+	NatsStream         string `mapstructure:"NATS_STREAM"`
+	NatsSubject        string `mapstructure:"NATS_SUBJECT"`
+	NatsConsumer       string `mapstructure:"NATS_CONSUMER"`
+	RabbitMqUrl        string `mapstructure:"RABBITMQ_URL"`
+	RabbitMqQueue      string `mapstructure:"RABBITMQ_QUEUE"`
+	RabbitMqPrefetch   int    `mapstructure:"RABBITMQ_PREFETCH"`
+	FileBrokerPath     string `mapstructure:"FILE_BROKER_PATH"`
+	EventsType         string `mapstructure:"EVENTS_TYPE"`
+	OutboxPollMs       int    `mapstructure:"OUTBOX_POLL_MS"`
+	OutboxBatchSize    int    `mapstructure:"OUTBOX_BATCH_SIZE"`
+	OutboxRetention    int    `mapstructure:"OUTBOX_RETENTION_HOURS"`
+	DlqType            string `mapstructure:"DLQ_TYPE"`
+	DlqFilePath        string `mapstructure:"DLQ_FILE_PATH"`
+	ConsumerBatchSize  int    `mapstructure:"CONSUMER_BATCH_SIZE"`
+	ConsumerBatchWait  int    `mapstructure:"CONSUMER_BATCH_WAIT_MS"`
+	ConsumerWorkers    int    `mapstructure:"CONSUMER_WORKERS"`
+	ConsumerOrderBy    string `mapstructure:"CONSUMER_ORDER_BY"`
+	DuplicatePolicy    string `mapstructure:"DUPLICATE_POLICY"`
+	AvroSchemaDir      string `mapstructure:"AVRO_SCHEMA_DIR"`
 	CacheType          string `mapstructure:"CACHE_TYPE"`
+	CacheCapacity      int    `mapstructure:"CACHE_CAPACITY"`
+	CacheMaxMb         int    `mapstructure:"CACHE_MAX_MB"`
+	CacheTtlSeconds    int    `mapstructure:"CACHE_TTL_SECONDS"`
+	CacheNotFoundTtlMs int    `mapstructure:"CACHE_NOT_FOUND_TTL_MS"`
+	CacheWarmupCount   int    `mapstructure:"CACHE_WARMUP_COUNT"`
+	CacheWarmupIds     string `mapstructure:"CACHE_WARMUP_IDS"`
 	RedisHost          string `mapstructure:"REDIS_HOST"`
 	RedisPort          int    `mapstructure:"REDIS_PORT"`
 	RedisPass          string `mapstructure:"REDIS_PASS"`
@@ -49,10 +82,21 @@ func (c *Config) Init(_ chan error) error {
 	if err := v.Unmarshal(c); err != nil {
 		return fmt.Errorf("unable to decode into struct: %v", err)
 	}
+	if err := c.validate(); err != nil {
+		return err
+	}
 	conf = c
 	return nil
 }
 
+// validate rejects settings that contradict each other
+func (c *Config) validate() error {
+	if c.ConsumerBatchSize > 1 && c.ConsumerWorkers > 1 {
+		return fmt.Errorf("CONSUMER_BATCH_SIZE and CONSUMER_WORKERS cannot both be greater than 1")
+	}
+	return nil
+}
+
 func (c *Config) SuccessfulMessage() string {
 	return "Config successfully initialized"
 }
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInitRejectsBatchingWorkers tests that batching and workers cannot be combined
+func TestInitRejectsBatchingWorkers(t *testing.T) {
+	t.Setenv("APP_PORT", "8080")
+	t.Setenv("CONSUMER_BATCH_SIZE", "100")
+	t.Setenv("CONSUMER_WORKERS", "4")
+	assert.Error(t, new(Config).Init(nil))
+
+	t.Setenv("CONSUMER_WORKERS", "1")
+	assert.NoError(t, new(Config).Init(nil))
+}
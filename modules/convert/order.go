@@ -24,9 +24,28 @@ func PgToApiOrder(order *pg_models.Order) *structs.Order {
 		SmId:              order.SmId,
 		DateCreated:       parsedTime,
 		OofShard:          order.OofShard,
+		Status:            order.Status,
 	}
 }
 
+func PgToApiStatusEntry(entry *pg_models.OrderStatusHistory) *structs.OrderStatusEntry {
+	return &structs.OrderStatusEntry{
+		From:      entry.FromStatus,
+		To:        entry.ToStatus,
+		Reason:    entry.Reason,
+		Source:    entry.Source,
+		ChangedAt: time.Unix(entry.ChangedAt, 0).UTC(),
+	}
+}
+
+func PgToApiStatusEntries(entries []*pg_models.OrderStatusHistory) []*structs.OrderStatusEntry {
+	result := make([]*structs.OrderStatusEntry, len(entries))
+	for i, entry := range entries {
+		result[i] = PgToApiStatusEntry(entry)
+	}
+	return result
+}
+
 func PgToApiDelivery(delivery *pg_models.OrderDelivery) *structs.Delivery {
 	return &structs.Delivery{
 		Name:    delivery.Name,
@@ -77,3 +96,79 @@ func PgToApiItems(items []*pg_models.OrderItem) []structs.Item {
 	}
 	return result
 }
+
+// ApiToPgOrder builds the order model together with its delivery, payment
+// and items so they can be inserted in one Create call
+func ApiToPgOrder(order *structs.Order) (*pg_models.Order, error) {
+	parsedTime, err := time.Parse(time.RFC3339, order.DateCreated)
+	if err != nil {
+		return nil, err
+	}
+	return &pg_models.Order{
+		Uid:               order.OrderUid,
+		TrackNumber:       order.TrackNumber,
+		Entry:             order.Entry,
+		Delivery:          ApiToPgDelivery(&order.Delivery),
+		Payment:           ApiToPgPayment(&order.Payment),
+		Items:             ApiToPgItems(order.Items),
+		Locale:            order.Locale,
+		InternalSignature: order.InternalSignature,
+		CustomerId:        order.CustomerId,
+		DeliveryService:   order.DeliveryService,
+		Shardkey:          order.Shardkey,
+		SmId:              order.SmId,
+		DateCreated:       parsedTime.Unix(),
+		OofShard:          order.OofShard,
+	}, nil
+}
+
+func ApiToPgDelivery(delivery *structs.Delivery) *pg_models.OrderDelivery {
+	return &pg_models.OrderDelivery{
+		Name:    delivery.Name,
+		Phone:   delivery.Phone,
+		Zip:     delivery.Zip,
+		City:    delivery.City,
+		Address: delivery.Address,
+		Region:  delivery.Region,
+		Email:   delivery.Email,
+	}
+}
+
+func ApiToPgPayment(payment *structs.Payment) *pg_models.OrderPayment {
+	return &pg_models.OrderPayment{
+		Transaction:  payment.Transaction,
+		RequestId:    payment.RequestId,
+		Currency:     payment.Currency,
+		Provider:     payment.Provider,
+		Amount:       payment.Amount,
+		PaymentDt:    payment.PaymentDt,
+		Bank:         payment.Bank,
+		DeliveryCost: payment.DeliveryCost,
+		GoodsTotal:   payment.GoodsTotal,
+		CustomFee:    payment.CustomFee,
+	}
+}
+
+func ApiToPgItem(item *structs.Item) *pg_models.OrderItem {
+	return &pg_models.OrderItem{
+		ChrtId:      item.ChrtId,
+		TrackNumber: item.TrackNumber,
+		Price:       item.Price,
+		Rid:         item.Rid,
+		Name:        item.Name,
+		Sale:        item.Sale,
+		Size:        item.Size,
+		TotalPrice:  item.TotalPrice,
+		NmId:        item.NmId,
+		Brand:       item.Brand,
+		Status:      item.Status,
+	}
+}
+
+func ApiToPgItems(items []structs.Item) []*pg_models.OrderItem {
+	result := make([]*pg_models.OrderItem, len(items))
+	for i := range items {
+		result[i] = ApiToPgItem(&items[i])
+	}
+	return result
+}
@@ -22,6 +22,11 @@ type Checker struct {
 	database HealthChecker
 	cache    HealthChecker
 	broker   HealthChecker
+	// warmup holds readiness back while the cache is preloaded
+	warmup HealthChecker
+	// withoutBroker leaves the broker out of the reports of processes that
+	// do not consume
+	withoutBroker bool
 }
 
 // NewChecker creates a new health checker
@@ -44,6 +49,21 @@ func (c *Checker) SetBroker(checker HealthChecker) {
 	c.broker = checker
 }
 
+// SetWarmup sets the cache warm-up that readiness waits for
+func (c *Checker) SetWarmup(checker HealthChecker) {
+	c.warmup = checker
+}
+
+// DisableBroker leaves the broker out of the health and readiness reports
+func (c *Checker) DisableBroker() {
+	c.withoutBroker = true
+}
+
+// BrokerEnabled reports whether the broker is part of the reports
+func (c *Checker) BrokerEnabled() bool {
+	return !c.withoutBroker
+}
+
 // CheckDatabaseHealth checks database health
 func (c *Checker) CheckDatabaseHealth() error {
 	if c.database == nil {
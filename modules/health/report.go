@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Health checks every service and reports whether all of them are healthy
+func (c *Checker) Health() (*HealthStatus, bool) {
+	status := newStatus("healthy")
+	checks := map[string]func() error{
+		"database": c.CheckDatabaseHealth,
+		"cache":    c.CheckCacheHealth,
+	}
+	if c.BrokerEnabled() {
+		checks["kafka"] = c.CheckBrokerHealth
+	}
+	for service, check := range checks {
+		if err := check(); err != nil {
+			status.Status = "unhealthy"
+			status.Services[service] = "error"
+		} else {
+			status.Services[service] = "healthy"
+		}
+	}
+	return status, status.Status == "healthy"
+}
+
+// Readiness reports whether the services needed to handle traffic are
+// available and the cache warm-up is done, stopping at the first one that is
+// not
+func (c *Checker) Readiness() (*HealthStatus, bool) {
+	status := newStatus("ready")
+	services := []string{"database", "cache"}
+	checks := []func() error{c.CheckDatabaseHealth, c.CheckCacheHealth}
+	if c.BrokerEnabled() {
+		services = append(services, "kafka")
+		checks = append(checks, c.CheckBrokerHealth)
+	}
+	if c.warmup != nil {
+		services = append(services, "cache_warmup")
+		checks = append(checks, func() error {
+			return c.warmup.HealthCheck(context.Background())
+		})
+	}
+	for i, check := range checks {
+		if err := check(); err != nil {
+			status.Status = "not ready"
+			status.Services = map[string]string{services[i]: "not available"}
+			return status, false
+		}
+		status.Services[services[i]] = "ready"
+	}
+	return status, true
+}
+
+// Liveness reports that the process is running
+func Liveness() *HealthStatus {
+	status := newStatus("alive")
+	status.Services["application"] = "running"
+	return status
+}
+
+// Mount serves the health, readiness and liveness reports on mux for
+// processes without the HTTP API
+func (c *Checker) Mount(mux *http.ServeMux) {
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, _ *http.Request) {
+		status, ok := c.Health()
+		writeStatus(w, status, ok)
+	})
+	mux.HandleFunc("GET /health/ready", func(w http.ResponseWriter, _ *http.Request) {
+		status, ok := c.Readiness()
+		writeStatus(w, status, ok)
+	})
+	mux.HandleFunc("GET /health/live", func(w http.ResponseWriter, _ *http.Request) {
+		writeStatus(w, Liveness(), true)
+	})
+}
+
+func newStatus(status string) *HealthStatus {
+	return &HealthStatus{
+		Status:    status,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Services:  make(map[string]string),
+	}
+}
+
+func writeStatus(w http.ResponseWriter, status *HealthStatus, ok bool) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
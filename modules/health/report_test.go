@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubChecker struct {
+	err error
+}
+
+func (s *stubChecker) HealthCheck(_ context.Context) error {
+	return s.err
+}
+
+func TestReports(t *testing.T) {
+	checker := NewChecker()
+	status, ok := checker.Health()
+	assert.False(t, ok)
+	assert.Equal(t, "error", status.Services["database"])
+
+	checker.SetDatabase(&stubChecker{})
+	checker.SetCache(&stubChecker{})
+	failingBroker := &stubChecker{err: errors.New("down")}
+	checker.SetBroker(failingBroker)
+	status, ok = checker.Health()
+	assert.False(t, ok)
+	assert.Equal(t, map[string]string{"database": "healthy", "cache": "healthy", "kafka": "error"}, status.Services)
+	status, ok = checker.Readiness()
+	assert.False(t, ok)
+	assert.Equal(t, map[string]string{"kafka": "not available"}, status.Services)
+
+	// Processes that do not consume are healthy without the broker
+	checker.DisableBroker()
+	status, ok = checker.Health()
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"database": "healthy", "cache": "healthy"}, status.Services)
+	status, ok = checker.Readiness()
+	assert.True(t, ok)
+	assert.Equal(t, "ready", status.Status)
+
+	// Readiness waits for the cache warm-up, health does not
+	warmup := &stubChecker{err: errors.New("in progress")}
+	checker.SetWarmup(warmup)
+	_, ok = checker.Health()
+	assert.True(t, ok)
+	status, ok = checker.Readiness()
+	assert.False(t, ok)
+	assert.Equal(t, map[string]string{"cache_warmup": "not available"}, status.Services)
+	warmup.err = nil
+	_, ok = checker.Readiness()
+	assert.True(t, ok)
+}
+
+func TestMount(t *testing.T) {
+	checker := NewChecker()
+	checker.SetDatabase(&stubChecker{})
+	checker.SetCache(&stubChecker{err: errors.New("down")})
+	checker.DisableBroker()
+	mux := http.NewServeMux()
+	checker.Mount(mux)
+
+	for path, code := range map[string]int{
+		"/health":       http.StatusServiceUnavailable,
+		"/health/ready": http.StatusServiceUnavailable,
+		"/health/live":  http.StatusOK,
+	} {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, code, recorder.Code, path)
+		var status HealthStatus
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status), path)
+		assert.NotEmpty(t, status.Status, path)
+	}
+}
@@ -9,32 +9,82 @@ import (
 	"wb-L0/modules/graceful"
 	"wb-L0/modules/kafka"
 	"wb-L0/modules/monitoring"
+	"wb-L0/modules/nats"
 	"wb-L0/modules/pg"
+	"wb-L0/modules/rabbitmq"
 	"wb-L0/modules/redis"
 	"wb-L0/modules/server"
+	"wb-L0/modules/sqlite"
 	"wb-L0/services/broker"
 	"wb-L0/services/cache"
+	"wb-L0/services/codec"
 	"wb-L0/services/composer/orders"
 	"wb-L0/services/database"
+	"wb-L0/services/deadletter"
+	"wb-L0/services/events"
+	"wb-L0/services/outbox"
+	"wb-L0/services/warmup"
 )
 
 var (
 	initializedUnitsList []Initializable
+	// cacheWarmer preloads the cache of processes serving the API
+	cacheWarmer *warmup.Warmer
 )
 
-func Init() {
+// memoryBrokerCapacity bounds the undelivered messages of the memory broker
+const memoryBrokerCapacity = 1000
+
+// Role selects the parts of the service a process runs
+type Role string
+
+const (
+	// RoleAll serves the HTTP API and consumes orders
+	RoleAll Role = "all"
+	// RoleApi serves the HTTP API only and does not join the consumer group
+	RoleApi Role = "api"
+	// RoleConsumer consumes orders without the HTTP server; health probes
+	// are answered on the metrics port
+	RoleConsumer Role = "consumer"
+)
+
+// ParseRole parses the APP_ROLE setting, empty meaning RoleAll
+func ParseRole(role string) (Role, error) {
+	switch Role(role) {
+	case "", RoleAll:
+		return RoleAll, nil
+	case RoleApi, RoleConsumer:
+		return Role(role), nil
+	default:
+		return "", fmt.Errorf("unknown role: %s", role)
+	}
+}
+
+// Init starts the units of the role; an empty role uses APP_ROLE
+func Init(role Role) {
 	var err error
 	err = graceful.Init()
 	if err != nil {
 		log.Fatal(err)
 	}
-	unitsList := []Initializable{
-		new(config.Config),
-		new(monitoring.Monitoring),
-		new(server.Server),
+	initUnits([]Initializable{new(config.Config)})
+	if config.GetConfig() == nil {
+		return
+	}
+	if role == "" {
+		role, err = ParseRole(config.GetConfig().AppRole)
+		if err != nil {
+			log.Println(err)
+			graceful.DoShutdown()
+			return
+		}
+	}
+	unitsList := []Initializable{new(monitoring.Monitoring)}
+	if role != RoleConsumer {
+		unitsList = append(unitsList, new(server.Server))
 	}
 	initUnits(unitsList)
-	optionalUnits, err := identifyOptionalUnits()
+	optionalUnits, err := identifyOptionalUnits(role)
 	if err != nil {
 		log.Println(err)
 		graceful.DoShutdown()
@@ -42,7 +92,12 @@ func Init() {
 	initUnits(optionalUnits)
 	select {
 	case <-graceful.GetContext().Done():
+		return
 	default:
+	}
+	log.Printf("Running as %s", role)
+	watchHealth(role)
+	if role != RoleApi {
 		orders.StartDataTransfer()
 	}
 }
@@ -69,37 +124,154 @@ func handleErrors(errChan chan error) {
 	}
 }
 
-func identifyOptionalUnits() ([]Initializable, error) {
+// watchHealth hands the services the role uses to the health checker
+func watchHealth(role Role) {
+	checker := monitoring.GetMonitoring().GetHealthChecker()
+	checker.SetDatabase(database.GetDatabase())
+	checker.SetCache(cache.GetCache())
+	if cacheWarmer != nil {
+		checker.SetWarmup(cacheWarmer)
+	}
+	if role == RoleApi {
+		checker.DisableBroker()
+	} else {
+		checker.SetBroker(broker.GetBroker())
+	}
+}
+
+func identifyOptionalUnits(role Role) ([]Initializable, error) {
 	units := make([]Initializable, 0)
-	switch config.GetConfig().BrokerType {
+	var kafkaInstance *kafka.Kafka
+	if role != RoleApi || config.GetConfig().BrokerType == "kafka" || config.GetConfig().BrokerType == "file" {
+		// The API publishes events and dead letters, replays offsets and
+		// accepts pushes to the file broker, which a Kafka writer and the
+		// file broker allow without consuming
+		brokerInstance, unit, err := brokerUnit(role == RoleApi)
+		if err != nil {
+			return nil, err
+		}
+		broker.SetBroker(brokerInstance)
+		kafkaInstance, _ = unit.(*kafka.Kafka)
+		if unit != nil {
+			units = append(units, unit)
+		}
+	}
+	if dir := config.GetConfig().AvroSchemaDir; dir != "" {
+		store := codec.NewLocalSchemaStore()
+		if err := store.LoadDir(dir); err != nil {
+			return nil, err
+		}
+		codec.SetRegistry(codec.NewDefaultRegistry(store))
+	}
+	switch config.GetConfig().DlqType {
 	case "kafka":
-		instance := new(kafka.Kafka)
-		units = append(units, instance)
-		broker.SetBroker(broker.NewKafkaBroker(instance))
+		if kafkaInstance == nil {
+			return nil, fmt.Errorf("dlq type kafka requires kafka broker")
+		}
+		deadletter.SetQueue(deadletter.NewKafkaQueue(kafkaInstance))
+	case "memory", "":
+		queue, err := deadletter.NewMemoryQueue(config.GetConfig().DlqFilePath)
+		if err != nil {
+			return nil, err
+		}
+		deadletter.SetQueue(queue)
 	default:
-		return nil, fmt.Errorf("unknown broker type: %s", config.GetConfig().BrokerType)
+		return nil, fmt.Errorf("unknown dlq type: %s", config.GetConfig().DlqType)
 	}
-	switch config.GetConfig().DbType {
-	case "postgres":
-		instance := new(pg.Postgres)
-		units = append(units, instance)
-		database.SetDatabase(database.NewPostgres(instance))
+	databaseInstance, unit, err := databaseUnit()
+	if err != nil {
+		return nil, err
+	}
+	database.SetDatabase(databaseInstance)
+	if unit != nil {
+		units = append(units, unit)
+	}
+	switch config.GetConfig().EventsType {
+	case "kafka":
+		if kafkaInstance == nil {
+			return nil, fmt.Errorf("events type kafka requires kafka broker")
+		}
+		events.SetPublisher(events.NewKafkaPublisher(kafkaInstance))
+		units = append(units, outbox.NewRelay(database.GetDatabase(), events.GetPublisher()))
+	case "none", "":
+		// Without a publisher the relay would mark events published that
+		// nobody receives, so they stay in the outbox until one is configured
+		log.Println("Order events are disabled, the outbox is not relayed")
 	default:
-		return nil, fmt.Errorf("unknown db type: %s", config.GetConfig().DbType)
+		return nil, fmt.Errorf("unknown events type: %s", config.GetConfig().EventsType)
 	}
 	switch config.GetConfig().CacheType {
 	case "memory":
-		cache.SetCache(cache.NewMemoryCache())
+		memoryCache := cache.NewMemoryCache()
+		units = append(units, memoryCache)
+		cache.SetCache(memoryCache)
 	case "redis":
 		instance := new(redis.Redis)
 		units = append(units, instance)
 		cache.SetCache(cache.NewRedisCache(instance))
+	case "tiered":
+		instance := new(redis.Redis)
+		tieredCache := cache.NewTieredCache(instance)
+		units = append(units, instance, tieredCache)
+		cache.SetCache(tieredCache)
 	default:
 		return nil, fmt.Errorf("unknown cache type: %s", config.GetConfig().CacheType)
 	}
+	if role != RoleConsumer {
+		// Units start in order, so the database and the cache are ready
+		cacheWarmer = warmup.NewWarmer(database.GetDatabase(), cache.GetCache())
+		units = append(units, cacheWarmer)
+	}
 	return units, nil
 }
 
+// brokerUnit creates the configured broker and the unit connecting it, if it
+// needs one. A writer-only Kafka connection does not join the consumer group.
+func brokerUnit(writerOnly bool) (broker.Broker, Initializable, error) {
+	switch config.GetConfig().BrokerType {
+	case "kafka":
+		instance := &kafka.Kafka{WriterOnly: writerOnly}
+		return broker.NewKafkaBroker(instance), instance, nil
+	case "nats":
+		instance := new(nats.Nats)
+		return broker.NewNatsBroker(instance), instance, nil
+	case "rabbitmq":
+		instance := new(rabbitmq.RabbitMQ)
+		return broker.NewRabbitMqBroker(instance), instance, nil
+	case "memory":
+		return broker.NewMemoryBroker(memoryBrokerCapacity), nil, nil
+	case "file":
+		fileBroker, err := broker.NewFileBroker(config.GetConfig().FileBrokerPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return fileBroker, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown broker type: %s", config.GetConfig().BrokerType)
+	}
+}
+
+// databaseUnit creates the configured database and the unit connecting it, if
+// it needs one
+func databaseUnit() (database.Database, Initializable, error) {
+	duplicatePolicy, err := database.ParseDuplicatePolicy(config.GetConfig().DuplicatePolicy)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch config.GetConfig().DbType {
+	case "postgres":
+		instance := new(pg.Postgres)
+		return database.NewPostgres(instance, duplicatePolicy), instance, nil
+	case "sqlite":
+		instance := new(sqlite.Sqlite)
+		return database.NewSqlite(instance, duplicatePolicy), instance, nil
+	case "memory":
+		return database.NewMemory(duplicatePolicy), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown db type: %s", config.GetConfig().DbType)
+	}
+}
+
 func initUnits(units []Initializable) {
 	errChan := make(chan error)
 	go handleErrors(errChan)
@@ -0,0 +1,94 @@
+package initializer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"wb-L0/modules/config"
+	"wb-L0/modules/migrations"
+	"wb-L0/modules/pg"
+	"wb-L0/modules/sqlite"
+)
+
+// Migrate runs the migrate subcommand against the configured database:
+// "up" (the default) applies pending migrations, "down [steps]" reverts the
+// latest ones and "status" lists them
+func Migrate(args []string) error {
+	err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	db, err := connectDatabase()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("applied %d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02T15:04:05Z07:00")
+			}
+			fmt.Printf("%d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %s: use up, down [steps] or status", command)
+	}
+}
+
+// connectDatabase opens the configured database without migrating it
+func connectDatabase() (*gorm.DB, error) {
+	switch config.GetConfig().DbType {
+	case "postgres":
+		return pg.Connect()
+	case "sqlite":
+		return sqlite.Connect(sqlite.Path())
+	default:
+		return nil, fmt.Errorf("db type %s has no migrations", config.GetConfig().DbType)
+	}
+}
@@ -0,0 +1,71 @@
+package initializer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"wb-L0/modules/config"
+	"wb-L0/services/broker"
+	"wb-L0/services/database"
+)
+
+// LoadConfig loads the configuration for commands that run without the full
+// set of units
+func LoadConfig() error {
+	return new(config.Config).Init(nil)
+}
+
+// OpenDatabase connects the configured database for a one-off command. The
+// returned function closes it again.
+func OpenDatabase() (database.Database, func(), error) {
+	if config.GetConfig().DbType == "memory" {
+		return nil, nil, fmt.Errorf("db type memory keeps no orders outside the serving process")
+	}
+	instance, unit, err := databaseUnit()
+	if err != nil {
+		return nil, nil, err
+	}
+	closeUnit, err := initStandalone(unit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return instance, closeUnit, nil
+}
+
+// OpenBroker connects the configured broker for a one-off command that
+// publishes messages. A Kafka connection does not join the consumer group.
+// The returned function closes it again.
+func OpenBroker() (broker.Broker, func(), error) {
+	if config.GetConfig().BrokerType == "memory" {
+		return nil, nil, fmt.Errorf("broker type memory only accepts messages from the serving process: use POST /admin/broker/messages")
+	}
+	instance, unit, err := brokerUnit(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeUnit, err := initStandalone(unit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return instance, closeUnit, nil
+}
+
+// initStandalone initializes a unit outside of the graceful lifecycle; a nil
+// unit needs nothing
+func initStandalone(unit Initializable) (func(), error) {
+	if unit == nil {
+		return func() {}, nil
+	}
+	// Asynchronous errors of a short-lived command surface through the calls
+	// it makes, so the channel only has to keep the unit from blocking
+	err := unit.Init(make(chan error, 1))
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := unit.Shutdown(context.Background()); err != nil {
+			log.Println(err)
+		}
+	}, nil
+}
@@ -2,7 +2,9 @@ package kafka
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"math"
 	"strings"
 	"time"
 
@@ -11,14 +13,32 @@ import (
 	"wb-L0/modules/config"
 )
 
+// readRangeTimeout bounds how long reading an offset range may take
+const readRangeTimeout = 30 * time.Second
+
 type Kafka struct {
 	Reader *kafka.Reader
+	Writer *kafka.Writer
+	// WriterOnly skips the consumer group reader, for processes that only
+	// publish
+	WriterOnly bool
 }
 
 func (k *Kafka) Init(_ chan error) error {
-	reader := kafka.NewReader(kafka.ReaderConfig{
+	k.Writer = &kafka.Writer{
+		Addr:                   kafka.TCP(config.GetConfig().KafkaUrl),
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		MaxAttempts:            5,
+		AllowAutoTopicCreation: true,
+		Logger:                 kafka.LoggerFunc(logKafka),
+		ErrorLogger:            kafka.LoggerFunc(logKafkaError),
+	}
+	if k.WriterOnly {
+		return nil
+	}
+	readerConfig := kafka.ReaderConfig{
 		Brokers:        []string{config.GetConfig().KafkaUrl},
-		Topic:          config.GetConfig().KafkaTopic,
 		GroupID:        config.GetConfig().KafkaConsumerGroup,
 		MinBytes:       1,
 		MaxBytes:       10e6,
@@ -29,8 +49,14 @@ func (k *Kafka) Init(_ chan error) error {
 		Logger:         kafka.LoggerFunc(logKafka),
 		ErrorLogger:    kafka.LoggerFunc(logKafkaError),
 		StartOffset:    kafka.LastOffset,
-	})
-	k.Reader = reader
+	}
+	// Status changes share the consumer group; messages carry their topic
+	if statusTopic := config.GetConfig().KafkaStatusTopic; statusTopic != "" {
+		readerConfig.GroupTopics = []string{config.GetConfig().KafkaTopic, statusTopic}
+	} else {
+		readerConfig.Topic = config.GetConfig().KafkaTopic
+	}
+	k.Reader = kafka.NewReader(readerConfig)
 	return nil
 }
 
@@ -39,7 +65,12 @@ func (k *Kafka) SuccessfulMessage() string {
 }
 
 func (k *Kafka) Shutdown(_ context.Context) error {
-	err := k.Reader.Close()
+	err := k.Writer.Close()
+	if k.Reader != nil {
+		if readerErr := k.Reader.Close(); err == nil {
+			err = readerErr
+		}
+	}
 	return err
 }
 
@@ -53,3 +84,88 @@ func logKafka(msg string, args ...interface{}) {
 func logKafkaError(msg string, args ...interface{}) {
 	log.Printf("[KAFKA-ERROR] "+msg, args...)
 }
+
+// Partitions returns the partition ids of the topic
+func (k *Kafka) Partitions(ctx context.Context, topic string) ([]int, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", config.GetConfig().KafkaUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	defer conn.Close()
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+	ids := make([]int, len(partitions))
+	for i, partition := range partitions {
+		ids[i] = partition.ID
+	}
+	return ids, nil
+}
+
+// ReadRange reads the messages of a single partition with offsets in
+// [from, to], clamped to what the partition held when the read started. It
+// bypasses the consumer group, so committed offsets are left untouched.
+func (k *Kafka) ReadRange(ctx context.Context, topic string, partition int, from, to int64) ([]kafka.Message, error) {
+	messages, _, err := k.readPartition(ctx, topic, partition, from, to)
+	return messages, err
+}
+
+// ReadFrom reads the messages of a single partition from offset from up to
+// the end of the partition and returns the offset to continue from
+func (k *Kafka) ReadFrom(ctx context.Context, topic string, partition int, from int64) ([]kafka.Message, int64, error) {
+	return k.readPartition(ctx, topic, partition, from, math.MaxInt64)
+}
+
+// readPartition reads offsets [from, to] below the high watermark seen when
+// it starts, so messages published meanwhile do not keep it reading. Offsets
+// that never come back, such as compacted ones, are skipped by fetch
+// position rather than waited for, and readRangeTimeout bounds the whole read.
+func (k *Kafka) readPartition(ctx context.Context, topic string, partition int, from, to int64) ([]kafka.Message, int64, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", config.GetConfig().KafkaUrl, topic, partition)
+	if err != nil {
+		return nil, from, fmt.Errorf("failed to connect to partition leader: %w", err)
+	}
+	defer conn.Close()
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, from, fmt.Errorf("failed to read offsets: %w", err)
+	}
+	next := max(from, first)
+	end := last
+	if to < last {
+		end = to + 1
+	}
+	deadline := time.Now().Add(readRangeTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	err = conn.SetReadDeadline(deadline)
+	if err != nil {
+		return nil, from, err
+	}
+
+	messages := make([]kafka.Message, 0)
+	for next < end {
+		if err := ctx.Err(); err != nil {
+			return nil, from, err
+		}
+		if _, err := conn.Seek(next, kafka.SeekAbsolute); err != nil {
+			return nil, from, fmt.Errorf("failed to seek to offset %d: %w", next, err)
+		}
+		batch := conn.ReadBatch(1, 10e6)
+		for {
+			msg, err := batch.ReadMessage()
+			if err != nil || msg.Offset >= end {
+				break
+			}
+			messages = append(messages, msg)
+		}
+		err := batch.Close()
+		next = max(next, batch.Offset())
+		if err != nil {
+			return nil, from, fmt.Errorf("failed to read offsets %d-%d at offset %d: %w", from, end-1, next, err)
+		}
+	}
+	return messages, max(end, from), nil
+}
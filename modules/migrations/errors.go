@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ErrUnknownDialect struct {
+	Dialect string
+}
+
+func (e ErrUnknownDialect) Error() string {
+	return fmt.Sprintf("no migrations for database dialect %s", e.Dialect)
+}
+
+type ErrInvalidMigration struct {
+	File string
+	Err  string
+}
+
+func (e ErrInvalidMigration) Error() string {
+	return fmt.Sprintf("invalid migration %s: %s", e.File, e.Err)
+}
+
+type ErrMigrationFailed struct {
+	Version int64
+	Name    string
+	Err     string
+}
+
+func (e ErrMigrationFailed) Error() string {
+	return fmt.Sprintf("migration %d_%s failed: %s", e.Version, e.Name, e.Err)
+}
+
+type ErrIrreversible struct {
+	Version int64
+	Name    string
+}
+
+func IsErrIrreversible(err error) bool {
+	return errors.As(err, new(ErrIrreversible))
+}
+
+func (e ErrIrreversible) Error() string {
+	return fmt.Sprintf("migration %d_%s cannot be reverted", e.Version, e.Name)
+}
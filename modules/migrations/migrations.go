@@ -0,0 +1,283 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// files holds one directory of migrations per dialect. A migration is a
+// <version>_<name>.up.sql file with an optional .down.sql counterpart.
+//
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// lockKey identifies the advisory lock held while migrations run
+const lockKey = 7310902
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	// Down is empty when the migration cannot be reverted
+	Down string
+}
+
+// Status reports whether a migration is applied. Migrations recorded in the
+// database but unknown to this binary have no Up statements.
+type Status struct {
+	*Migration
+	AppliedAt *time.Time
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"type:varchar(255);not null"`
+	AppliedAt int64  `gorm:"type:bigint;not null"`
+}
+
+func (*appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    bigint PRIMARY KEY,
+    name       varchar(255) NOT NULL,
+    applied_at bigint NOT NULL
+)`
+
+// Load returns the migrations of a dialect ordered by version
+func Load(dialect string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, ErrUnknownDialect{Dialect: dialect}
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, ErrInvalidMigration{File: entry.Name(), Err: "name is not <version>_<name>.<up|down>.sql"}
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, ErrInvalidMigration{File: entry.Name(), Err: err.Error()}
+		}
+		data, err := files.ReadFile(path.Join(dialect, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, ErrInvalidMigration{File: entry.Name(), Err: fmt.Sprintf("version %d is also named %s", version, migration.Name)}
+		}
+		if match[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+	result := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, ErrInvalidMigration{File: fmt.Sprintf("%d_%s", migration.Version, migration.Name), Err: "no up migration"}
+		}
+		result = append(result, migration)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+// Migrator applies the embedded migrations of the database dialect. Every
+// migration runs in its own transaction together with its schema_migrations
+// row, so a failed migration leaves nothing behind. On Postgres the whole run
+// holds an advisory lock: replicas starting together apply each migration
+// once and wait for each other. SQLite serializes writers by itself.
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []*Migration
+}
+
+func New(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Up applies all pending migrations in version order and returns them
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	applied := make([]*Migration, 0)
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		versions, err := appliedVersions(db)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			err = db.Transaction(func(tx *gorm.DB) error {
+				err := tx.Exec(migration.Up).Error
+				if err != nil {
+					return err
+				}
+				return tx.Create(&appliedMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					AppliedAt: time.Now().Unix(),
+				}).Error
+			})
+			if err != nil {
+				return ErrMigrationFailed{Version: migration.Version, Name: migration.Name, Err: err.Error()}
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts up to steps of the most recently applied migrations, newest
+// first, and returns them. It stops at a migration without down statements.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	reverted := make([]*Migration, 0, steps)
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		versions, err := appliedVersions(db)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return ErrIrreversible{Version: migration.Version, Name: migration.Name}
+			}
+			err = db.Transaction(func(tx *gorm.DB) error {
+				err := tx.Exec(migration.Down).Error
+				if err != nil {
+					return err
+				}
+				return tx.Delete(&appliedMigration{Version: migration.Version}).Error
+			})
+			if err != nil {
+				return ErrMigrationFailed{Version: migration.Version, Name: migration.Name, Err: err.Error()}
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists the known and the recorded migrations by version
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	db := m.db.WithContext(ctx)
+	err := db.Exec(createTable).Error
+	if err != nil {
+		return nil, err
+	}
+	versions, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := &Status{Migration: migration}
+		if row, ok := versions[migration.Version]; ok {
+			appliedAt := time.Unix(row.AppliedAt, 0).UTC()
+			status.AppliedAt = &appliedAt
+			delete(versions, migration.Version)
+		}
+		result = append(result, status)
+	}
+	for _, row := range versions {
+		appliedAt := time.Unix(row.AppliedAt, 0).UTC()
+		result = append(result, &Status{
+			Migration: &Migration{Version: row.Version, Name: row.Name},
+			AppliedAt: &appliedAt,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+// withLock creates schema_migrations and runs fc on a single connection
+// holding the Postgres advisory lock
+func (m *Migrator) withLock(ctx context.Context, fc func(db *gorm.DB) error) error {
+	db := m.db.WithContext(ctx)
+	if m.dialect != "postgres" {
+		err := db.Exec(createTable).Error
+		if err != nil {
+			return err
+		}
+		return fc(db)
+	}
+	return db.Connection(func(conn *gorm.DB) error {
+		err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error
+		if err != nil {
+			return err
+		}
+		// The lock belongs to the pooled connection, so it is released even
+		// when ctx is done
+		defer conn.WithContext(context.Background()).Exec("SELECT pg_advisory_unlock(?)", lockKey)
+		err = conn.Exec(createTable).Error
+		if err != nil {
+			return err
+		}
+		return fc(conn)
+	})
+}
+
+func appliedVersions(db *gorm.DB) (map[int64]*appliedMigration, error) {
+	rows := make([]*appliedMigration, 0)
+	err := db.Order("version").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[int64]*appliedMigration, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row
+	}
+	return versions, nil
+}
+
+// Run applies the pending migrations and logs each applied one
+func Run(ctx context.Context, db *gorm.DB) error {
+	migrator, err := New(db)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		log.Printf("[MIGRATE] applied %d_%s", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package migrations_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	_ "wb-L0/models/pg_models"
+	"wb-L0/modules/migrations"
+	"wb-L0/modules/pg"
+	"wb-L0/modules/sqlite"
+)
+
+// testDSN points at the integration test database from docker-compose.test.yml
+const testDSN = "host=localhost user=test_user password=test_pass dbname=test_db port=5433 sslmode=disable TimeZone=UTC"
+
+func TestLoad(t *testing.T) {
+	for _, dialect := range []string{"postgres", "sqlite"} {
+		loaded, err := migrations.Load(dialect)
+		require.NoError(t, err, dialect)
+		require.NotEmpty(t, loaded, dialect)
+		assert.Equal(t, int64(1), loaded[0].Version, dialect)
+		assert.Equal(t, "initial_schema", loaded[0].Name, dialect)
+		assert.NotEmpty(t, loaded[0].Down, dialect)
+		for i := 1; i < len(loaded); i++ {
+			assert.Less(t, loaded[i-1].Version, loaded[i].Version, dialect)
+		}
+	}
+
+	_, err := migrations.Load("mysql")
+	assert.Error(t, err)
+}
+
+func TestSqliteUpDown(t *testing.T) {
+	db := openSqlite(t)
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, applied)
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, migration := range status {
+		assert.NotNil(t, migration.AppliedAt, migration.Name)
+	}
+	assert.True(t, db.Migrator().HasTable("order"))
+
+	reverted, err := migrator.Down(ctx, len(status))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(status))
+	assert.False(t, db.Migrator().HasTable("order"))
+	status, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, migration := range status {
+		assert.Nil(t, migration.AppliedAt, migration.Name)
+	}
+}
+
+// TestSqliteMatchesModels tests that the migrated schema has every column the models use
+func TestSqliteMatchesModels(t *testing.T) {
+	db := openSqlite(t)
+	require.NoError(t, migrations.Run(context.Background(), db))
+
+	assertMatchesModels(t, db)
+}
+
+// TestPostgresMigrations runs the Postgres migrations in a fresh schema of the
+// test database
+func TestPostgresMigrations(t *testing.T) {
+	db := openPostgres(t)
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Replicas starting together apply every migration exactly once
+	var wg sync.WaitGroup
+	applied := make([][]*migrations.Migration, 3)
+	for i := range applied {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var runErr error
+			applied[i], runErr = migrator.Up(ctx)
+			assert.NoError(t, runErr)
+		}()
+	}
+	wg.Wait()
+	total := 0
+	for _, run := range applied {
+		total += len(run)
+	}
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(status), total)
+	assertMatchesModels(t, db)
+
+	_, err = migrator.Down(ctx, len(status))
+	assert.True(t, migrations.IsErrIrreversible(err))
+}
+
+// TestSqliteUpgradeBaseline tests that a database created by AutoMigrate of
+// the baseline models is migrated with its orders kept
+func TestSqliteUpgradeBaseline(t *testing.T) {
+	assertUpgradesBaseline(t, openSqlite(t))
+}
+
+// TestPostgresUpgradeBaseline tests that a database created by AutoMigrate of
+// the baseline models is migrated with its orders kept
+func TestPostgresUpgradeBaseline(t *testing.T) {
+	assertUpgradesBaseline(t, openPostgres(t))
+}
+
+// Models as AutoMigrate created them before migrations were introduced
+type baselineOrder struct {
+	Id                int64  `gorm:"primaryKey;autoIncrement"`
+	Uid               string `gorm:"type:varchar(50);not null;unique"`
+	TrackNumber       string `gorm:"type:varchar(100)"`
+	Entry             string `gorm:"type:varchar(50)"`
+	Locale            string `gorm:"type:varchar(5)"`
+	InternalSignature string `gorm:"type:text"`
+	CustomerId        string `gorm:"type:varchar(50)"`
+	DeliveryService   string `gorm:"type:varchar(50)"`
+	Shardkey          string `gorm:"type:varchar(50)"`
+	SmId              int    `gorm:"type:integer"`
+	DateCreated       int64  `gorm:"type:bigint"`
+	OofShard          string `gorm:"type:varchar(5)"`
+}
+
+func (baselineOrder) TableName() string {
+	return "order"
+}
+
+type baselineDelivery struct {
+	Id      int64  `gorm:"primaryKey;autoIncrement"`
+	OrderId int64  `gorm:"type:int;not null"`
+	Name    string `gorm:"type:varchar(50);not null"`
+	Phone   string `gorm:"type:varchar(12);not null"`
+	Zip     string `gorm:"type:varchar(12);not null"`
+	City    string `gorm:"type:varchar(50);not null"`
+	Address string `gorm:"type:varchar(50);not null"`
+	Region  string `gorm:"type:varchar(50);not null"`
+	Email   string `gorm:"type:varchar(50);not null"`
+}
+
+func (baselineDelivery) TableName() string {
+	return "order_delivery"
+}
+
+type baselinePayment struct {
+	Id           int64  `gorm:"primaryKey;autoIncrement"`
+	OrderId      int64  `gorm:"type:int;not null"`
+	Transaction  string `gorm:"type:varchar(50);not null"`
+	RequestId    string `gorm:"type:varchar(50)"`
+	Currency     string `gorm:"type:varchar(5);not null"`
+	Provider     string `gorm:"type:varchar(50);not null"`
+	Amount       int    `gorm:"type:int;not null"`
+	PaymentDt    int64  `gorm:"type:int;not null"`
+	Bank         string `gorm:"type:varchar(50);not null"`
+	DeliveryCost int    `gorm:"type:int;not null"`
+	GoodsTotal   int    `gorm:"type:int;not null"`
+	CustomFee    int    `gorm:"type:int"`
+}
+
+func (baselinePayment) TableName() string {
+	return "order_payment"
+}
+
+type baselineItem struct {
+	Id          int64  `gorm:"primaryKey;autoIncrement"`
+	OrderId     int64  `gorm:"type:int;not null"`
+	ChrtId      int64  `gorm:"type:int;not null"`
+	TrackNumber string `gorm:"type:varchar(50);not null"`
+	Price       int    `gorm:"type:int;not null"`
+	Rid         string `gorm:"type:varchar(50);not null"`
+	Name        string `gorm:"type:varchar(50);not null"`
+	Sale        int    `gorm:"type:int;not null"`
+	Size        string `gorm:"type:varchar(5);not null"`
+	TotalPrice  int    `gorm:"type:int;not null"`
+	NmId        int64  `gorm:"type:int;not null"`
+	Brand       string `gorm:"type:varchar(50);not null"`
+	Status      int    `gorm:"type:int;not null"`
+}
+
+func (baselineItem) TableName() string {
+	return "order_item"
+}
+
+func assertUpgradesBaseline(t *testing.T, db *gorm.DB) {
+	require.NoError(t, db.AutoMigrate(&baselineOrder{}, &baselineDelivery{}, &baselinePayment{}, &baselineItem{}))
+	order := &baselineOrder{Uid: "baseline", DateCreated: 1}
+	require.NoError(t, db.Create(order).Error)
+	require.NoError(t, db.Create(&baselineDelivery{OrderId: order.Id, Name: "name"}).Error)
+	require.NoError(t, db.Create(&baselinePayment{OrderId: order.Id, Transaction: "baseline"}).Error)
+	require.NoError(t, db.Create(&baselineItem{OrderId: order.Id, ChrtId: 1}).Error)
+
+	require.NoError(t, migrations.Run(context.Background(), db))
+
+	assertMatchesModels(t, db)
+	var status string
+	require.NoError(t, db.Raw(`SELECT status FROM "order" WHERE uid = ?`, "baseline").Scan(&status).Error)
+	assert.Equal(t, "created", status)
+	// The added foreign keys cascade deletes to the kept parts
+	require.NoError(t, db.Exec(`DELETE FROM "order" WHERE id = ?`, order.Id).Error)
+	for _, table := range []string{"order_delivery", "order_payment", "order_item"} {
+		var count int64
+		require.NoError(t, db.Table(table).Count(&count).Error)
+		assert.Zero(t, count, table)
+	}
+}
+
+// openPostgres opens a fresh schema of the test database, skipping the test
+// when Postgres is not available
+func openPostgres(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		dsn = testDSN
+	}
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err == nil {
+		err = admin.Exec("SELECT 1").Error
+	}
+	if err != nil {
+		t.Skipf("Postgres not available, skipping migrations test: %v", err)
+	}
+	schema := fmt.Sprintf("migrations_%d", time.Now().UnixNano())
+	require.NoError(t, admin.Exec("CREATE SCHEMA "+schema).Error)
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+	})
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	return db
+}
+
+func openSqlite(t *testing.T) *gorm.DB {
+	db, err := sqlite.Connect(":memory:")
+	require.NoError(t, err)
+	db.Logger = logger.Discard
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func assertMatchesModels(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for _, model := range pg.Models() {
+		statement := &gorm.Statement{DB: db}
+		require.NoError(t, statement.Parse(model))
+		require.True(t, db.Migrator().HasTable(model), statement.Table)
+		for _, field := range statement.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", statement.Table, field.DBName)
+		}
+	}
+}
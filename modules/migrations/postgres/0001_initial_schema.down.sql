@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS order_item;
+DROP TABLE IF EXISTS order_payment;
+DROP TABLE IF EXISTS order_delivery;
+DROP TABLE IF EXISTS "order";
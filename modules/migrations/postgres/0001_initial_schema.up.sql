@@ -0,0 +1,62 @@
+-- Schema created by GORM AutoMigrate before migrations were introduced.
+-- IF NOT EXISTS keeps it a no-op on such databases; names match GORM's.
+-- Everything added since is left to the following migrations, which bring
+-- these databases and new ones to the same schema.
+CREATE TABLE IF NOT EXISTS "order" (
+    id                 bigserial PRIMARY KEY,
+    uid                varchar(50) NOT NULL,
+    track_number       varchar(100),
+    entry              varchar(50),
+    locale             varchar(5),
+    internal_signature text,
+    customer_id        varchar(50),
+    delivery_service   varchar(50),
+    shardkey           varchar(50),
+    sm_id              integer,
+    date_created       bigint,
+    oof_shard          varchar(5),
+    CONSTRAINT uni_order_uid UNIQUE (uid)
+);
+
+CREATE TABLE IF NOT EXISTS order_delivery (
+    id       bigserial PRIMARY KEY,
+    order_id integer     NOT NULL,
+    name     varchar(50) NOT NULL,
+    phone    varchar(12) NOT NULL,
+    zip      varchar(12) NOT NULL,
+    city     varchar(50) NOT NULL,
+    address  varchar(50) NOT NULL,
+    region   varchar(50) NOT NULL,
+    email    varchar(50) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS order_payment (
+    id            bigserial PRIMARY KEY,
+    order_id      integer     NOT NULL,
+    transaction   varchar(50) NOT NULL,
+    request_id    varchar(50),
+    currency      varchar(5)  NOT NULL,
+    provider      varchar(50) NOT NULL,
+    amount        integer     NOT NULL,
+    payment_dt    integer     NOT NULL,
+    bank          varchar(50) NOT NULL,
+    delivery_cost integer     NOT NULL,
+    goods_total   integer     NOT NULL,
+    custom_fee    integer
+);
+
+CREATE TABLE IF NOT EXISTS order_item (
+    id           bigserial PRIMARY KEY,
+    order_id     integer     NOT NULL,
+    chrt_id      integer     NOT NULL,
+    track_number varchar(50) NOT NULL,
+    price        integer     NOT NULL,
+    rid          varchar(50) NOT NULL,
+    name         varchar(50) NOT NULL,
+    sale         integer     NOT NULL,
+    size         varchar(5)  NOT NULL,
+    total_price  integer     NOT NULL,
+    nm_id        integer     NOT NULL,
+    brand        varchar(50) NOT NULL,
+    status       integer     NOT NULL
+);
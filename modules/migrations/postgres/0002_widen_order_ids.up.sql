@@ -0,0 +1,6 @@
+-- The baseline schema references orders by integer ids and AutoMigrate
+-- never altered existing column types. There is no down migration since
+-- narrowing could lose ids.
+ALTER TABLE order_delivery ALTER COLUMN order_id TYPE bigint;
+ALTER TABLE order_payment ALTER COLUMN order_id TYPE bigint;
+ALTER TABLE order_item ALTER COLUMN order_id TYPE bigint;
@@ -0,0 +1,3 @@
+ALTER TABLE order_item DROP CONSTRAINT IF EXISTS fk_order_items;
+ALTER TABLE order_payment DROP CONSTRAINT IF EXISTS fk_order_payment;
+ALTER TABLE order_delivery DROP CONSTRAINT IF EXISTS fk_order_delivery;
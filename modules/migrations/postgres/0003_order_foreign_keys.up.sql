@@ -0,0 +1,8 @@
+-- Deleting an order deletes its parts. Dropping first keeps the migration
+-- repeatable on databases where AutoMigrate already created the keys.
+ALTER TABLE order_delivery DROP CONSTRAINT IF EXISTS fk_order_delivery,
+    ADD CONSTRAINT fk_order_delivery FOREIGN KEY (order_id) REFERENCES "order" (id) ON DELETE CASCADE;
+ALTER TABLE order_payment DROP CONSTRAINT IF EXISTS fk_order_payment,
+    ADD CONSTRAINT fk_order_payment FOREIGN KEY (order_id) REFERENCES "order" (id) ON DELETE CASCADE;
+ALTER TABLE order_item DROP CONSTRAINT IF EXISTS fk_order_items,
+    ADD CONSTRAINT fk_order_items FOREIGN KEY (order_id) REFERENCES "order" (id) ON DELETE CASCADE;
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_order_item_brand;
+DROP INDEX IF EXISTS idx_order_item_nm_id;
+DROP INDEX IF EXISTS idx_order_item_track_number;
+DROP INDEX IF EXISTS idx_order_item_order_id;
+DROP INDEX IF EXISTS idx_order_payment_bank;
+DROP INDEX IF EXISTS idx_order_payment_provider;
+DROP INDEX IF EXISTS idx_order_payment_request_id;
+DROP INDEX IF EXISTS idx_order_payment_transaction;
+DROP INDEX IF EXISTS idx_order_payment_order_id;
+DROP INDEX IF EXISTS idx_order_delivery_order_id;
+DROP INDEX IF EXISTS idx_order_date_created_id;
+DROP INDEX IF EXISTS idx_order_delivery_service;
+DROP INDEX IF EXISTS idx_order_customer_id;
+DROP INDEX IF EXISTS idx_order_locale;
+DROP INDEX IF EXISTS idx_order_track_number;
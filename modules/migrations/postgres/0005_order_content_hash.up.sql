@@ -0,0 +1,2 @@
+-- Hash of the order content compared by the ignore duplicate policy
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS content_hash varchar(64);
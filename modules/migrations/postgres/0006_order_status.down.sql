@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS order_status_history;
+DROP INDEX IF EXISTS idx_order_status;
+ALTER TABLE "order" DROP COLUMN IF EXISTS status;
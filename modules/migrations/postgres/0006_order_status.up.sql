@@ -0,0 +1,15 @@
+-- Existing orders start the status lifecycle as created
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'created';
+CREATE INDEX IF NOT EXISTS idx_order_status ON "order" (status);
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id          bigserial PRIMARY KEY,
+    order_id    bigint      NOT NULL,
+    from_status varchar(20),
+    to_status   varchar(20) NOT NULL,
+    reason      varchar(255),
+    source      varchar(20),
+    changed_at  bigint,
+    CONSTRAINT fk_order_history FOREIGN KEY (order_id) REFERENCES "order" (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history (order_id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS outbox_event (
+    id           bigserial PRIMARY KEY,
+    event_id     varchar(36) NOT NULL,
+    event_type   varchar(50) NOT NULL,
+    aggregate_id varchar(50) NOT NULL,
+    payload      jsonb       NOT NULL,
+    created_at   bigint      NOT NULL,
+    published_at bigint,
+    CONSTRAINT uni_outbox_event_event_id UNIQUE (event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_event_aggregate_id ON outbox_event (aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_event_published_at ON outbox_event (published_at);
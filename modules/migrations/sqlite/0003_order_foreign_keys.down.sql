@@ -0,0 +1,58 @@
+CREATE TABLE order_delivery_new (
+    id       integer PRIMARY KEY AUTOINCREMENT,
+    order_id bigint      NOT NULL,
+    name     varchar(50) NOT NULL,
+    phone    varchar(12) NOT NULL,
+    zip      varchar(12) NOT NULL,
+    city     varchar(50) NOT NULL,
+    address  varchar(50) NOT NULL,
+    region   varchar(50) NOT NULL,
+    email    varchar(50) NOT NULL
+);
+INSERT INTO order_delivery_new (id, order_id, name, phone, zip, city, address, region, email)
+SELECT id, order_id, name, phone, zip, city, address, region, email FROM order_delivery;
+DROP TABLE order_delivery;
+ALTER TABLE order_delivery_new RENAME TO order_delivery;
+
+CREATE TABLE order_payment_new (
+    id            integer PRIMARY KEY AUTOINCREMENT,
+    order_id      bigint      NOT NULL,
+    "transaction" varchar(50) NOT NULL,
+    request_id    varchar(50),
+    currency      varchar(5)  NOT NULL,
+    provider      varchar(50) NOT NULL,
+    amount        integer     NOT NULL,
+    payment_dt    integer     NOT NULL,
+    bank          varchar(50) NOT NULL,
+    delivery_cost integer     NOT NULL,
+    goods_total   integer     NOT NULL,
+    custom_fee    integer
+);
+INSERT INTO order_payment_new (id, order_id, "transaction", request_id, currency, provider, amount, payment_dt,
+    bank, delivery_cost, goods_total, custom_fee)
+SELECT id, order_id, "transaction", request_id, currency, provider, amount, payment_dt,
+    bank, delivery_cost, goods_total, custom_fee FROM order_payment;
+DROP TABLE order_payment;
+ALTER TABLE order_payment_new RENAME TO order_payment;
+
+CREATE TABLE order_item_new (
+    id           integer PRIMARY KEY AUTOINCREMENT,
+    order_id     bigint      NOT NULL,
+    chrt_id      integer     NOT NULL,
+    track_number varchar(50) NOT NULL,
+    price        integer     NOT NULL,
+    rid          varchar(50) NOT NULL,
+    name         varchar(50) NOT NULL,
+    sale         integer     NOT NULL,
+    size         varchar(5)  NOT NULL,
+    total_price  integer     NOT NULL,
+    nm_id        integer     NOT NULL,
+    brand        varchar(50) NOT NULL,
+    status       integer     NOT NULL
+);
+INSERT INTO order_item_new (id, order_id, chrt_id, track_number, price, rid, name, sale, size, total_price,
+    nm_id, brand, status)
+SELECT id, order_id, chrt_id, track_number, price, rid, name, sale, size, total_price,
+    nm_id, brand, status FROM order_item;
+DROP TABLE order_item;
+ALTER TABLE order_item_new RENAME TO order_item;
@@ -0,0 +1,16 @@
+-- Indexes for loading order parts, list filters and keyset pagination
+CREATE INDEX IF NOT EXISTS idx_order_track_number ON "order" (track_number);
+CREATE INDEX IF NOT EXISTS idx_order_locale ON "order" (locale);
+CREATE INDEX IF NOT EXISTS idx_order_customer_id ON "order" (customer_id);
+CREATE INDEX IF NOT EXISTS idx_order_delivery_service ON "order" (delivery_service);
+CREATE INDEX IF NOT EXISTS idx_order_date_created_id ON "order" (date_created, id);
+CREATE INDEX IF NOT EXISTS idx_order_delivery_order_id ON order_delivery (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_payment_order_id ON order_payment (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_payment_transaction ON order_payment ("transaction");
+CREATE INDEX IF NOT EXISTS idx_order_payment_request_id ON order_payment (request_id);
+CREATE INDEX IF NOT EXISTS idx_order_payment_provider ON order_payment (provider);
+CREATE INDEX IF NOT EXISTS idx_order_payment_bank ON order_payment (bank);
+CREATE INDEX IF NOT EXISTS idx_order_item_order_id ON order_item (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_item_track_number ON order_item (track_number);
+CREATE INDEX IF NOT EXISTS idx_order_item_nm_id ON order_item (nm_id);
+CREATE INDEX IF NOT EXISTS idx_order_item_brand ON order_item (brand);
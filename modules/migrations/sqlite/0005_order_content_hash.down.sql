@@ -0,0 +1 @@
+ALTER TABLE "order" DROP COLUMN content_hash;
@@ -57,6 +57,25 @@ var (
 			Help: "Total number of cache misses",
 		},
 	)
+	cacheEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Total number of entries evicted from the memory cache",
+		},
+		[]string{"reason"},
+	)
+	cacheEntries = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cache_entries",
+			Help: "Number of entries in the memory cache",
+		},
+	)
+	cacheSizeBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cache_size_bytes",
+			Help: "Approximate memory held by the memory cache entries",
+		},
+	)
 	databaseQueries = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "database_queries_total",
@@ -117,6 +136,9 @@ func (m *Monitoring) InitWithRegistry(errChan chan error, registry *prometheus.R
 		httpRequestDuration,
 		cacheHits,
 		cacheMisses,
+		cacheEvictions,
+		cacheEntries,
+		cacheSizeBytes,
 		databaseQueries,
 		databaseQueryDuration,
 		kafkaMessagesProcessed,
@@ -152,9 +174,14 @@ func (m *Monitoring) InitWithRegistry(errChan chan error, registry *prometheus.R
 		metricsPort = config.MetricsPort
 	}
 	metricsAddr := fmt.Sprintf("0.0.0.0:%d", metricsPort)
+	// The metrics server also answers health probes, so processes without
+	// the HTTP API can be probed
+	mux := http.NewServeMux()
+	mux.Handle("/", promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{}))
+	m.health.Mount(mux)
 	m.server = &http.Server{
 		Addr:    metricsAddr,
-		Handler: promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{}),
+		Handler: mux,
 	}
 	go func() {
 		logger.Info("Starting metrics server", zap.String("address", metricsAddr))
@@ -217,6 +244,17 @@ func IncrementCacheMisses() {
 	cacheMisses.Inc()
 }
 
+// IncrementCacheEvictions counts an entry evicted for reason: capacity,
+// memory or expired
+func IncrementCacheEvictions(reason string) {
+	cacheEvictions.WithLabelValues(reason).Inc()
+}
+
+func SetCacheSize(entries int, bytes int64) {
+	cacheEntries.Set(float64(entries))
+	cacheSizeBytes.Set(float64(bytes))
+}
+
 func IncrementDatabaseQueries(operation, table string) {
 	databaseQueries.WithLabelValues(operation, table).Inc()
 }
@@ -24,19 +24,73 @@ func (m *mockDB) HealthCheck(ctx context.Context) error { return assert.AnError
 func (m *mockDB) GetOrderById(ctx context.Context, orderId string) (*structs.Order, error) {
 	panic("not implemented")
 }
+func (m *mockDB) GetOrdersByIds(ctx context.Context, orderIds []string) ([]*structs.Order, error) {
+	panic("not implemented")
+}
+func (m *mockDB) FindOrderIdsByTrackNumber(ctx context.Context, trackNumber string) ([]string, error) {
+	panic("not implemented")
+}
+func (m *mockDB) FindOrderIdsByTransaction(ctx context.Context, transaction string) ([]string, error) {
+	panic("not implemented")
+}
+func (m *mockDB) FindOrderIdsByRequestId(ctx context.Context, requestId string) ([]string, error) {
+	panic("not implemented")
+}
+func (m *mockDB) ListOrders(ctx context.Context, filter *structs.OrderFilter) (*structs.OrderPage, error) {
+	panic("not implemented")
+}
 func (m *mockDB) InsertOrder(ctx context.Context, order *structs.Order) error {
 	panic("not implemented")
 }
 
+func (m *mockDB) InsertOrders(ctx context.Context, orders []*structs.Order) ([]error, error) {
+	panic("not implemented")
+}
+
+func (m *mockDB) UpdateOrderStatus(ctx context.Context, oid string, change *structs.OrderStatusChange) error {
+	panic("not implemented")
+}
+
+func (m *mockDB) GetOrderStatusHistory(ctx context.Context, oid string) (*structs.OrderStatusHistory, error) {
+	panic("not implemented")
+}
+
+func (m *mockDB) PublishOutbox(ctx context.Context, limit int,
+	publish func(context.Context, []*structs.OrderEvent) error) (int, error) {
+	panic("not implemented")
+}
+
+func (m *mockDB) PruneOutbox(ctx context.Context, before time.Time) (int64, error) {
+	panic("not implemented")
+}
+
 type mockCache struct{}
 
 func (m *mockCache) HealthCheck(ctx context.Context) error { return assert.AnError }
 func (m *mockCache) GetOrder(ctx context.Context, orderId string) (*structs.Order, error) {
 	panic("not implemented")
 }
+func (m *mockCache) GetOrders(ctx context.Context, orderIds []string) (map[string]*structs.Order, error) {
+	panic("not implemented")
+}
 func (m *mockCache) PutOrder(ctx context.Context, orderId string, order *structs.Order) error {
 	panic("not implemented")
 }
+func (m *mockCache) DeleteOrder(ctx context.Context, orderId string) error {
+	panic("not implemented")
+}
+func (m *mockCache) DeleteOrders(ctx context.Context, orderIds []string) (int, error) {
+	panic("not implemented")
+}
+func (m *mockCache) Flush(ctx context.Context) error {
+	panic("not implemented")
+}
+func (m *mockCache) Stats(ctx context.Context) (*structs.CacheStats, error) {
+	panic("not implemented")
+}
+func (m *mockCache) Keys(ctx context.Context, pattern string, limit int) ([]string, error) {
+	panic("not implemented")
+}
 
 type mockBroker struct{}
 
@@ -0,0 +1,107 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"wb-L0/modules/config"
+	"wb-L0/modules/graceful"
+)
+
+const (
+	defaultUrl      = nats.DefaultURL
+	defaultStream   = "ORDERS"
+	defaultSubject  = "orders"
+	defaultConsumer = "wb-l0"
+	// ackWait is how long JetStream waits for an ack before redelivering
+	ackWait = time.Minute
+)
+
+type Nats struct {
+	Conn      *nats.Conn
+	JetStream jetstream.JetStream
+	Consumer  jetstream.Consumer
+}
+
+// Init connects to NATS and ensures the stream and the durable pull consumer
+// exist. The stream captures the order subject and, when configured, the
+// status subject (KAFKA_STATUS_TOPIC).
+func (n *Nats) Init(_ chan error) error {
+	conf := config.GetConfig()
+	url := conf.NatsUrl
+	if url == "" {
+		url = defaultUrl
+	}
+	conn, err := nats.Connect(url,
+		nats.Name("wb-l0"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Printf("[NATS-ERROR] disconnected: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			log.Printf("[NATS] reconnected to %s", conn.ConnectedUrl())
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("nats connection failed: %v", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("jetstream initialization failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(graceful.GetContext(), 10*time.Second)
+	defer cancel()
+	stream := valueOr(conf.NatsStream, defaultStream)
+	subjects := []string{valueOr(conf.NatsSubject, defaultSubject)}
+	if conf.KafkaStatusTopic != "" {
+		subjects = append(subjects, conf.KafkaStatusTopic)
+	}
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     stream,
+		Subjects: subjects,
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("jetstream stream %s setup failed: %v", stream, err)
+	}
+	consumer, err := js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       valueOr(conf.NatsConsumer, defaultConsumer),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       ackWait,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+		MaxDeliver:    -1,
+	})
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("jetstream consumer setup failed: %v", err)
+	}
+	n.Conn = conn
+	n.JetStream = js
+	n.Consumer = consumer
+	return nil
+}
+
+func (n *Nats) SuccessfulMessage() string {
+	return "NATS JetStream successfully initialized"
+}
+
+func (n *Nats) Shutdown(_ context.Context) error {
+	return n.Conn.Drain()
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -8,6 +8,7 @@ import (
 	"gorm.io/gorm"
 
 	"wb-L0/modules/config"
+	"wb-L0/modules/migrations"
 )
 
 var (
@@ -19,19 +20,27 @@ type Postgres struct {
 }
 
 func (p *Postgres) Init(_ chan error) error {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
-		config.GetConfig().DbHost, config.GetConfig().DbUser, config.GetConfig().DbPass,
-		config.GetConfig().DbName, config.GetConfig().DbPort)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := Connect()
 	if err != nil {
-		return fmt.Errorf("failed to connect to PostgreSQL: %v", err)
+		return err
 	}
 	p.Db = db
-	err = db.AutoMigrate(models...)
+	if config.GetConfig().DbSkipMigrations {
+		return nil
+	}
+	return migrations.Run(context.Background(), db)
+}
+
+// Connect opens the configured database without migrating it
+func Connect() (*gorm.DB, error) {
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
+		config.GetConfig().DbHost, config.GetConfig().DbUser, config.GetConfig().DbPass,
+		config.GetConfig().DbName, config.GetConfig().DbPort)
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
-		return fmt.Errorf("failed to migrate models: %v", err)
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %v", err)
 	}
-	return nil
+	return db, nil
 }
 
 func (p *Postgres) SuccessfulMessage() string {
@@ -53,3 +62,9 @@ func (p *Postgres) GetEngine(ctx context.Context) *gorm.DB {
 func RegisterModel(model interface{}) {
 	models = append(models, model)
 }
+
+// Models returns the registered models so tests can check the migrations
+// against them
+func Models() []interface{} {
+	return models
+}
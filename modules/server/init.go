@@ -31,6 +31,7 @@ func (s *Server) Init(errChan chan error) error {
 	r.Use(monitoring.MonitoringMiddleware())
 
 	routing.MountSystemRoutes(r)
+	routing.MountAdminRoutes(r)
 	routing.MountPurchasesRoutes(r)
 	routing.MountFrontRoutes(r)
 	addr := fmt.Sprintf("0.0.0.0:%d", config.GetConfig().AppPort)
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"wb-L0/modules/config"
+	"wb-L0/modules/migrations"
+)
+
+const defaultPath = "wb_l0.db"
+
+// pragmas enable cascading deletes, let writers wait for each other and use
+// the write-ahead log so commits do not sync the whole database file
+const pragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)" +
+	"&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)"
+
+// Sqlite is a file or in-memory SQLite database using the Postgres models
+type Sqlite struct {
+	Db *gorm.DB
+}
+
+func (s *Sqlite) Init(_ chan error) error {
+	db, err := Connect(Path())
+	if err != nil {
+		return err
+	}
+	s.Db = db
+	if config.GetConfig().DbSkipMigrations {
+		return nil
+	}
+	return migrations.Run(context.Background(), db)
+}
+
+// Path returns the configured database path
+func Path() string {
+	if config.GetConfig().SqlitePath == "" {
+		return defaultPath
+	}
+	return config.GetConfig().SqlitePath
+}
+
+// Connect opens the database at path without migrating it; ":memory:" keeps
+// it in memory. A single connection is used, so transactions run one at a
+// time and an in-memory database is shared by all queries.
+func Connect(path string) (*gorm.DB, error) {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	db, err := gorm.Open(sqlite.Open(path+separator+pragmas), &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database %s: %v", path, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DB connection: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return db, nil
+}
+
+func (s *Sqlite) SuccessfulMessage() string {
+	return "SQLite successfully initialized"
+}
+
+func (s *Sqlite) Shutdown(_ context.Context) error {
+	db, err := s.Db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get DB connection: %v", err)
+	}
+	return db.Close()
+}
+
+func (s *Sqlite) GetEngine(ctx context.Context) *gorm.DB {
+	return s.Db.WithContext(ctx)
+}
@@ -0,0 +1,62 @@
+package routing
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"wb-L0/handlers"
+	"wb-L0/modules/config"
+	"wb-L0/structs"
+)
+
+// AdminAuthMiddleware requires the ADMIN_TOKEN as a bearer token and rejects
+// every request while the token is not configured
+func AdminAuthMiddleware() gin.HandlerFunc {
+	token := ""
+	if conf := config.GetConfig(); conf != nil {
+		token = conf.AdminToken
+	}
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, structs.ApiError{Message: "ADMIN_TOKEN is not configured"})
+			return
+		}
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, structs.ApiError{Message: "missing or wrong admin token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// acceptsPushedMessages reports whether messages may be published over HTTP.
+// Only the development brokers accept them; production brokers are fed by
+// their producers alone.
+func acceptsPushedMessages() bool {
+	conf := config.GetConfig()
+	return conf != nil && (conf.BrokerType == "memory" || conf.BrokerType == "file")
+}
+
+func MountAdminRoutes(r *gin.Engine) {
+	admin := r.Group("/admin", AdminAuthMiddleware())
+	dlq := admin.Group("/dlq")
+	dlq.GET("", handlers.ListDeadLetters)
+	dlq.POST("/replay", handlers.ReplayDeadLetters)
+	replay := admin.Group("/replay")
+	replay.POST("/offsets", handlers.ReplayOffsets)
+	if acceptsPushedMessages() {
+		brokerGroup := admin.Group("/broker")
+		brokerGroup.POST("/messages", handlers.PushBrokerMessage)
+	}
+	cacheGroup := admin.Group("/cache")
+	cacheGroup.GET("", handlers.GetCacheStats)
+	cacheGroup.DELETE("", handlers.FlushCache)
+	cacheGroup.GET("/keys", handlers.ListCacheKeys)
+	cacheGroup.POST("/invalidate", handlers.InvalidateCachedOrders)
+	cacheGroup.DELETE("/orders/:order_id", handlers.InvalidateCachedOrder)
+}
@@ -0,0 +1,80 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/modules/config"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func() *gin.Engine {
+		router := gin.New()
+		router.GET("/admin/cache", AdminAuthMiddleware(), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+	request := func(router *gin.Engine, authorization string) int {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin/cache", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	t.Setenv("APP_PORT", "8080")
+	t.Setenv("ADMIN_TOKEN", "")
+	require.NoError(t, new(config.Config).Init(nil))
+	assert.Equal(t, http.StatusForbidden, request(newRouter(), "Bearer "))
+
+	t.Setenv("ADMIN_TOKEN", "secret")
+	require.NoError(t, new(config.Config).Init(nil))
+	router := newRouter()
+	assert.Equal(t, http.StatusUnauthorized, request(router, ""))
+	assert.Equal(t, http.StatusUnauthorized, request(router, "Bearer wrong"))
+	assert.Equal(t, http.StatusUnauthorized, request(router, "secret"))
+	assert.Equal(t, http.StatusOK, request(router, "Bearer secret"))
+}
+
+// TestMountAdminRoutes tests that no admin endpoint answers without the token
+func TestMountAdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("APP_PORT", "8080")
+	t.Setenv("ADMIN_TOKEN", "secret")
+	require.NoError(t, new(config.Config).Init(nil))
+	router := gin.New()
+	MountAdminRoutes(router)
+
+	for _, route := range router.Routes() {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(route.Method, route.Path, nil))
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code, route.Method+" "+route.Path)
+	}
+}
+
+// TestMountAdminRoutesPush tests that messages can be pushed only to the development brokers
+func TestMountAdminRoutesPush(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("APP_PORT", "8080")
+	for brokerType, mounted := range map[string]bool{"kafka": false, "nats": false, "memory": true, "file": true} {
+		t.Setenv("BROKER_TYPE", brokerType)
+		require.NoError(t, new(config.Config).Init(nil))
+		router := gin.New()
+		MountAdminRoutes(router)
+
+		found := false
+		for _, route := range router.Routes() {
+			found = found || route.Path == "/admin/broker/messages"
+		}
+		assert.Equal(t, mounted, found, brokerType)
+	}
+}
@@ -17,5 +17,14 @@ func ApiContextMiddleware() gin.HandlerFunc {
 func MountPurchasesRoutes(r *gin.Engine) {
 	api := r.Group("/api")
 	order := api.Group("/order")
+	order.POST("", handlers.CreatePurchase)
 	order.GET("/:order_id", handlers.GetPurchase)
+	order.GET("/:order_id/status", handlers.GetPurchaseStatus)
+	order.PATCH("/:order_id/status", handlers.UpdatePurchaseStatus)
+	orders := api.Group("/orders")
+	orders.GET("", handlers.ListPurchases)
+	orders.POST("/lookup", handlers.LookupPurchases)
+	orders.GET("/by-track/:track_number", handlers.GetPurchasesByTrackNumber)
+	orders.GET("/by-transaction/:transaction", handlers.GetPurchasesByTransaction)
+	orders.GET("/by-request/:request_id", handlers.GetPurchasesByRequestId)
 }
@@ -36,101 +36,44 @@ func MountSystemRoutes(r *gin.Engine) {
 }
 
 func healthCheck(c *gin.Context) {
-	status := health.HealthStatus{
-		Status:    "healthy",
-		Timestamp: time.Now().Format(time.RFC3339),
-		Services:  make(map[string]string),
-	}
-
 	// Get monitoring instance and health checker
 	mon := monitoring.GetMonitoring()
 	if mon == nil {
-		status.Status = "unhealthy"
-		status.Services["monitoring"] = "not initialized"
-		c.JSON(http.StatusServiceUnavailable, status)
+		monitoringUnavailable(c, "unhealthy")
 		return
 	}
-
-	healthChecker := mon.GetHealthChecker()
-
-	// Check database health
-	if err := healthChecker.CheckDatabaseHealth(); err != nil {
-		status.Status = "unhealthy"
-		status.Services["database"] = "error"
-	} else {
-		status.Services["database"] = "healthy"
-	}
-
-	// Check cache health
-	if err := healthChecker.CheckCacheHealth(); err != nil {
-		status.Status = "unhealthy"
-		status.Services["cache"] = "error"
-	} else {
-		status.Services["cache"] = "healthy"
-	}
-
-	// Check kafka health
-	if err := healthChecker.CheckBrokerHealth(); err != nil {
-		status.Status = "unhealthy"
-		status.Services["kafka"] = "error"
-	} else {
-		status.Services["kafka"] = "healthy"
-	}
-
-	if status.Status == "healthy" {
-		c.JSON(http.StatusOK, status)
-	} else {
-		c.JSON(http.StatusServiceUnavailable, status)
-	}
+	status, ok := mon.GetHealthChecker().Health()
+	writeHealthStatus(c, status, ok)
 }
 
 func readinessCheck(c *gin.Context) {
 	// Readiness check - service is ready to receive traffic
-	status := health.HealthStatus{
-		Status:    "ready",
-		Timestamp: time.Now().Format(time.RFC3339),
-		Services:  make(map[string]string),
-	}
-
-	// Get monitoring instance and health checker
 	mon := monitoring.GetMonitoring()
 	if mon == nil {
-		status.Status = "not ready"
-		status.Services["monitoring"] = "not initialized"
-		c.JSON(http.StatusServiceUnavailable, status)
-		return
-	}
-
-	healthChecker := mon.GetHealthChecker()
-
-	// Check if all required services are available
-	if err := healthChecker.CheckDatabaseHealth(); err != nil {
-		status.Status = "not ready"
-		status.Services["database"] = "not available"
-		c.JSON(http.StatusServiceUnavailable, status)
-		return
-	}
-
-	if err := healthChecker.CheckCacheHealth(); err != nil {
-		status.Status = "not ready"
-		status.Services["cache"] = "not available"
-		c.JSON(http.StatusServiceUnavailable, status)
+		monitoringUnavailable(c, "not ready")
 		return
 	}
-
-	status.Services["database"] = "ready"
-	status.Services["cache"] = "ready"
-	c.JSON(http.StatusOK, status)
+	status, ok := mon.GetHealthChecker().Readiness()
+	writeHealthStatus(c, status, ok)
 }
 
 func livenessCheck(c *gin.Context) {
 	// Liveness check - service is alive and running
-	status := health.HealthStatus{
-		Status:    "alive",
+	c.JSON(http.StatusOK, health.Liveness())
+}
+
+func monitoringUnavailable(c *gin.Context, state string) {
+	c.JSON(http.StatusServiceUnavailable, health.HealthStatus{
+		Status:    state,
 		Timestamp: time.Now().Format(time.RFC3339),
-		Services:  make(map[string]string),
-	}
+		Services:  map[string]string{"monitoring": "not initialized"},
+	})
+}
 
-	status.Services["application"] = "running"
-	c.JSON(http.StatusOK, status)
+func writeHealthStatus(c *gin.Context, status *health.HealthStatus, ok bool) {
+	if ok {
+		c.JSON(http.StatusOK, status)
+	} else {
+		c.JSON(http.StatusServiceUnavailable, status)
+	}
 }
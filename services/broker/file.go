@@ -0,0 +1,299 @@
+package broker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// filePollInterval is how often the file is checked for appended lines
+const filePollInterval = 500 * time.Millisecond
+
+// FileBroker streams the lines of a JSONL file as messages of the order
+// topic, with the zero-based line number as offset. It behaves like a single
+// Kafka partition: Ack commits the line and every line before it, and Nack
+// rewinds the stream to the line. The committed position is kept in a
+// "<path>.offset" file, so a restart resumes after the last committed line.
+// Lines appended while consuming are picked up; blank lines are skipped.
+type FileBroker struct {
+	path      string
+	topic     string
+	mutex     sync.Mutex
+	committed int64
+	rewind    int64 // -1 when no rewind is pending
+}
+
+func NewFileBroker(path string) (*FileBroker, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file broker requires FILE_BROKER_PATH")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("file broker: %w", err)
+	}
+	broker := &FileBroker{path: path, topic: orderTopic(), rewind: -1}
+	data, err := os.ReadFile(broker.offsetPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("file broker: %w", err)
+	}
+	if len(data) > 0 {
+		broker.committed, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("file broker: invalid offset file %s: %w", broker.offsetPath(), err)
+		}
+	}
+	return broker, nil
+}
+
+func (b *FileBroker) StartConsuming(ctx context.Context) chan Message {
+	resultChan := make(chan Message, 1)
+	go func() {
+		defer close(resultChan)
+		b.mutex.Lock()
+		start := b.committed
+		b.mutex.Unlock()
+		cursor, err := openLines(b.path, start)
+		if err != nil {
+			resultChan <- Message{Err: err}
+			return
+		}
+		defer func() {
+			cursor.close()
+		}()
+		for {
+			if offset := b.takeRewind(); offset >= 0 && offset < cursor.next {
+				cursor.close()
+				if cursor, err = openLines(b.path, offset); err != nil {
+					resultChan <- Message{Err: err}
+					return
+				}
+			}
+			line, err := cursor.readLine()
+			if errors.Is(err, io.EOF) {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(filePollInterval):
+					continue
+				}
+			}
+			if err != nil {
+				resultChan <- Message{Err: err}
+				return
+			}
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			select {
+			case resultChan <- b.message(cursor.next-1, line):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return resultChan
+}
+
+func (b *FileBroker) message(offset int64, line []byte) Message {
+	return Message{
+		Value:  line,
+		Topic:  b.topic,
+		Offset: offset,
+		Ack: func() error {
+			return b.commit(offset + 1)
+		},
+		Nack: func() error {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+			if b.rewind < 0 || offset < b.rewind {
+				b.rewind = offset
+			}
+			return nil
+		},
+	}
+}
+
+// commit stores the position the next run starts from; it never moves back
+func (b *FileBroker) commit(next int64) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if next <= b.committed {
+		return nil
+	}
+	err := os.WriteFile(b.offsetPath(), []byte(strconv.FormatInt(next, 10)), 0o644)
+	if err != nil {
+		return fmt.Errorf("file broker commit failed: %w", err)
+	}
+	b.committed = next
+	return nil
+}
+
+func (b *FileBroker) takeRewind() int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	offset := b.rewind
+	b.rewind = -1
+	return offset
+}
+
+func (b *FileBroker) offsetPath() string {
+	return b.path + ".offset"
+}
+
+// ReadRange reads the lines with offsets in [from, to]
+func (b *FileBroker) ReadRange(_ context.Context, topic string, partition int, from, to int64) ([]Message, error) {
+	if (topic != "" && topic != b.topic) || partition != 0 {
+		return nil, fmt.Errorf("file broker only has partition 0 of topic %s", b.topic)
+	}
+	cursor, err := openLines(b.path, from)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.close()
+	messages := make([]Message, 0)
+	for cursor.next <= to {
+		line, err := cursor.readLine()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		messages = append(messages, Message{
+			Value:  line,
+			Topic:  b.topic,
+			Offset: cursor.next - 1,
+			Ack:    noop,
+			Nack:   noop,
+		})
+	}
+	return messages, nil
+}
+
+// Push appends the message as a line and returns its line number as the
+// offset. The file has no headers, so only JSON messages of the order topic
+// are accepted; JSON spanning several lines is compacted to one.
+func (b *FileBroker) Push(_ context.Context, message Message) (Message, error) {
+	if message.Topic != "" && message.Topic != b.topic {
+		return Message{}, fmt.Errorf("file broker only has topic %s", b.topic)
+	}
+	if contentType := message.Headers["content-type"]; contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "application/json" {
+			return Message{}, fmt.Errorf("file broker only carries JSON lines, not %s", contentType)
+		}
+	}
+	line := message.Value
+	if bytes.ContainsAny(line, "\r\n") {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, line); err != nil {
+			return Message{}, fmt.Errorf("file broker: message spans several lines and is not JSON: %w", err)
+		}
+		line = compact.Bytes()
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return Message{}, fmt.Errorf("file broker: %w", err)
+	}
+	record := make([]byte, 0, len(line)+2)
+	offset := int64(bytes.Count(data, []byte("\n")))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		// Terminate a last line written without a line break
+		record = append(record, '\n')
+		offset++
+	}
+	record = append(append(record, line...), '\n')
+	file, err := os.OpenFile(b.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return Message{}, fmt.Errorf("file broker: %w", err)
+	}
+	_, err = file.Write(record)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Message{}, fmt.Errorf("file broker: %w", err)
+	}
+	message.Topic = b.topic
+	message.Partition = 0
+	message.Offset = offset
+	return message, nil
+}
+
+func (b *FileBroker) HealthCheck(_ context.Context) error {
+	_, err := os.Stat(b.path)
+	return err
+}
+
+// lineCursor reads complete lines of a file that may still be growing
+type lineCursor struct {
+	file    *os.File
+	reader  *bufio.Reader
+	next    int64
+	partial []byte
+	// unterminated is set after returning a line that had no line break yet
+	unterminated bool
+}
+
+// openLines opens the file positioned at the line with the given offset, or
+// at its end when the file is shorter
+func openLines(path string, offset int64) (*lineCursor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("file broker: %w", err)
+	}
+	cursor := &lineCursor{file: file, reader: bufio.NewReader(file)}
+	for cursor.next < offset {
+		if _, err := cursor.readLine(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			cursor.close()
+			return nil, err
+		}
+	}
+	return cursor, nil
+}
+
+// readLine returns the next complete line without its line break. At the end
+// of the file an unterminated line is returned only if it is valid JSON, as
+// the writer may still be appending to it; otherwise io.EOF is returned until
+// it is completed.
+func (c *lineCursor) readLine() ([]byte, error) {
+	for {
+		data, err := c.reader.ReadBytes('\n')
+		c.partial = append(c.partial, data...)
+		if err != nil && (!errors.Is(err, io.EOF) || !json.Valid(c.partial)) {
+			return nil, err
+		}
+		line := bytes.TrimRight(c.partial, "\r\n")
+		c.partial = nil
+		if c.unterminated && err == nil && len(line) == 0 {
+			// Line break completing the line returned before
+			c.unterminated = false
+			continue
+		}
+		c.unterminated = err != nil
+		c.next++
+		return line, nil
+	}
+}
+
+func (c *lineCursor) close() {
+	c.file.Close()
+}
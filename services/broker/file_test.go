@@ -0,0 +1,138 @@
+package broker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeLines(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// TestFileBrokerStreamsLines tests that non-blank lines are delivered with their line number as offset
+func TestFileBrokerStreamsLines(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fileBroker, err := NewFileBroker(writeLines(t, "{\"a\":1}\n\n{\"b\":2}\r\n{\"c\":3}"))
+	require.NoError(t, err)
+
+	messageChan := fileBroker.StartConsuming(ctx)
+
+	first := receive(t, messageChan)
+	assert.Equal(t, `{"a":1}`, string(first.Value))
+	assert.Equal(t, int64(0), first.Offset)
+	assert.Equal(t, "orders", first.Topic)
+	second := receive(t, messageChan)
+	assert.Equal(t, `{"b":2}`, string(second.Value))
+	assert.Equal(t, int64(2), second.Offset)
+	// The last line has no line break but is complete JSON
+	third := receive(t, messageChan)
+	assert.Equal(t, `{"c":3}`, string(third.Value))
+	assert.Equal(t, int64(3), third.Offset)
+}
+
+// TestFileBrokerNackRewinds tests that a nacked line and the lines after it are delivered again
+func TestFileBrokerNackRewinds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fileBroker, err := NewFileBroker(writeLines(t, "{\"a\":1}\n{\"b\":2}\n{\"c\":3}\n"))
+	require.NoError(t, err)
+	messageChan := fileBroker.StartConsuming(ctx)
+
+	require.NoError(t, receive(t, messageChan).Ack())
+	second := receive(t, messageChan)
+	require.NoError(t, second.Nack())
+
+	var offsets []int64
+	for len(offsets) < 2 {
+		message := receive(t, messageChan)
+		offsets = append(offsets, message.Offset)
+		require.NoError(t, message.Ack())
+	}
+	// The third line may have been read ahead before the rewind
+	if offsets[0] == 2 {
+		offsets = []int64{offsets[1], receive(t, messageChan).Offset}
+	}
+	assert.Equal(t, []int64{1, 2}, offsets)
+}
+
+// TestFileBrokerResumesAfterCommit tests that a new broker starts after the last acked line
+func TestFileBrokerResumesAfterCommit(t *testing.T) {
+	path := writeLines(t, "{\"a\":1}\n{\"b\":2}\n{\"c\":3}\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	fileBroker, err := NewFileBroker(path)
+	require.NoError(t, err)
+	messageChan := fileBroker.StartConsuming(ctx)
+	receive(t, messageChan)
+	require.NoError(t, receive(t, messageChan).Ack())
+	cancel()
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	fileBroker, err = NewFileBroker(path)
+	require.NoError(t, err)
+	message := receive(t, fileBroker.StartConsuming(ctx))
+
+	assert.Equal(t, int64(2), message.Offset)
+}
+
+// TestFileBrokerReadRange tests that offset ranges are read without committing
+func TestFileBrokerReadRange(t *testing.T) {
+	fileBroker, err := NewFileBroker(writeLines(t, "{\"a\":1}\n\n{\"b\":2}\n{\"c\":3}\n"))
+	require.NoError(t, err)
+
+	messages, err := fileBroker.ReadRange(context.Background(), "", 0, 1, 2)
+
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, int64(2), messages[0].Offset)
+	_, err = fileBroker.ReadRange(context.Background(), "", 1, 0, 1)
+	assert.Error(t, err)
+}
+
+// TestFileBrokerMissingFile tests that a missing file is reported on construction
+func TestFileBrokerMissingFile(t *testing.T) {
+	_, err := NewFileBroker(filepath.Join(t.TempDir(), "missing.jsonl"))
+
+	assert.Error(t, err)
+}
+
+// TestFileBrokerPush tests that pushed messages are appended as lines and consumed
+func TestFileBrokerPush(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fileBroker, err := NewFileBroker(writeLines(t, "{\"a\":1}\n\n{\"b\":2}"))
+	require.NoError(t, err)
+
+	pushed, err := fileBroker.Push(ctx, Message{Value: []byte("{\n  \"c\": 3\n}")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), pushed.Offset)
+	assert.Equal(t, "orders", pushed.Topic)
+	pushed, err = fileBroker.Push(ctx, Message{
+		Value:   []byte(`{"d":4}`),
+		Headers: map[string]string{"content-type": "application/json; charset=utf-8"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), pushed.Offset)
+
+	_, err = fileBroker.Push(ctx, Message{Value: []byte("x"), Headers: map[string]string{"content-type": "application/x-protobuf"}})
+	assert.Error(t, err)
+	_, err = fileBroker.Push(ctx, Message{Value: []byte(`{"e":5}`), Topic: "statuses"})
+	assert.Error(t, err)
+
+	messages, err := fileBroker.ReadRange(ctx, "", 0, 0, 10)
+	require.NoError(t, err)
+	values := make([]string, len(messages))
+	for i, message := range messages {
+		values[i] = string(message.Value)
+	}
+	assert.Equal(t, []string{`{"a":1}`, `{"b":2}`, `{"c":3}`, `{"d":4}`}, values)
+}
@@ -6,11 +6,23 @@ import (
 
 var brokerInstance Broker
 
+// KeyHeader carries the ordering key on brokers without message keys
+const KeyHeader = "key"
+
 type Message struct {
-	Value []byte
-	Err   error
-	Ack   func() error
-	Nack  func() error
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string // keyed by lowercased header name
+	Topic     string
+	Partition int
+	Offset    int64
+	Err       error
+	// IndividualAck is set by brokers that ack each message on its own.
+	// Otherwise Ack commits the offset, and with it every earlier offset of
+	// the partition, as Kafka does.
+	IndividualAck bool
+	Ack           func() error
+	Nack          func() error
 }
 
 type Broker interface {
@@ -18,6 +30,13 @@ type Broker interface {
 	HealthCheck(context.Context) error
 }
 
+// Replayer is implemented by brokers that can re-read already consumed
+// messages. Returned messages are detached from the consumer group, so their
+// Ack and Nack are no-ops.
+type Replayer interface {
+	ReadRange(ctx context.Context, topic string, partition int, from, to int64) ([]Message, error)
+}
+
 func SetBroker(broker Broker) {
 	brokerInstance = broker
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	kafka_lib "github.com/segmentio/kafka-go"
@@ -83,7 +84,12 @@ func (b *KafkaBroker) StartConsuming(ctx context.Context) chan Message {
 
 				msgCopy := msg
 				resultChan <- Message{
-					Value: msgCopy.Value,
+					Key:       msgCopy.Key,
+					Value:     msgCopy.Value,
+					Headers:   headers(msgCopy.Headers),
+					Topic:     msgCopy.Topic,
+					Partition: msgCopy.Partition,
+					Offset:    msgCopy.Offset,
 					Ack: func() error {
 						return b.commitWithRetry(ctx, msgCopy)
 					},
@@ -98,6 +104,70 @@ func (b *KafkaBroker) StartConsuming(ctx context.Context) chan Message {
 	return resultChan
 }
 
+func (b *KafkaBroker) ReadRange(ctx context.Context, topic string, partition int, from, to int64) ([]Message, error) {
+	if topic == "" {
+		topic = config.GetConfig().KafkaTopic
+	}
+	kafkaMessages, err := b.kafkaConn.ReadRange(ctx, topic, partition, from, to)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]Message, len(kafkaMessages))
+	for i, msg := range kafkaMessages {
+		messages[i] = Message{
+			Key:       msg.Key,
+			Value:     msg.Value,
+			Headers:   headers(msg.Headers),
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+			Ack:       noop,
+			Nack:      noop,
+		}
+	}
+	return messages, nil
+}
+
+// Push writes the message through the shared writer. The writer does not
+// report where the message landed, so partition and offset are -1.
+func (b *KafkaBroker) Push(ctx context.Context, message Message) (Message, error) {
+	if message.Topic == "" {
+		message.Topic = orderTopic()
+	}
+	kafkaMessage := kafka_lib.Message{
+		Topic: message.Topic,
+		Key:   message.Key,
+		Value: message.Value,
+	}
+	for key, value := range message.Headers {
+		kafkaMessage.Headers = append(kafkaMessage.Headers, kafka_lib.Header{Key: key, Value: []byte(value)})
+	}
+	err := b.kafkaConn.Writer.WriteMessages(ctx, kafkaMessage)
+	if err != nil {
+		return Message{}, fmt.Errorf("kafka write to %s failed: %w", message.Topic, err)
+	}
+	message.Partition = -1
+	message.Offset = -1
+	return message, nil
+}
+
+// headers converts Kafka headers to a map. Header names are case-insensitive;
+// the last header of a name wins.
+func headers(kafkaHeaders []kafka_lib.Header) map[string]string {
+	if len(kafkaHeaders) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(kafkaHeaders))
+	for _, header := range kafkaHeaders {
+		result[strings.ToLower(header.Key)] = string(header.Value)
+	}
+	return result
+}
+
+func noop() error {
+	return nil
+}
+
 func isCoordinatorError(err error) bool {
 	if kafkaErr, ok := err.(kafka_lib.Error); ok {
 		return errors.Is(kafkaErr, kafka_lib.GroupCoordinatorNotAvailable) ||
@@ -0,0 +1,139 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"wb-L0/modules/config"
+)
+
+// Pusher is implemented by brokers that messages can be published to. Only
+// Key, Value, Headers and Topic of the message are used; an empty topic
+// means the order topic. Push returns the message with its topic, partition
+// and offset assigned; brokers that do not report them leave -1.
+type Pusher interface {
+	Push(ctx context.Context, message Message) (Message, error)
+}
+
+var (
+	_ Pusher = (*MemoryBroker)(nil)
+	_ Pusher = (*KafkaBroker)(nil)
+	_ Pusher = (*NatsBroker)(nil)
+	_ Pusher = (*RabbitMqBroker)(nil)
+	_ Pusher = (*FileBroker)(nil)
+)
+
+// MemoryBroker is an in-process broker for local development and tests.
+// Pushed messages are delivered once; a nacked message is delivered again
+// before any message that was not delivered yet. Unacked messages are lost
+// on shutdown.
+type MemoryBroker struct {
+	messages  chan Message
+	wake      chan struct{}
+	mutex     sync.Mutex
+	offsets   map[string]int64
+	redeliver []Message
+}
+
+// NewMemoryBroker creates a broker buffering up to capacity undelivered
+// messages; Push blocks while the buffer is full
+func NewMemoryBroker(capacity int) *MemoryBroker {
+	return &MemoryBroker{
+		messages: make(chan Message, capacity),
+		wake:     make(chan struct{}, 1),
+		offsets:  make(map[string]int64),
+	}
+}
+
+func (b *MemoryBroker) Push(ctx context.Context, message Message) (Message, error) {
+	if message.Topic == "" {
+		message.Topic = orderTopic()
+	}
+	b.mutex.Lock()
+	message.Offset = b.offsets[message.Topic]
+	b.offsets[message.Topic]++
+	b.mutex.Unlock()
+	message.Partition = 0
+	message.Err = nil
+	select {
+	case b.messages <- message:
+		return message, nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+func (b *MemoryBroker) StartConsuming(ctx context.Context) chan Message {
+	resultChan := make(chan Message, 1)
+	go func() {
+		defer close(resultChan)
+		for {
+			message, ok := b.nextRedelivery()
+			if !ok {
+				select {
+				case <-ctx.Done():
+					return
+				case <-b.wake:
+					continue
+				case message = <-b.messages:
+				}
+			}
+			select {
+			case resultChan <- b.deliver(message):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return resultChan
+}
+
+// deliver attaches Ack and Nack to a copy of the message. Only the first of
+// them takes effect.
+func (b *MemoryBroker) deliver(message Message) Message {
+	var settled atomic.Bool
+	delivery := message
+	delivery.IndividualAck = true
+	delivery.Ack = func() error {
+		settled.Store(true)
+		return nil
+	}
+	delivery.Nack = func() error {
+		if settled.Swap(true) {
+			return nil
+		}
+		b.mutex.Lock()
+		b.redeliver = append(b.redeliver, message)
+		b.mutex.Unlock()
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+	return delivery
+}
+
+func (b *MemoryBroker) nextRedelivery() (Message, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.redeliver) == 0 {
+		return Message{}, false
+	}
+	message := b.redeliver[0]
+	b.redeliver = b.redeliver[1:]
+	return message, true
+}
+
+func (b *MemoryBroker) HealthCheck(_ context.Context) error {
+	return nil
+}
+
+// orderTopic is the configured order topic, "orders" by default
+func orderTopic() string {
+	if conf := config.GetConfig(); conf != nil && conf.KafkaTopic != "" {
+		return conf.KafkaTopic
+	}
+	return "orders"
+}
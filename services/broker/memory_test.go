@@ -0,0 +1,82 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, messageChan chan Message) Message {
+	t.Helper()
+	select {
+	case message := <-messageChan:
+		return message
+	case <-time.After(time.Second):
+		t.Fatal("no message delivered")
+		return Message{}
+	}
+}
+
+// TestMemoryBrokerPush tests that pushed messages are delivered in order with offsets per topic
+func TestMemoryBrokerPush(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	memoryBroker := NewMemoryBroker(10)
+
+	first, err := memoryBroker.Push(ctx, Message{Value: []byte("a"), Key: []byte("order-1")})
+	require.NoError(t, err)
+	second, err := memoryBroker.Push(ctx, Message{Value: []byte("b"), Topic: "orders.status"})
+	require.NoError(t, err)
+	third, err := memoryBroker.Push(ctx, Message{Value: []byte("c")})
+	require.NoError(t, err)
+	assert.Equal(t, "orders", first.Topic)
+	assert.Equal(t, []int64{0, 0, 1}, []int64{first.Offset, second.Offset, third.Offset})
+
+	messageChan := memoryBroker.StartConsuming(ctx)
+	for _, expected := range []string{"a", "b", "c"} {
+		message := receive(t, messageChan)
+		assert.Equal(t, expected, string(message.Value))
+		assert.True(t, message.IndividualAck)
+		require.NoError(t, message.Ack())
+	}
+}
+
+// TestMemoryBrokerNack tests that a nacked message is delivered again before undelivered ones
+func TestMemoryBrokerNack(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	memoryBroker := NewMemoryBroker(10)
+	messageChan := memoryBroker.StartConsuming(ctx)
+
+	_, err := memoryBroker.Push(ctx, Message{Value: []byte("a")})
+	require.NoError(t, err)
+	message := receive(t, messageChan)
+	require.NoError(t, message.Nack())
+	require.NoError(t, message.Nack())
+
+	redelivered := receive(t, messageChan)
+	assert.Equal(t, "a", string(redelivered.Value))
+	assert.Equal(t, message.Offset, redelivered.Offset)
+	require.NoError(t, redelivered.Ack())
+	require.NoError(t, redelivered.Nack())
+
+	_, err = memoryBroker.Push(ctx, Message{Value: []byte("b")})
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(receive(t, messageChan).Value))
+}
+
+// TestMemoryBrokerPushBlocksWhenFull tests that Push waits for space until its context ends
+func TestMemoryBrokerPushBlocksWhenFull(t *testing.T) {
+	memoryBroker := NewMemoryBroker(1)
+	_, err := memoryBroker.Push(context.Background(), Message{Value: []byte("a")})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = memoryBroker.Push(ctx, Message{Value: []byte("b")})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,136 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	nats_lib "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"wb-L0/modules/config"
+	"wb-L0/modules/nats"
+)
+
+// natsRedeliveryDelay delays redelivery of nacked messages so a failing
+// dependency is not retried in a tight loop
+const natsRedeliveryDelay = time.Second
+
+type NatsBroker struct {
+	nats *nats.Nats
+}
+
+func NewNatsBroker(natsInstance *nats.Nats) Broker {
+	return &NatsBroker{nats: natsInstance}
+}
+
+// StartConsuming pulls from the durable JetStream consumer. Messages are
+// acked one by one; a nacked message is redelivered by the server after
+// natsRedeliveryDelay, and an unacked one after the consumer ack wait.
+// The subject is reported as the topic and the stream sequence as the offset.
+func (b *NatsBroker) StartConsuming(ctx context.Context) chan Message {
+	resultChan := make(chan Message, 1)
+	go func() {
+		defer close(resultChan)
+		iterator, err := b.nats.Consumer.Messages()
+		if err != nil {
+			resultChan <- Message{Err: fmt.Errorf("jetstream consume failed: %w", err)}
+			return
+		}
+		go func() {
+			<-ctx.Done()
+			iterator.Stop()
+		}()
+		for {
+			msg, err := iterator.Next()
+			if err != nil {
+				if errors.Is(err, jetstream.ErrMsgIteratorClosed) || ctx.Err() != nil {
+					return
+				}
+				resultChan <- Message{Err: err}
+				continue
+			}
+			select {
+			case resultChan <- natsMessage(msg):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return resultChan
+}
+
+func natsMessage(msg jetstream.Msg) Message {
+	message := Message{
+		Value:         msg.Data(),
+		Headers:       natsHeaders(msg.Headers()),
+		Topic:         msg.Subject(),
+		IndividualAck: true,
+		Ack:           msg.Ack,
+		Nack: func() error {
+			return msg.NakWithDelay(natsRedeliveryDelay)
+		},
+	}
+	message.Key = []byte(message.Headers[KeyHeader])
+	if metadata, err := msg.Metadata(); err == nil {
+		message.Offset = int64(metadata.Sequence.Stream)
+	}
+	return message
+}
+
+// Push publishes the message to its subject and waits for the stream to
+// store it; the stream sequence is returned as the offset. The key travels
+// in the key header.
+func (b *NatsBroker) Push(ctx context.Context, message Message) (Message, error) {
+	if message.Topic == "" {
+		message.Topic = natsSubject()
+	}
+	msg := nats_lib.NewMsg(message.Topic)
+	msg.Data = message.Value
+	for key, value := range message.Headers {
+		msg.Header.Set(key, value)
+	}
+	if len(message.Key) > 0 {
+		msg.Header.Set(KeyHeader, string(message.Key))
+	}
+	ack, err := b.nats.JetStream.PublishMsg(ctx, msg)
+	if err != nil {
+		return Message{}, fmt.Errorf("jetstream publish to %s failed: %w", message.Topic, err)
+	}
+	message.Partition = 0
+	message.Offset = int64(ack.Sequence)
+	return message, nil
+}
+
+func natsSubject() string {
+	if subject := config.GetConfig().NatsSubject; subject != "" {
+		return subject
+	}
+	return "orders"
+}
+
+func natsHeaders(header nats_lib.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(header))
+	for key, values := range header {
+		if len(values) > 0 {
+			result[strings.ToLower(key)] = values[len(values)-1]
+		}
+	}
+	return result
+}
+
+// HealthCheck verifies the connection and that JetStream answers
+func (b *NatsBroker) HealthCheck(ctx context.Context) error {
+	if b.nats.Conn == nil || !b.nats.Conn.IsConnected() {
+		return fmt.Errorf("nats is not connected")
+	}
+	if _, err := b.nats.JetStream.AccountInfo(ctx); err != nil {
+		return fmt.Errorf("failed to reach jetstream: %w", err)
+	}
+	return nil
+}
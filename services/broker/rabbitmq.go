@@ -0,0 +1,139 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"wb-L0/modules/rabbitmq"
+)
+
+// rabbitMqRedeliveryDelay holds nacked deliveries before requeueing them so
+// a failing dependency is not retried in a tight loop
+const rabbitMqRedeliveryDelay = time.Second
+
+type RabbitMqBroker struct {
+	rabbitMq *rabbitmq.RabbitMQ
+}
+
+func NewRabbitMqBroker(rabbitMqInstance *rabbitmq.RabbitMQ) Broker {
+	return &RabbitMqBroker{rabbitMq: rabbitMqInstance}
+}
+
+// StartConsuming consumes every configured queue with manual acks. A nacked
+// delivery is requeued after rabbitMqRedeliveryDelay and redelivered with the
+// redelivered flag set; deliveries left unacked are requeued when the channel
+// closes. The queue
+// is reported as the topic and the delivery tag as the offset.
+func (b *RabbitMqBroker) StartConsuming(ctx context.Context) chan Message {
+	resultChan := make(chan Message, 1)
+	deliveries := make([]<-chan amqp.Delivery, 0, len(b.rabbitMq.Queues))
+	for _, queue := range b.rabbitMq.Queues {
+		delivery, err := b.rabbitMq.Channel.ConsumeWithContext(ctx, queue, "", false, false, false, false, nil)
+		if err != nil {
+			go func() {
+				defer close(resultChan)
+				resultChan <- Message{Err: fmt.Errorf("rabbitmq consume of %s failed: %w", queue, err)}
+			}()
+			return resultChan
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	done := make(chan struct{}, len(deliveries))
+	for i := range deliveries {
+		go func(queue string, delivery <-chan amqp.Delivery) {
+			defer func() { done <- struct{}{} }()
+			for d := range delivery {
+				select {
+				case resultChan <- rabbitMqMessage(queue, d, rabbitMqRedeliveryDelay):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(b.rabbitMq.Queues[i], deliveries[i])
+	}
+	go func() {
+		for range deliveries {
+			<-done
+		}
+		close(resultChan)
+	}()
+	return resultChan
+}
+
+// rabbitMqMessage maps the delivery. Nack returns at once and requeues the
+// delivery after the delay; it stays unacked, and counts against the
+// prefetch, until then.
+func rabbitMqMessage(queue string, d amqp.Delivery, redeliveryDelay time.Duration) Message {
+	headers := make(map[string]string, len(d.Headers)+1)
+	for key, value := range d.Headers {
+		headers[strings.ToLower(key)] = fmt.Sprint(value)
+	}
+	if d.ContentType != "" {
+		headers["content-type"] = d.ContentType
+	}
+	return Message{
+		Key:           []byte(headers[KeyHeader]),
+		Value:         d.Body,
+		Headers:       headers,
+		Topic:         queue,
+		Offset:        int64(d.DeliveryTag),
+		IndividualAck: true,
+		Ack: func() error {
+			return d.Ack(false)
+		},
+		Nack: func() error {
+			time.AfterFunc(redeliveryDelay, func() {
+				if err := d.Nack(false, true); err != nil {
+					log.Printf("rabbitmq nack of %s delivery %d failed: %v", queue, d.DeliveryTag, err)
+				}
+			})
+			return nil
+		},
+	}
+}
+
+// Push publishes a persistent message to the queue through the default
+// exchange. RabbitMQ does not number messages, so partition and offset are -1.
+func (b *RabbitMqBroker) Push(ctx context.Context, message Message) (Message, error) {
+	if message.Topic == "" {
+		message.Topic = b.rabbitMq.Queues[0]
+	}
+	publishing := amqp.Publishing{
+		Headers:      amqp.Table{},
+		DeliveryMode: amqp.Persistent,
+		Body:         message.Value,
+	}
+	for key, value := range message.Headers {
+		if key == "content-type" {
+			publishing.ContentType = value
+			continue
+		}
+		publishing.Headers[key] = value
+	}
+	if len(message.Key) > 0 {
+		publishing.Headers[KeyHeader] = string(message.Key)
+	}
+	err := b.rabbitMq.Channel.PublishWithContext(ctx, "", message.Topic, false, false, publishing)
+	if err != nil {
+		return Message{}, fmt.Errorf("rabbitmq publish to %s failed: %w", message.Topic, err)
+	}
+	message.Partition = -1
+	message.Offset = -1
+	return message, nil
+}
+
+// HealthCheck verifies that the connection and channel are open
+func (b *RabbitMqBroker) HealthCheck(_ context.Context) error {
+	if b.rabbitMq.Conn == nil || b.rabbitMq.Conn.IsClosed() {
+		return fmt.Errorf("rabbitmq connection is closed")
+	}
+	if b.rabbitMq.Channel.IsClosed() {
+		return fmt.Errorf("rabbitmq channel is closed")
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package broker
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	nats_lib "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAcknowledger records the acks of RabbitMQ deliveries
+type fakeAcknowledger struct {
+	mutex  sync.Mutex
+	acked  []uint64
+	nacked chan uint64
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, _ bool) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.acked = append(a.acked, tag)
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, _ bool, requeue bool) error {
+	if requeue {
+		a.nacked <- tag
+	}
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(uint64, bool) error {
+	return nil
+}
+
+// fakeNatsMsg is a JetStream message recording its acks
+type fakeNatsMsg struct {
+	jetstream.Msg
+	headers map[string][]string
+	acked   bool
+	nakedIn time.Duration
+}
+
+func (m *fakeNatsMsg) Data() []byte {
+	return []byte("payload")
+}
+
+func (m *fakeNatsMsg) Headers() nats_lib.Header {
+	return m.headers
+}
+
+func (m *fakeNatsMsg) Subject() string {
+	return "orders"
+}
+
+func (m *fakeNatsMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{Sequence: jetstream.SequencePair{Consumer: 3, Stream: 42}}, nil
+}
+
+func (m *fakeNatsMsg) Ack() error {
+	m.acked = true
+	return nil
+}
+
+func (m *fakeNatsMsg) NakWithDelay(delay time.Duration) error {
+	m.nakedIn = delay
+	return nil
+}
+
+// TestRabbitMqMessage tests that deliveries are mapped with their headers and ordering key
+func TestRabbitMqMessage(t *testing.T) {
+	delivery := amqp.Delivery{
+		ContentType: "application/x-protobuf",
+		Headers:     amqp.Table{"Key": "order-1", "attempt": int32(2)},
+		DeliveryTag: 42,
+		Body:        []byte("payload"),
+	}
+
+	message := rabbitMqMessage("orders", delivery, time.Second)
+
+	assert.Equal(t, "orders", message.Topic)
+	assert.Equal(t, int64(42), message.Offset)
+	assert.Equal(t, []byte("payload"), message.Value)
+	assert.Equal(t, []byte("order-1"), message.Key)
+	assert.Equal(t, "application/x-protobuf", message.Headers["content-type"])
+	assert.Equal(t, "2", message.Headers["attempt"])
+	assert.True(t, message.IndividualAck)
+}
+
+// TestRabbitMqAck tests that an ack is passed to the delivery at once
+func TestRabbitMqAck(t *testing.T) {
+	acknowledger := &fakeAcknowledger{nacked: make(chan uint64, 1)}
+	message := rabbitMqMessage("orders", amqp.Delivery{Acknowledger: acknowledger, DeliveryTag: 7}, time.Second)
+
+	require.NoError(t, message.Ack())
+
+	assert.Equal(t, []uint64{7}, acknowledger.acked)
+}
+
+// TestRabbitMqNackDelay tests that a nacked delivery is requeued only after the redelivery delay
+func TestRabbitMqNackDelay(t *testing.T) {
+	acknowledger := &fakeAcknowledger{nacked: make(chan uint64, 1)}
+	delay := 50 * time.Millisecond
+	message := rabbitMqMessage("orders", amqp.Delivery{Acknowledger: acknowledger, DeliveryTag: 7}, delay)
+
+	start := time.Now()
+	require.NoError(t, message.Nack())
+
+	select {
+	case tag := <-acknowledger.nacked:
+		assert.Equal(t, uint64(7), tag)
+		assert.GreaterOrEqual(t, time.Since(start), delay)
+	case <-time.After(time.Second):
+		t.Fatal("delivery was not requeued")
+	}
+}
+
+// TestNatsMessage tests that JetStream messages are mapped with their ordering key and stream sequence
+func TestNatsMessage(t *testing.T) {
+	msg := &fakeNatsMsg{headers: map[string][]string{"Key": {"order-1"}, "Content-Type": {"application/json"}}}
+
+	message := natsMessage(msg)
+
+	assert.Equal(t, "orders", message.Topic)
+	assert.Equal(t, int64(42), message.Offset)
+	assert.Equal(t, []byte("payload"), message.Value)
+	assert.Equal(t, []byte("order-1"), message.Key)
+	assert.Equal(t, "application/json", message.Headers["content-type"])
+	assert.True(t, message.IndividualAck)
+}
+
+// TestNatsAckNack tests that an ack is passed through and a nack asks for delayed redelivery
+func TestNatsAckNack(t *testing.T) {
+	msg := &fakeNatsMsg{}
+	message := natsMessage(msg)
+
+	require.NoError(t, message.Ack())
+	require.NoError(t, message.Nack())
+
+	assert.True(t, msg.acked)
+	assert.Equal(t, natsRedeliveryDelay, msg.nakedIn)
+}
+
+// TestNatsHeaders tests that the last value of each header is kept under its lowercased name
+func TestNatsHeaders(t *testing.T) {
+	headers := natsHeaders(map[string][]string{
+		"Content-Type": {"application/json"},
+		"Key":          {"old", "order-1"},
+	})
+
+	assert.Equal(t, map[string]string{"content-type": "application/json", "key": "order-1"}, headers)
+	assert.Nil(t, natsHeaders(nil))
+}
@@ -16,3 +16,16 @@ func (e ErrCacheMiss) Error() string {
 func IsErrCacheMiss(err error) bool {
 	return errors.As(err, new(ErrCacheMiss))
 }
+
+// ErrInvalidLimit reports a negative limit
+type ErrInvalidLimit struct {
+	Limit int
+}
+
+func (e ErrInvalidLimit) Error() string {
+	return fmt.Sprintf("invalid limit %d", e.Limit)
+}
+
+func IsErrInvalidLimit(err error) bool {
+	return errors.As(err, new(ErrInvalidLimit))
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"wb-L0/structs"
+)
+
+// cacheFills tracks the database reads filling a cache in this process
+var cacheFills = &fillTracker{fills: make(map[*Fill]struct{})}
+
+// fillTracker orders cache fills after the invalidations of this process, so
+// an order read before it changed is not put back once it was deleted
+type fillTracker struct {
+	mutex sync.Mutex
+	fills map[*Fill]struct{}
+}
+
+// Fill is a database read whose orders fill the cache. It records the keys
+// invalidated since the read started and does not put them. A put and an
+// invalidation exclude each other, so the put either lands before the
+// deletion that follows the invalidation or does not happen.
+type Fill struct {
+	cache       Cache
+	mutex       sync.Mutex
+	invalidated map[string]struct{}
+	flushed     bool
+}
+
+// StartFill registers a read that fills orderCache; start it before the read
+// and call Done once its orders are put
+func StartFill(orderCache Cache) *Fill {
+	fill := &Fill{cache: orderCache, invalidated: make(map[string]struct{})}
+	cacheFills.mutex.Lock()
+	defer cacheFills.mutex.Unlock()
+	cacheFills.fills[fill] = struct{}{}
+	return fill
+}
+
+// Put caches the order read unless its key was invalidated since the read
+// started
+func (f *Fill) Put(ctx context.Context, key string, order *structs.Order) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, ok := f.invalidated[key]; ok || f.flushed {
+		return nil
+	}
+	return f.cache.PutOrder(ctx, key, order)
+}
+
+// Done stops tracking invalidations for the read
+func (f *Fill) Done() {
+	cacheFills.mutex.Lock()
+	defer cacheFills.mutex.Unlock()
+	delete(cacheFills.fills, f)
+}
+
+// Invalidate keeps reads in flight from caching the keys. Call it after the
+// orders changed and before deleting them from the cache; it waits for puts
+// in progress.
+func Invalidate(keys ...string) {
+	for _, fill := range activeFills() {
+		fill.mutex.Lock()
+		for _, key := range keys {
+			fill.invalidated[key] = struct{}{}
+		}
+		fill.mutex.Unlock()
+	}
+}
+
+// InvalidateAll keeps reads in flight from caching anything; call it before
+// flushing
+func InvalidateAll() {
+	for _, fill := range activeFills() {
+		fill.mutex.Lock()
+		fill.flushed = true
+		fill.mutex.Unlock()
+	}
+}
+
+func activeFills() []*Fill {
+	cacheFills.mutex.Lock()
+	defer cacheFills.mutex.Unlock()
+	fills := make([]*Fill, 0, len(cacheFills.fills))
+	for fill := range cacheFills.fills {
+		fills = append(fills, fill)
+	}
+	return fills
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/structs"
+)
+
+func TestFillPutsOrders(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+
+	fill := StartFill(c)
+	require.NoError(t, fill.Put(ctx, "a", &structs.Order{OrderUid: "a"}))
+	fill.Done()
+
+	_, err := c.GetOrder(ctx, "a")
+	assert.NoError(t, err)
+	assert.Empty(t, cacheFills.fills)
+}
+
+func TestFillSkipsInvalidatedOrders(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+
+	fill := StartFill(c)
+	Invalidate("a")
+	require.NoError(t, fill.Put(ctx, "a", &structs.Order{OrderUid: "a"}))
+	require.NoError(t, fill.Put(ctx, "b", &structs.Order{OrderUid: "b"}))
+	flushed := StartFill(c)
+	InvalidateAll()
+	require.NoError(t, flushed.Put(ctx, "c", &structs.Order{OrderUid: "c"}))
+	fill.Done()
+	flushed.Done()
+
+	_, err := c.GetOrder(ctx, "a")
+	assert.True(t, IsErrCacheMiss(err))
+	_, err = c.GetOrder(ctx, "b")
+	assert.NoError(t, err)
+	_, err = c.GetOrder(ctx, "c")
+	assert.True(t, IsErrCacheMiss(err))
+
+	// A read started after the invalidation fills the cache again
+	fill = StartFill(c)
+	require.NoError(t, fill.Put(ctx, "a", &structs.Order{OrderUid: "a"}))
+	fill.Done()
+	_, err = c.GetOrder(ctx, "a")
+	assert.NoError(t, err)
+}
@@ -10,7 +10,19 @@ var cacheInstance Cache
 
 type Cache interface {
 	GetOrder(context.Context, string) (*structs.Order, error)
+	// GetOrders returns the cached orders among keys; absent keys are omitted
+	GetOrders(context.Context, []string) (map[string]*structs.Order, error)
 	PutOrder(context.Context, string, *structs.Order) error
+	// DeleteOrder evicts the key; deleting an absent key is not an error
+	DeleteOrder(context.Context, string) error
+	// DeleteOrders evicts the keys and returns how many of them were cached
+	DeleteOrders(context.Context, []string) (int, error)
+	// Flush evicts every key
+	Flush(context.Context) error
+	Stats(context.Context) (*structs.CacheStats, error)
+	// Keys returns up to limit cached keys matching the glob pattern, any key
+	// for an empty pattern; a negative limit is ErrInvalidLimit
+	Keys(ctx context.Context, pattern string, limit int) ([]string, error)
 	HealthCheck(context.Context) error
 }
 
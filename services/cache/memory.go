@@ -3,71 +3,361 @@ package cache
 import (
 	"container/list"
 	"context"
+	"path"
 	"sync"
+	"time"
+	"unsafe"
 
+	"wb-L0/modules/config"
+	"wb-L0/modules/monitoring"
 	"wb-L0/structs"
 )
 
+const (
+	defaultCapacity = 10000
+	// maxSweepInterval bounds how long expired entries keep their memory
+	maxSweepInterval = time.Minute
+	minSweepInterval = time.Second
+)
+
+// Eviction reasons of the cache_evictions_total metric
+const (
+	evictedCapacity = "capacity"
+	evictedMemory   = "memory"
+	evictedExpired  = "expired"
+)
+
 type entry struct {
-	key   string
-	value *structs.Order
+	key       string
+	value     *structs.Order
+	size      int64
+	expiresAt time.Time
+	// expiryElem is the element of the entry in MemoryCache.expiry
+	expiryElem *list.Element
 }
 
+// MemoryCache is an LRU cache bounded by an entry count and optionally by the
+// approximate memory of its orders. With a TTL, entries expire that long
+// after they were put; expired entries are misses and a background sweep
+// releases them.
 type MemoryCache struct {
 	capacity int
+	// maxBytes is the memory budget, zero for none
+	maxBytes int64
+	// ttl is zero when entries do not expire
+	ttl      time.Duration
 	cacheMap map[string]*list.Element
-	list     *list.List
-	mutex    sync.Mutex
+	// list orders entries from the most to the least recently used
+	list *list.List
+	// expiry orders entries by expiry time, which with a single TTL is the
+	// order they were put in
+	expiry *list.List
+	bytes  int64
+	mutex  sync.Mutex
+	done   chan struct{}
+	stop   chan struct{}
+	// stopOnce lets Shutdown run more than once
+	stopOnce sync.Once
 }
 
-// NewMemoryCache creates a new LRU cache with specified capacity
+// NewMemoryCache creates an LRU cache sized by CACHE_CAPACITY, CACHE_MAX_MB
+// and CACHE_TTL_SECONDS
 func NewMemoryCache() *MemoryCache {
-	return &MemoryCache{
+	c := &MemoryCache{
 		mutex:    sync.Mutex{},
-		capacity: 10,
+		capacity: defaultCapacity,
 		cacheMap: make(map[string]*list.Element),
 		list:     list.New(),
+		expiry:   list.New(),
+	}
+	if conf := config.GetConfig(); conf != nil {
+		if conf.CacheCapacity > 0 {
+			c.capacity = conf.CacheCapacity
+		}
+		if conf.CacheMaxMb > 0 {
+			c.maxBytes = int64(conf.CacheMaxMb) << 20
+		}
+		if conf.CacheTtlSeconds > 0 {
+			c.ttl = time.Duration(conf.CacheTtlSeconds) * time.Second
+		}
+	}
+	return c
+}
+
+// Init starts sweeping expired entries when entries expire
+func (c *MemoryCache) Init(_ chan error) error {
+	c.done = make(chan struct{})
+	c.stop = make(chan struct{})
+	c.stopOnce = sync.Once{}
+	if c.ttl == 0 {
+		close(c.done)
+		return nil
+	}
+	go c.sweepPeriodically()
+	return nil
+}
+
+func (c *MemoryCache) SuccessfulMessage() string {
+	return "Memory cache successfully initialized"
+}
+
+// Shutdown stops the sweep; a cache that was never initialized has none
+func (c *MemoryCache) Shutdown(ctx context.Context) error {
+	if c.stop == nil {
+		return nil
+	}
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
+func (c *MemoryCache) sweepPeriodically() {
+	defer close(c.done)
+	interval := min(max(c.ttl/2, minSweepInterval), maxSweepInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.sweep(time.Now())
+		}
+	}
+}
+
+// sweep removes the entries expired at now
+func (c *MemoryCache) sweep(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for front := c.expiry.Front(); front != nil; front = c.expiry.Front() {
+		elem := front.Value.(*list.Element)
+		if !c.expired(elem.Value.(*entry), now) {
+			break
+		}
+		c.evict(elem, evictedExpired)
+	}
+	c.reportSize()
+}
+
 func (c *MemoryCache) PutOrder(_ context.Context, key string, order *structs.Order) error {
 	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	size := orderSize(key, order)
 	if elem, exists := c.cacheMap[key]; exists {
-		elem.Value.(*entry).value = order
-		c.list.MoveToFront(elem)
+		c.remove(elem)
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		// An order larger than the whole budget is not cached
+		c.reportSize()
 		return nil
 	}
 
-	newEntry := &entry{key: key, value: order}
+	newEntry := &entry{key: key, value: order, size: size}
+	if c.ttl > 0 {
+		newEntry.expiresAt = time.Now().Add(c.ttl)
+	}
 	newElem := c.list.PushFront(newEntry)
+	newEntry.expiryElem = c.expiry.PushBack(newElem)
 	c.cacheMap[key] = newElem
+	c.bytes += size
 
-	if c.list.Len() > c.capacity {
-		tail := c.list.Back()
-		if tail != nil {
-			keyToRemove := tail.Value.(*entry).key
-			delete(c.cacheMap, keyToRemove)
-			c.list.Remove(tail)
-		}
+	for c.list.Len() > c.capacity {
+		c.evict(c.list.Back(), evictedCapacity)
+	}
+	for c.maxBytes > 0 && c.bytes > c.maxBytes {
+		c.evict(c.list.Back(), evictedMemory)
 	}
-	c.mutex.Unlock()
+	c.reportSize()
 	return nil
 }
 
 func (c *MemoryCache) GetOrder(_ context.Context, key string) (*structs.Order, error) {
 	c.mutex.Lock()
-	elem, exists := c.cacheMap[key]
+	defer c.mutex.Unlock()
+	elem, exists := c.lookup(key, time.Now())
 	if !exists {
 		return nil, ErrCacheMiss{Key: key}
 	}
 
 	c.list.MoveToFront(elem)
-	c.mutex.Unlock()
 	return elem.Value.(*entry).value, nil
 }
 
+func (c *MemoryCache) GetOrders(_ context.Context, keys []string) (map[string]*structs.Order, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	result := make(map[string]*structs.Order, len(keys))
+	for _, key := range keys {
+		elem, exists := c.lookup(key, now)
+		if !exists {
+			continue
+		}
+		c.list.MoveToFront(elem)
+		result[key] = elem.Value.(*entry).value
+	}
+	return result, nil
+}
+
+func (c *MemoryCache) DeleteOrder(_ context.Context, key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, exists := c.cacheMap[key]
+	if !exists {
+		return nil
+	}
+	c.remove(elem)
+	c.reportSize()
+	return nil
+}
+
+func (c *MemoryCache) DeleteOrders(_ context.Context, keys []string) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	deleted := 0
+	for _, key := range keys {
+		elem, exists := c.cacheMap[key]
+		if !exists {
+			continue
+		}
+		c.remove(elem)
+		deleted++
+	}
+	c.reportSize()
+	return deleted, nil
+}
+
+func (c *MemoryCache) Flush(_ context.Context) error {
+	c.purge()
+	return nil
+}
+
+func (c *MemoryCache) Stats(_ context.Context) (*structs.CacheStats, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return &structs.CacheStats{
+		Type:       "memory",
+		Entries:    int64(c.list.Len()),
+		SizeBytes:  c.bytes,
+		Capacity:   c.capacity,
+		MaxBytes:   c.maxBytes,
+		TtlSeconds: int64(c.ttl / time.Second),
+	}, nil
+}
+
+// Keys lists keys from the most to the least recently used, skipping expired
+// ones; patterns follow path.Match
+func (c *MemoryCache) Keys(_ context.Context, pattern string, limit int) ([]string, error) {
+	if limit < 0 {
+		return nil, ErrInvalidLimit{Limit: limit}
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	keys := make([]string, 0, min(limit, c.list.Len()))
+	for elem := c.list.Front(); elem != nil && len(keys) < limit; elem = elem.Next() {
+		e := elem.Value.(*entry)
+		if c.expired(e, now) {
+			continue
+		}
+		if matched, _ := path.Match(pattern, e.key); matched {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys, nil
+}
+
 // HealthCheck performs a health check on memory cache
 func (c *MemoryCache) HealthCheck(_ context.Context) error {
 	// Memory cache is always healthy if it's initialized
 	return nil
 }
+
+// purge removes every entry
+func (c *MemoryCache) purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.cacheMap = make(map[string]*list.Element)
+	c.list.Init()
+	c.expiry.Init()
+	c.bytes = 0
+	c.reportSize()
+}
+
+// lookup returns the element of key unless it is absent or expired; an
+// expired element is evicted
+func (c *MemoryCache) lookup(key string, now time.Time) (*list.Element, bool) {
+	elem, exists := c.cacheMap[key]
+	if !exists {
+		return nil, false
+	}
+	if c.expired(elem.Value.(*entry), now) {
+		c.evict(elem, evictedExpired)
+		c.reportSize()
+		return nil, false
+	}
+	return elem, true
+}
+
+func (c *MemoryCache) expired(e *entry, now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+func (c *MemoryCache) evict(elem *list.Element, reason string) {
+	c.remove(elem)
+	monitoring.IncrementCacheEvictions(reason)
+}
+
+func (c *MemoryCache) remove(elem *list.Element) {
+	e := elem.Value.(*entry)
+	delete(c.cacheMap, e.key)
+	c.list.Remove(elem)
+	c.expiry.Remove(e.expiryElem)
+	c.bytes -= e.size
+}
+
+func (c *MemoryCache) reportSize() {
+	monitoring.SetCacheSize(c.list.Len(), c.bytes)
+}
+
+// orderSize approximates the memory an entry holds: the structs, their
+// strings and the bookkeeping of the entry
+func orderSize(key string, order *structs.Order) int64 {
+	size := int64(unsafe.Sizeof(entry{})+unsafe.Sizeof(structs.Order{})) + 2*listElementSize
+	size += int64(len(key)) + stringsSize(order.OrderUid, order.TrackNumber, order.Entry,
+		order.Locale, order.InternalSignature, order.CustomerId, order.DeliveryService,
+		order.Shardkey, order.DateCreated, order.OofShard, order.Status)
+	size += stringsSize(order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
+		order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email)
+	size += stringsSize(order.Payment.Transaction, order.Payment.RequestId, order.Payment.Currency,
+		order.Payment.Provider, order.Payment.Bank)
+	size += int64(cap(order.Items)) * int64(unsafe.Sizeof(structs.Item{}))
+	for _, item := range order.Items {
+		size += stringsSize(item.TrackNumber, item.Rid, item.Name, item.Size, item.Brand)
+	}
+	return size
+}
+
+// listElementSize is the size of a container/list element
+const listElementSize = int64(unsafe.Sizeof(list.Element{}))
+
+func stringsSize(values ...string) int64 {
+	var size int64
+	for _, value := range values {
+		size += int64(len(value))
+	}
+	return size
+}
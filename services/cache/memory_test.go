@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/structs"
+)
+
+func TestMemoryCacheGetOrders(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+	require.NoError(t, c.PutOrder(ctx, "a", &structs.Order{OrderUid: "a"}))
+	require.NoError(t, c.PutOrder(ctx, "b", &structs.Order{OrderUid: "b"}))
+
+	found, err := c.GetOrders(ctx, []string{"a", "missing", "b"})
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, "a", found["a"].OrderUid)
+
+	// Repeated misses must not leave the cache locked
+	_, err = c.GetOrder(ctx, "missing")
+	assert.True(t, IsErrCacheMiss(err))
+	_, err = c.GetOrder(ctx, "missing")
+	assert.True(t, IsErrCacheMiss(err))
+}
+
+func TestMemoryCacheDeleteOrder(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+	require.NoError(t, c.PutOrder(ctx, "a", &structs.Order{OrderUid: "a"}))
+
+	require.NoError(t, c.DeleteOrder(ctx, "a"))
+	_, err := c.GetOrder(ctx, "a")
+	assert.True(t, IsErrCacheMiss(err))
+	assert.NoError(t, c.DeleteOrder(ctx, "a"))
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewMemoryCache()
+	c.capacity = 2
+	ctx := context.Background()
+	require.NoError(t, c.PutOrder(ctx, "a", &structs.Order{OrderUid: "a"}))
+	require.NoError(t, c.PutOrder(ctx, "b", &structs.Order{OrderUid: "b"}))
+	_, err := c.GetOrder(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, c.PutOrder(ctx, "c", &structs.Order{OrderUid: "c"}))
+
+	found, err := c.GetOrders(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Contains(t, found, "a")
+	assert.NotContains(t, found, "b")
+	assert.Contains(t, found, "c")
+}
+
+func TestMemoryCacheMemoryBudget(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+	small := &structs.Order{OrderUid: "small"}
+	large := &structs.Order{OrderUid: "large", Items: make([]structs.Item, 50)}
+	c.maxBytes = 2*orderSize("a", small) + orderSize("a", small)/2
+
+	require.NoError(t, c.PutOrder(ctx, "a", small))
+	require.NoError(t, c.PutOrder(ctx, "b", small))
+	assert.Equal(t, 2*orderSize("a", small), c.bytes)
+	require.NoError(t, c.PutOrder(ctx, "c", small))
+	_, err := c.GetOrder(ctx, "a")
+	assert.True(t, IsErrCacheMiss(err))
+	assert.LessOrEqual(t, c.bytes, c.maxBytes)
+
+	// An order over the whole budget replaces nothing
+	require.NoError(t, c.PutOrder(ctx, "b", large))
+	_, err = c.GetOrder(ctx, "b")
+	assert.True(t, IsErrCacheMiss(err))
+	_, err = c.GetOrder(ctx, "c")
+	assert.NoError(t, err)
+	assert.Equal(t, orderSize("c", small), c.bytes)
+
+	require.NoError(t, c.DeleteOrder(ctx, "c"))
+	assert.Zero(t, c.bytes)
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	c := NewMemoryCache()
+	c.ttl = time.Minute
+	ctx := context.Background()
+	require.NoError(t, c.PutOrder(ctx, "a", &structs.Order{OrderUid: "a"}))
+	require.NoError(t, c.PutOrder(ctx, "b", &structs.Order{OrderUid: "b"}))
+	c.list.Front().Value.(*entry).expiresAt = time.Now().Add(-time.Second)
+
+	_, err := c.GetOrder(ctx, "b")
+	assert.True(t, IsErrCacheMiss(err))
+	_, err = c.GetOrder(ctx, "a")
+	assert.NoError(t, err)
+
+	// Putting a key again restarts its TTL
+	require.NoError(t, c.PutOrder(ctx, "b", &structs.Order{OrderUid: "b"}))
+	c.sweep(time.Now())
+	assert.Equal(t, 2, c.list.Len())
+	c.cacheMap["a"].Value.(*entry).expiresAt = time.Now()
+	c.sweep(time.Now().Add(time.Second))
+	assert.Equal(t, 1, c.list.Len())
+	assert.Contains(t, c.cacheMap, "b")
+	c.sweep(time.Now().Add(2 * time.Minute))
+	assert.Zero(t, c.list.Len())
+	assert.Zero(t, c.expiry.Len())
+	assert.Zero(t, c.bytes)
+}
+
+func TestMemoryCacheSweeper(t *testing.T) {
+	c := NewMemoryCache()
+	c.ttl = time.Millisecond
+	require.NoError(t, c.Init(nil))
+	require.NoError(t, c.PutOrder(context.Background(), "a", &structs.Order{OrderUid: "a"}))
+
+	assert.Eventually(t, func() bool {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		return c.list.Len() == 0
+	}, 3*time.Second, 10*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, c.Shutdown(ctx))
+}
+
+func TestMemoryCacheAdministration(t *testing.T) {
+	c := NewMemoryCache()
+	c.ttl = time.Hour
+	ctx := context.Background()
+	for _, key := range []string{"a1", "a2", "b1", "expired"} {
+		require.NoError(t, c.PutOrder(ctx, key, &structs.Order{OrderUid: key}))
+	}
+	c.cacheMap["expired"].Value.(*entry).expiresAt = time.Now()
+
+	keys, err := c.Keys(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b1", "a2", "a1"}, keys)
+	keys, err = c.Keys(ctx, "a*", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a2"}, keys)
+	_, err = c.Keys(ctx, "[", 10)
+	assert.Error(t, err)
+	_, err = c.Keys(ctx, "", -1)
+	assert.True(t, IsErrInvalidLimit(err))
+	keys, err = c.Keys(ctx, "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	stats, err := c.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "memory", stats.Type)
+	assert.Equal(t, int64(4), stats.Entries)
+	assert.Equal(t, c.bytes, stats.SizeBytes)
+	assert.Equal(t, int64(3600), stats.TtlSeconds)
+
+	deleted, err := c.DeleteOrders(ctx, []string{"a1", "a2", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	_, err = c.GetOrder(ctx, "a1")
+	assert.True(t, IsErrCacheMiss(err))
+
+	require.NoError(t, c.Flush(ctx))
+	stats, err = c.Stats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Entries)
+	assert.Zero(t, stats.SizeBytes)
+}
+
+func TestUsedMemory(t *testing.T) {
+	info := "# Memory\r\nused_memory:1048576\r\nused_memory_human:1.00M\r\n"
+	assert.Equal(t, int64(1048576), usedMemory(info))
+	assert.Zero(t, usedMemory("# Memory\r\n"))
+}
+
+// TestMemoryCacheShutdown tests that Shutdown is safe before Init and when repeated
+func TestMemoryCacheShutdown(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	assert.NoError(t, c.Shutdown(ctx))
+
+	c.ttl = time.Hour
+	require.NoError(t, c.Init(nil))
+	assert.NoError(t, c.Shutdown(ctx))
+	assert.NoError(t, c.Shutdown(ctx))
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"wb-L0/modules/redis"
@@ -13,6 +15,28 @@ import (
 	redis_lib "github.com/go-redis/redis/v8"
 )
 
+// orderTtl is how long Redis keeps an order
+const orderTtl = 24 * time.Hour
+
+// keysScanCount is the number of keys a SCAN call inspects
+const keysScanCount = 1000
+
+// keyPrefix namespaces the cached orders so the database can hold other keys;
+// flushes, listings and stats only touch keys under it
+const keyPrefix = "order:"
+
+func redisKey(key string) string {
+	return keyPrefix + key
+}
+
+func redisKeys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = redisKey(key)
+	}
+	return prefixed
+}
+
 type RedisCache struct {
 	redisConn *redis.Redis
 }
@@ -28,11 +52,11 @@ func (c *RedisCache) PutOrder(ctx context.Context, key string, order *structs.Or
 	if err != nil {
 		return fmt.Errorf("marshaling error: %w", err)
 	}
-	return c.redisConn.Client.Set(ctx, key, jsonData, 24*time.Hour).Err()
+	return c.redisConn.Client.Set(ctx, redisKey(key), jsonData, orderTtl).Err()
 }
 
 func (c *RedisCache) GetOrder(ctx context.Context, key string) (*structs.Order, error) {
-	val, err := c.redisConn.Client.Get(ctx, key).Bytes()
+	val, err := c.redisConn.Client.Get(ctx, redisKey(key)).Bytes()
 	if err != nil {
 		if errors.Is(err, redis_lib.Nil) {
 			return nil, ErrCacheMiss{
@@ -48,6 +72,133 @@ func (c *RedisCache) GetOrder(ctx context.Context, key string) (*structs.Order,
 	return &order, nil
 }
 
+func (c *RedisCache) GetOrders(ctx context.Context, keys []string) (map[string]*structs.Order, error) {
+	result := make(map[string]*structs.Order, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	values, err := c.redisConn.Client.MGet(ctx, redisKeys(keys)...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var order structs.Order
+		if err := json.Unmarshal([]byte(raw), &order); err != nil {
+			return nil, fmt.Errorf("unmarshaling error: %w", err)
+		}
+		result[keys[i]] = &order
+	}
+	return result, nil
+}
+
+func (c *RedisCache) DeleteOrder(ctx context.Context, key string) error {
+	return c.redisConn.Client.Del(ctx, redisKey(key)).Err()
+}
+
+func (c *RedisCache) DeleteOrders(ctx context.Context, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	deleted, err := c.redisConn.Client.Del(ctx, redisKeys(keys)...).Result()
+	return int(deleted), err
+}
+
+// Flush unlinks the cached orders batch by batch and leaves other keys of the
+// database alone. Orders cached while the flush scans may survive it.
+func (c *RedisCache) Flush(ctx context.Context) error {
+	return c.scan(ctx, keyPrefix+"*", func(keys []string) (bool, error) {
+		if len(keys) == 0 {
+			return true, nil
+		}
+		return true, c.redisConn.Client.Unlink(ctx, keys...).Err()
+	})
+}
+
+// Stats counts the cached orders with a scan; the size is the memory of the
+// whole Redis instance
+func (c *RedisCache) Stats(ctx context.Context) (*structs.CacheStats, error) {
+	var entries int64
+	err := c.scan(ctx, keyPrefix+"*", func(keys []string) (bool, error) {
+		entries += int64(len(keys))
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	info, err := c.redisConn.Client.Info(ctx, "memory").Result()
+	if err != nil {
+		return nil, err
+	}
+	return &structs.CacheStats{
+		Type:       "redis",
+		Entries:    entries,
+		SizeBytes:  usedMemory(info),
+		TtlSeconds: int64(orderTtl / time.Second),
+	}, nil
+}
+
+// Keys scans the cached orders until limit keys match; patterns follow the
+// Redis glob syntax and match the key without its prefix
+func (c *RedisCache) Keys(ctx context.Context, pattern string, limit int) ([]string, error) {
+	if limit < 0 {
+		return nil, ErrInvalidLimit{Limit: limit}
+	}
+	if limit == 0 {
+		return []string{}, nil
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+	keys := make([]string, 0)
+	err := c.scan(ctx, keyPrefix+pattern, func(batch []string) (bool, error) {
+		for _, key := range batch {
+			keys = append(keys, strings.TrimPrefix(key, keyPrefix))
+		}
+		return len(keys) < limit, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys[:min(len(keys), limit)], nil
+}
+
+// scan hands the keys matching the pattern to handle batch by batch until
+// the scan ends or handle returns false
+func (c *RedisCache) scan(ctx context.Context, pattern string, handle func([]string) (bool, error)) error {
+	var cursor uint64
+	for {
+		batch, next, err := c.redisConn.Client.Scan(ctx, cursor, pattern, keysScanCount).Result()
+		if err != nil {
+			return err
+		}
+		more, err := handle(batch)
+		if err != nil || !more || next == 0 {
+			return err
+		}
+		cursor = next
+	}
+}
+
+// usedMemory reads used_memory from the memory section of INFO
+func usedMemory(info string) int64 {
+	for _, line := range strings.Split(info, "\r\n") {
+		value, ok := strings.CutPrefix(line, "used_memory:")
+		if !ok {
+			continue
+		}
+		used, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0
+		}
+		return used
+	}
+	return 0
+}
+
 // HealthCheck performs a health check on Redis
 func (c *RedisCache) HealthCheck(ctx context.Context) error {
 	return c.redisConn.Client.Ping(ctx).Err()
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	redis_lib "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/modules/redis"
+	"wb-L0/structs"
+)
+
+// testRedisAddr points at the Redis of docker-compose.test.yml
+const testRedisAddr = "localhost:6380"
+
+// newTestRedis connects to an empty database of the test Redis, skipping the
+// test when it is not running
+func newTestRedis(t *testing.T) *redis.Redis {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		addr = testRedisAddr
+	}
+	client := redis_lib.NewClient(&redis_lib.Options{Addr: addr, Password: "test_pass", DB: 15})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		t.Skipf("Redis not available, skipping: %v", err)
+	}
+	require.NoError(t, client.FlushDB(context.Background()).Err())
+	t.Cleanup(func() {
+		client.FlushDB(context.Background())
+		client.Close()
+	})
+	return &redis.Redis{Client: client}
+}
+
+// TestRedisCacheKeysLimit tests that limits are checked before Redis is scanned
+func TestRedisCacheKeysLimit(t *testing.T) {
+	c := NewRedisCache(nil)
+
+	_, err := c.Keys(context.Background(), "", -1)
+	assert.True(t, IsErrInvalidLimit(err))
+	keys, err := c.Keys(context.Background(), "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+// TestRedisCacheKeepsForeignKeys tests that listing, stats and flushes only touch cached orders
+func TestRedisCacheKeepsForeignKeys(t *testing.T) {
+	instance := newTestRedis(t)
+	c := NewRedisCache(instance)
+	ctx := context.Background()
+	require.NoError(t, instance.Client.Set(ctx, "session:1", "foreign", 0).Err())
+	for _, key := range []string{"a1", "a2", "b1"} {
+		require.NoError(t, c.PutOrder(ctx, key, &structs.Order{OrderUid: key}))
+	}
+
+	keys, err := c.Keys(ctx, "a*", 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a1", "a2"}, keys)
+	keys, err = c.Keys(ctx, "", 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a1", "a2", "b1"}, keys)
+	stats, err := c.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Entries)
+
+	require.NoError(t, c.Flush(ctx))
+	_, err = c.GetOrder(ctx, "a1")
+	assert.True(t, IsErrCacheMiss(err))
+	stats, err = c.Stats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Entries)
+	foreign, err := instance.Client.Get(ctx, "session:1").Result()
+	require.NoError(t, err)
+	assert.Equal(t, "foreign", foreign)
+}
@@ -0,0 +1,316 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	redis_lib "github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"wb-L0/modules/config"
+	"wb-L0/modules/redis"
+	"wb-L0/structs"
+)
+
+// invalidationChannel is the pub/sub channel deleted keys are announced on.
+// Pub/sub ignores the database number, so it is part of the name.
+const invalidationChannel = "wb-l0:cache-invalidation:%d"
+
+// maxLocalTtl bounds how long a local copy lives, whatever CACHE_TTL_SECONDS
+// says, as the announcement that should have dropped it may have been lost
+const maxLocalTtl = 30 * time.Second
+
+// TieredCache keeps a local LRU in front of Redis. Reads try the local tier
+// first and copy Redis hits into it, unless the key was invalidated while
+// Redis was read. Puts fill both tiers; changed orders are deleted, and a
+// deletion or a flush is announced over Redis pub/sub so every replica drops
+// its local copies. Announcements are not delivered while the subscription
+// reconnects, so the local tier is emptied whenever it resubscribes, and
+// local copies expire after at most maxLocalTtl.
+type TieredCache struct {
+	local     *MemoryCache
+	remote    Cache
+	redisConn *redis.Redis
+	channel   string
+	// origin tells announcements of this replica from the others
+	origin string
+	// publish announces a deleted key, or a flush without one
+	publish func(ctx context.Context, payload string) error
+	pubsub  *redis_lib.PubSub
+	done    chan struct{}
+
+	// fillMutex orders local fills after Redis reads with invalidations
+	fillMutex sync.Mutex
+	// fills holds the keys being read from Redis to fill the local tier
+	fills map[string]*localFill
+}
+
+// localFill tracks the Redis reads of a key that will fill the local tier
+type localFill struct {
+	readers int
+	// stale is set when the key is invalidated during the reads
+	stale bool
+}
+
+func NewTieredCache(redisInstance *redis.Redis) *TieredCache {
+	local := NewMemoryCache()
+	if local.ttl == 0 || local.ttl > maxLocalTtl {
+		local.ttl = maxLocalTtl
+	}
+	c := &TieredCache{
+		local:     local,
+		remote:    NewRedisCache(redisInstance),
+		redisConn: redisInstance,
+		channel:   fmt.Sprintf(invalidationChannel, 0),
+		origin:    uuid.NewString(),
+	}
+	if conf := config.GetConfig(); conf != nil {
+		c.channel = fmt.Sprintf(invalidationChannel, conf.RedisDatabase)
+	}
+	c.publish = func(ctx context.Context, payload string) error {
+		return redisInstance.Client.Publish(ctx, c.channel, payload).Err()
+	}
+	return c
+}
+
+// Init subscribes to invalidations and starts the local tier; the Redis unit
+// must be initialized first
+func (c *TieredCache) Init(errChan chan error) error {
+	err := c.local.Init(errChan)
+	if err != nil {
+		return err
+	}
+	c.pubsub = c.redisConn.Client.Subscribe(context.Background(), c.channel)
+	c.done = make(chan struct{})
+	go c.listen(c.pubsub.ChannelWithSubscriptions(context.Background(), 100))
+	return nil
+}
+
+func (c *TieredCache) SuccessfulMessage() string {
+	return "Tiered cache successfully initialized"
+}
+
+func (c *TieredCache) Shutdown(ctx context.Context) error {
+	if c.pubsub == nil {
+		return c.local.Shutdown(ctx)
+	}
+	err := c.pubsub.Close()
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if localErr := c.local.Shutdown(ctx); err == nil {
+		err = localErr
+	}
+	return err
+}
+
+func (c *TieredCache) listen(messages <-chan interface{}) {
+	defer close(c.done)
+	subscribed := false
+	for message := range messages {
+		subscribed = c.handle(message, subscribed)
+	}
+}
+
+// handle applies a pub/sub message to the local tier and reports whether the
+// channel has been subscribed to
+func (c *TieredCache) handle(message interface{}, subscribed bool) bool {
+	switch message := message.(type) {
+	case *redis_lib.Subscription:
+		if message.Kind != "subscribe" {
+			return subscribed
+		}
+		if subscribed {
+			log.Printf("Cache invalidations resubscribed, emptying the local cache")
+			c.purgeLocal()
+		}
+		return true
+	case *redis_lib.Message:
+		origin, key, ok := strings.Cut(message.Payload, " ")
+		switch {
+		case origin == c.origin:
+		case ok:
+			c.invalidateLocal(key)
+		default:
+			// An announcement without a key is a flush
+			c.purgeLocal()
+		}
+	}
+	return subscribed
+}
+
+func (c *TieredCache) GetOrder(ctx context.Context, key string) (*structs.Order, error) {
+	order, err := c.local.GetOrder(ctx, key)
+	if err == nil {
+		return order, nil
+	}
+	fill := c.startFill(key)
+	order, err = c.remote.GetOrder(ctx, key)
+	if err != nil {
+		c.finishFill(key, fill, nil)
+		return nil, err
+	}
+	c.finishFill(key, fill, order)
+	return order, nil
+}
+
+func (c *TieredCache) GetOrders(ctx context.Context, keys []string) (map[string]*structs.Order, error) {
+	result, err := c.local.GetOrders(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	missing := make([]string, 0, len(keys)-len(result))
+	for _, key := range keys {
+		if _, ok := result[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	fills := make([]*localFill, len(missing))
+	for i, key := range missing {
+		fills[i] = c.startFill(key)
+	}
+	found, err := c.remote.GetOrders(ctx, missing)
+	for i, key := range missing {
+		c.finishFill(key, fills[i], found[key])
+	}
+	if err != nil {
+		return nil, err
+	}
+	for key, order := range found {
+		result[key] = order
+	}
+	return result, nil
+}
+
+func (c *TieredCache) PutOrder(ctx context.Context, key string, order *structs.Order) error {
+	err := c.remote.PutOrder(ctx, key, order)
+	if err != nil {
+		return err
+	}
+	return c.local.PutOrder(ctx, key, order)
+}
+
+// DeleteOrder deletes the key from Redis, then from the local tier, and
+// announces it to the other replicas. Deleting Redis first keeps local
+// misses from reading the old order back in.
+func (c *TieredCache) DeleteOrder(ctx context.Context, key string) error {
+	err := c.remote.DeleteOrder(ctx, key)
+	c.invalidateLocal(key)
+	if err != nil {
+		return err
+	}
+	return c.publish(ctx, c.origin+" "+key)
+}
+
+// DeleteOrders deletes the keys from Redis, then from the local tier, and
+// announces them to the other replicas; the count is the keys Redis had
+func (c *TieredCache) DeleteOrders(ctx context.Context, keys []string) (int, error) {
+	deleted, err := c.remote.DeleteOrders(ctx, keys)
+	for _, key := range keys {
+		c.invalidateLocal(key)
+	}
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		err = c.publish(ctx, c.origin+" "+key)
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// Flush empties both tiers and announces it to the other replicas
+func (c *TieredCache) Flush(ctx context.Context) error {
+	err := c.remote.Flush(ctx)
+	if err != nil {
+		return err
+	}
+	c.purgeLocal()
+	return c.publish(ctx, c.origin)
+}
+
+// Stats describes Redis with the local tier of this replica
+func (c *TieredCache) Stats(ctx context.Context) (*structs.CacheStats, error) {
+	stats, err := c.remote.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.Local, err = c.local.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.Type = "tiered"
+	return stats, nil
+}
+
+// Keys lists the keys in Redis, which holds every key of the local tiers
+func (c *TieredCache) Keys(ctx context.Context, pattern string, limit int) ([]string, error) {
+	return c.remote.Keys(ctx, pattern, limit)
+}
+
+// HealthCheck checks Redis; the local tier is always healthy
+func (c *TieredCache) HealthCheck(ctx context.Context) error {
+	return c.remote.HealthCheck(ctx)
+}
+
+// startFill registers a Redis read of the key that may fill the local tier
+func (c *TieredCache) startFill(key string) *localFill {
+	c.fillMutex.Lock()
+	defer c.fillMutex.Unlock()
+	if c.fills == nil {
+		c.fills = make(map[string]*localFill)
+	}
+	fill, ok := c.fills[key]
+	if !ok {
+		fill = new(localFill)
+		c.fills[key] = fill
+	}
+	fill.readers++
+	return fill
+}
+
+// finishFill copies the order read from Redis into the local tier unless the
+// key was invalidated since the read started; a nil order fills nothing
+func (c *TieredCache) finishFill(key string, fill *localFill, order *structs.Order) {
+	c.fillMutex.Lock()
+	defer c.fillMutex.Unlock()
+	if order != nil && !fill.stale {
+		c.local.PutOrder(context.Background(), key, order)
+	}
+	fill.readers--
+	if fill.readers == 0 {
+		delete(c.fills, key)
+	}
+}
+
+// invalidateLocal deletes the key from the local tier and keeps reads in
+// flight from putting it back
+func (c *TieredCache) invalidateLocal(key string) {
+	c.fillMutex.Lock()
+	defer c.fillMutex.Unlock()
+	if fill, ok := c.fills[key]; ok {
+		fill.stale = true
+	}
+	c.local.DeleteOrder(context.Background(), key)
+}
+
+// purgeLocal empties the local tier and keeps reads in flight from filling it
+func (c *TieredCache) purgeLocal() {
+	c.fillMutex.Lock()
+	defer c.fillMutex.Unlock()
+	for _, fill := range c.fills {
+		fill.stale = true
+	}
+	c.local.purge()
+}
@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	redis_lib "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/structs"
+)
+
+// newTieredReplicas returns two tiered caches sharing a remote tier, with
+// announcements of each delivered to both like Redis pub/sub does
+func newTieredReplicas() (*TieredCache, *TieredCache) {
+	remote := NewMemoryCache()
+	replicas := []*TieredCache{
+		{local: NewMemoryCache(), remote: remote, origin: "a"},
+		{local: NewMemoryCache(), remote: remote, origin: "b"},
+	}
+	for _, replica := range replicas {
+		replica.publish = func(_ context.Context, payload string) error {
+			for _, receiver := range replicas {
+				receiver.handle(&redis_lib.Message{Payload: payload}, true)
+			}
+			return nil
+		}
+	}
+	return replicas[0], replicas[1]
+}
+
+func TestTieredCacheReadsThroughTiers(t *testing.T) {
+	a, b := newTieredReplicas()
+	ctx := context.Background()
+	require.NoError(t, a.PutOrder(ctx, "x", &structs.Order{OrderUid: "x"}))
+
+	// b finds the order remotely and keeps a local copy
+	order, err := b.GetOrder(ctx, "x")
+	require.NoError(t, err)
+	assert.Equal(t, "x", order.OrderUid)
+	require.NoError(t, b.remote.DeleteOrder(ctx, "x"))
+	_, err = b.GetOrder(ctx, "x")
+	assert.NoError(t, err)
+
+	_, err = b.GetOrder(ctx, "missing")
+	assert.True(t, IsErrCacheMiss(err))
+
+	require.NoError(t, a.remote.PutOrder(ctx, "y", &structs.Order{OrderUid: "y"}))
+	found, err := b.GetOrders(ctx, []string{"x", "y", "missing"})
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+	_, err = b.local.GetOrder(ctx, "y")
+	assert.NoError(t, err)
+}
+
+func TestTieredCacheInvalidatesReplicas(t *testing.T) {
+	a, b := newTieredReplicas()
+	ctx := context.Background()
+	require.NoError(t, a.PutOrder(ctx, "x", &structs.Order{OrderUid: "x"}))
+	_, err := b.GetOrder(ctx, "x")
+	require.NoError(t, err)
+
+	require.NoError(t, a.DeleteOrder(ctx, "x"))
+	for _, replica := range []*TieredCache{a, b} {
+		_, err = replica.GetOrder(ctx, "x")
+		assert.True(t, IsErrCacheMiss(err), replica.origin)
+	}
+
+	// A replica ignores its own announcements
+	require.NoError(t, a.PutOrder(ctx, "y", &structs.Order{OrderUid: "y"}))
+	a.handle(&redis_lib.Message{Payload: "a y"}, true)
+	_, err = a.local.GetOrder(ctx, "y")
+	assert.NoError(t, err)
+}
+
+func TestTieredCacheEmptiesLocalTierOnResubscribe(t *testing.T) {
+	a, _ := newTieredReplicas()
+	ctx := context.Background()
+	require.NoError(t, a.PutOrder(ctx, "x", &structs.Order{OrderUid: "x"}))
+
+	subscribed := a.handle(&redis_lib.Subscription{Kind: "subscribe"}, false)
+	assert.True(t, subscribed)
+	_, err := a.local.GetOrder(ctx, "x")
+	assert.NoError(t, err)
+
+	a.handle(&redis_lib.Subscription{Kind: "subscribe"}, subscribed)
+	_, err = a.local.GetOrder(ctx, "x")
+	assert.True(t, IsErrCacheMiss(err))
+	_, err = a.GetOrder(ctx, "x")
+	assert.NoError(t, err)
+}
+
+func TestTieredCacheAdministration(t *testing.T) {
+	a, b := newTieredReplicas()
+	ctx := context.Background()
+	for _, key := range []string{"x", "y", "z"} {
+		require.NoError(t, a.PutOrder(ctx, key, &structs.Order{OrderUid: key}))
+		_, err := b.GetOrder(ctx, key)
+		require.NoError(t, err)
+	}
+
+	deleted, err := a.DeleteOrders(ctx, []string{"x", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = b.local.GetOrder(ctx, "x")
+	assert.True(t, IsErrCacheMiss(err))
+
+	stats, err := b.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "tiered", stats.Type)
+	assert.Equal(t, int64(2), stats.Entries)
+	require.NotNil(t, stats.Local)
+	assert.Equal(t, int64(2), stats.Local.Entries)
+
+	// A flush empties the local tier of every replica
+	require.NoError(t, a.Flush(ctx))
+	for _, replica := range []*TieredCache{a, b} {
+		keys, err := replica.local.Keys(ctx, "", 10)
+		require.NoError(t, err)
+		assert.Empty(t, keys, replica.origin)
+	}
+	keys, err := b.Keys(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+// racingCache runs invalidate after a read has returned its order but before
+// the caller uses it
+type racingCache struct {
+	*MemoryCache
+	invalidate func()
+}
+
+func (c *racingCache) GetOrder(ctx context.Context, key string) (*structs.Order, error) {
+	order, err := c.MemoryCache.GetOrder(ctx, key)
+	if c.invalidate != nil {
+		c.invalidate()
+	}
+	return order, err
+}
+
+func (c *racingCache) GetOrders(ctx context.Context, keys []string) (map[string]*structs.Order, error) {
+	orders, err := c.MemoryCache.GetOrders(ctx, keys)
+	if c.invalidate != nil {
+		c.invalidate()
+	}
+	return orders, err
+}
+
+func TestTieredCacheSkipsFillsRacingInvalidations(t *testing.T) {
+	a, b := newTieredReplicas()
+	ctx := context.Background()
+	remote := &racingCache{MemoryCache: a.remote.(*MemoryCache)}
+	b.remote = remote
+	require.NoError(t, a.PutOrder(ctx, "x", &structs.Order{OrderUid: "x"}))
+
+	// The order is deleted while b reads it from Redis
+	remote.invalidate = func() {
+		remote.invalidate = nil
+		require.NoError(t, a.DeleteOrder(ctx, "x"))
+	}
+	_, err := b.GetOrder(ctx, "x")
+	require.NoError(t, err)
+	_, err = b.local.GetOrder(ctx, "x")
+	assert.True(t, IsErrCacheMiss(err))
+
+	require.NoError(t, a.PutOrder(ctx, "y", &structs.Order{OrderUid: "y"}))
+	remote.invalidate = func() {
+		remote.invalidate = nil
+		require.NoError(t, a.Flush(ctx))
+	}
+	_, err = b.GetOrders(ctx, []string{"y"})
+	require.NoError(t, err)
+	_, err = b.local.GetOrder(ctx, "y")
+	assert.True(t, IsErrCacheMiss(err))
+	assert.Empty(t, b.fills)
+
+	// Without an invalidation the read fills the local tier
+	require.NoError(t, a.PutOrder(ctx, "z", &structs.Order{OrderUid: "z"}))
+	_, err = b.GetOrder(ctx, "z")
+	require.NoError(t, err)
+	_, err = b.local.GetOrder(ctx, "z")
+	assert.NoError(t, err)
+}
+
+func TestTieredCacheBoundsLocalTtl(t *testing.T) {
+	assert.Equal(t, maxLocalTtl, NewTieredCache(nil).local.ttl)
+}
@@ -0,0 +1,97 @@
+package codec
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+
+	"wb-L0/structs"
+)
+
+// avroApi maps record fields to the json tags of structs.Order
+var avroApi = avro.Config{TagKey: "json"}.Freeze()
+
+// AvroCodec reads and writes orders in the Confluent schema registry wire
+// format: a zero magic byte, the 4-byte big-endian schema id and the Avro
+// binary payload. Payloads written with another registered schema are
+// resolved against the order schema: unknown fields are dropped and missing
+// fields take their defaults.
+type AvroCodec struct {
+	store    *LocalSchemaStore
+	schemaId uint32
+	schema   avro.Schema
+
+	mutex    sync.RWMutex
+	resolved map[uint32]avro.Schema
+}
+
+func NewAvroCodec(store *LocalSchemaStore) *AvroCodec {
+	schema, err := store.Schema(OrderSchemaId)
+	if err != nil {
+		panic(err)
+	}
+	return &AvroCodec{
+		store:    store,
+		schemaId: OrderSchemaId,
+		schema:   schema,
+		resolved: map[uint32]avro.Schema{OrderSchemaId: schema},
+	}
+}
+
+func (c *AvroCodec) ContentType() string {
+	return ContentTypeAvro
+}
+
+func (c *AvroCodec) Decode(data []byte) (*structs.Order, error) {
+	if len(data) < 5 || data[0] != 0 {
+		return nil, malformedAvro("missing schema registry header")
+	}
+	schema, err := c.readerSchema(binary.BigEndian.Uint32(data[1:5]))
+	if err != nil {
+		return nil, err
+	}
+	var order structs.Order
+	err = avroApi.Unmarshal(schema, data[5:], &order)
+	if err != nil {
+		return nil, malformedAvro(err.Error())
+	}
+	return &order, nil
+}
+
+func (c *AvroCodec) Encode(order *structs.Order) ([]byte, error) {
+	payload, err := avroApi.Marshal(c.schema, order)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(b[1:], c.schemaId)
+	return append(b, payload...), nil
+}
+
+// readerSchema returns the order schema resolved against the writer schema
+// of the id. Resolutions are kept, as registered schemas never change.
+func (c *AvroCodec) readerSchema(id uint32) (avro.Schema, error) {
+	c.mutex.RLock()
+	schema, ok := c.resolved[id]
+	c.mutex.RUnlock()
+	if ok {
+		return schema, nil
+	}
+	writer, err := c.store.Schema(id)
+	if err != nil {
+		return nil, err
+	}
+	schema, err = avro.NewSchemaCompatibility().Resolve(c.schema, writer)
+	if err != nil {
+		return nil, malformedAvro(err.Error())
+	}
+	c.mutex.Lock()
+	c.resolved[id] = schema
+	c.mutex.Unlock()
+	return schema, nil
+}
+
+func malformedAvro(reason string) error {
+	return ErrMalformed{ContentType: ContentTypeAvro, Err: reason}
+}
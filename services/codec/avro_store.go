@@ -0,0 +1,81 @@
+package codec
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+)
+
+// OrderSchemaId is the schema registry id of schemas/order.avsc
+const OrderSchemaId uint32 = 1
+
+//go:embed schemas/order.avsc
+var orderSchema []byte
+
+// LocalSchemaStore resolves schema registry ids without a registry server.
+// It always holds the order schema under OrderSchemaId; further schemas are
+// loaded from files named <id>.avsc, e.g. exported from a Confluent registry
+// with GET /schemas/ids/<id>/schema.
+type LocalSchemaStore struct {
+	mutex   sync.RWMutex
+	schemas map[uint32]avro.Schema
+}
+
+func NewLocalSchemaStore() *LocalSchemaStore {
+	store := &LocalSchemaStore{schemas: make(map[uint32]avro.Schema)}
+	if err := store.Register(OrderSchemaId, orderSchema); err != nil {
+		panic(err)
+	}
+	return store
+}
+
+// Register parses the schema and stores it under the id. Every schema gets
+// its own name cache, so versions of a record do not replace each other.
+func (s *LocalSchemaStore) Register(id uint32, text []byte) error {
+	schema, err := avro.ParseWithCache(string(text), "", &avro.SchemaCache{})
+	if err != nil {
+		return fmt.Errorf("schema %d: invalid avro schema: %w", id, err)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.schemas[id] = schema
+	return nil
+}
+
+func (s *LocalSchemaStore) Schema(id uint32) (avro.Schema, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	schema, ok := s.schemas[id]
+	if !ok {
+		return nil, ErrUnknownSchema{Id: id}
+	}
+	return schema, nil
+}
+
+// LoadDir registers every <id>.avsc file of the directory
+func (s *LocalSchemaStore) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.avsc"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		id, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), ".avsc"), 10, 32)
+		if err != nil {
+			return fmt.Errorf("schema file %s is not named <id>.avsc", path)
+		}
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := s.Register(uint32(id), text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
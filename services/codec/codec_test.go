@@ -0,0 +1,230 @@
+package codec
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hamba/avro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"wb-L0/structs"
+)
+
+func testOrder() *structs.Order {
+	return &structs.Order{
+		OrderUid:          "b563feb7b2b84b6test",
+		TrackNumber:       "WBILMTESTTRACK",
+		Entry:             "WBIL",
+		Locale:            "en",
+		InternalSignature: "sig",
+		CustomerId:        "test",
+		DeliveryService:   "meest",
+		Shardkey:          "9",
+		SmId:              99,
+		DateCreated:       "2021-11-26T06:22:19Z",
+		OofShard:          "1",
+		Delivery: structs.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: structs.Payment{
+			Transaction:  "b563feb7b2b84b6test",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []structs.Item{
+			{
+				ChrtId:      9934930,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       453,
+				Rid:         "ab4219087a764ae0btest",
+				Name:        "Mascaras",
+				Sale:        -30,
+				Size:        "0",
+				TotalPrice:  317,
+				NmId:        2389212,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+		},
+	}
+}
+
+// TestCodecsRoundTrip tests that every codec decodes what it encodes
+func TestCodecsRoundTrip(t *testing.T) {
+	for _, codec := range []Codec{JsonCodec{}, ProtobufCodec{}, NewAvroCodec(NewLocalSchemaStore())} {
+		t.Run(codec.ContentType(), func(t *testing.T) {
+			data, err := codec.Encode(testOrder())
+			require.NoError(t, err)
+
+			order, err := codec.Decode(data)
+
+			require.NoError(t, err)
+			assert.Equal(t, testOrder(), order)
+		})
+	}
+}
+
+// TestRegistryLookup tests content type normalization and unsupported types
+func TestRegistryLookup(t *testing.T) {
+	registry := NewDefaultRegistry(NewLocalSchemaStore())
+	cases := map[string]string{
+		"":                                   ContentTypeJson,
+		"application/json; charset=utf-8":    ContentTypeJson,
+		"Application/X-Protobuf":             ContentTypeProtobuf,
+		"application/protobuf":               ContentTypeProtobuf,
+		"avro/binary":                        ContentTypeAvro,
+		"application/vnd.apache.avro+binary": ContentTypeAvro,
+	}
+	for contentType, expected := range cases {
+		codec, err := registry.Lookup(contentType)
+		require.NoError(t, err, contentType)
+		assert.Equal(t, expected, codec.ContentType())
+	}
+
+	_, err := registry.Lookup("application/xml")
+	assert.True(t, IsErrUnsupportedContentType(err))
+}
+
+// TestProtobufSkipsUnknownFields tests that fields added by newer producers are ignored
+func TestProtobufSkipsUnknownFields(t *testing.T) {
+	data, err := ProtobufCodec{}.Encode(testOrder())
+	require.NoError(t, err)
+	data = protowire.AppendTag(data, 99, protowire.BytesType)
+	data = protowire.AppendString(data, "future")
+	data = protowire.AppendTag(data, 100, protowire.VarintType)
+	data = protowire.AppendVarint(data, 7)
+
+	order, err := ProtobufCodec{}.Decode(data)
+
+	require.NoError(t, err)
+	assert.Equal(t, testOrder(), order)
+}
+
+// TestProtobufMalformed tests that truncated payloads are rejected
+func TestProtobufMalformed(t *testing.T) {
+	data, err := ProtobufCodec{}.Encode(testOrder())
+	require.NoError(t, err)
+
+	_, err = ProtobufCodec{}.Decode(data[:len(data)-2])
+
+	assert.Error(t, err)
+}
+
+// evolvedSchema is order.avsc with a new field and without a defaulted one
+const evolvedSchema = `{
+  "type": "record", "name": "Order", "namespace": "wb.order.v1",
+  "fields": [
+    {"name": "order_uid", "type": "string"},
+    {"name": "priority", "type": ["null", "int"], "default": null},
+    {"name": "track_number", "type": "string"},
+    {"name": "entry", "type": "string"},
+    {"name": "delivery", "type": {"type": "record", "name": "Delivery", "fields": [
+      {"name": "name", "type": "string"}, {"name": "phone", "type": "string"},
+      {"name": "zip", "type": "string"}, {"name": "city", "type": "string"},
+      {"name": "address", "type": "string"}, {"name": "region", "type": "string"},
+      {"name": "email", "type": "string"}
+    ]}},
+    {"name": "payment", "type": {"type": "record", "name": "Payment", "fields": [
+      {"name": "transaction", "type": "string"}, {"name": "request_id", "type": "string"},
+      {"name": "currency", "type": "string"}, {"name": "provider", "type": "string"},
+      {"name": "amount", "type": "long"}, {"name": "payment_dt", "type": "long"},
+      {"name": "bank", "type": "string"}, {"name": "delivery_cost", "type": "long"},
+      {"name": "goods_total", "type": "long"}, {"name": "custom_fee", "type": "long"}
+    ]}},
+    {"name": "items", "type": {"type": "array", "items": {"type": "record", "name": "Item", "fields": [
+      {"name": "chrt_id", "type": "long"}, {"name": "track_number", "type": "string"},
+      {"name": "price", "type": "long"}, {"name": "rid", "type": "string"},
+      {"name": "name", "type": "string"}, {"name": "sale", "type": "int"},
+      {"name": "size", "type": "string"}, {"name": "total_price", "type": "long"},
+      {"name": "nm_id", "type": "long"}, {"name": "brand", "type": "string"},
+      {"name": "status", "type": "int"}
+    ]}}},
+    {"name": "locale", "type": "string"},
+    {"name": "customer_id", "type": "string"},
+    {"name": "delivery_service", "type": "string"},
+    {"name": "shardkey", "type": "string"},
+    {"name": "sm_id", "type": "int"},
+    {"name": "date_created", "type": "string"},
+    {"name": "oof_shard", "type": "string"}
+  ]
+}`
+
+// evolvedOrder is an order as written by a producer using evolvedSchema
+type evolvedOrder struct {
+	structs.Order
+	Priority *int `json:"priority"`
+}
+
+// TestAvroSchemaEvolution tests that payloads of another registered writer schema are resolved
+func TestAvroSchemaEvolution(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "7.avsc"), []byte(evolvedSchema), 0o644))
+	store := NewLocalSchemaStore()
+	require.NoError(t, store.LoadDir(dir))
+	writer, err := store.Schema(7)
+	require.NoError(t, err)
+	expected := testOrder()
+	expected.InternalSignature = ""
+
+	// Write with the evolved schema the way a newer producer would
+	priority := 3
+	payload, err := avroApi.Marshal(writer, evolvedOrder{Order: *expected, Priority: &priority})
+	require.NoError(t, err)
+	data := append([]byte{0, 0, 0, 0, 7}, payload...)
+
+	order, err := NewAvroCodec(store).Decode(data)
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, order)
+}
+
+// TestAvroIncompatibleSchema tests that payloads of a schema the order schema cannot read are rejected
+func TestAvroIncompatibleSchema(t *testing.T) {
+	store := NewLocalSchemaStore()
+	require.NoError(t, store.Register(8, []byte(`{"type": "record", "name": "Order", "namespace": "wb.order.v1",
+		"fields": [{"name": "order_uid", "type": "long"}]}`)))
+	writer, err := store.Schema(8)
+	require.NoError(t, err)
+	payload, err := avro.Marshal(writer, map[string]any{"order_uid": int64(1)})
+	require.NoError(t, err)
+
+	_, err = NewAvroCodec(store).Decode(append([]byte{0, 0, 0, 0, 8}, payload...))
+
+	assert.Error(t, err)
+	assert.False(t, IsErrUnknownSchema(err))
+}
+
+// TestAvroUnknownSchema tests that payloads of unregistered schemas are rejected
+func TestAvroUnknownSchema(t *testing.T) {
+	codec := NewAvroCodec(NewLocalSchemaStore())
+	data, err := codec.Encode(testOrder())
+	require.NoError(t, err)
+	binary.BigEndian.PutUint32(data[1:5], 42)
+
+	_, err = codec.Decode(data)
+
+	assert.True(t, IsErrUnknownSchema(err))
+}
+
+// TestAvroMissingHeader tests that payloads without the registry header are rejected
+func TestAvroMissingHeader(t *testing.T) {
+	_, err := NewAvroCodec(NewLocalSchemaStore()).Decode([]byte{1, 2})
+
+	assert.Error(t, err)
+	assert.False(t, IsErrUnknownSchema(err))
+}
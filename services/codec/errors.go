@@ -0,0 +1,40 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ErrUnsupportedContentType struct {
+	ContentType string
+}
+
+func IsErrUnsupportedContentType(err error) bool {
+	return errors.As(err, new(ErrUnsupportedContentType))
+}
+
+func (e ErrUnsupportedContentType) Error() string {
+	return fmt.Sprintf("unsupported content type: %s", e.ContentType)
+}
+
+type ErrUnknownSchema struct {
+	Id uint32
+}
+
+func IsErrUnknownSchema(err error) bool {
+	return errors.As(err, new(ErrUnknownSchema))
+}
+
+func (e ErrUnknownSchema) Error() string {
+	return fmt.Sprintf("unknown avro schema id: %d", e.Id)
+}
+
+// ErrMalformed reports a payload that does not match its format
+type ErrMalformed struct {
+	ContentType string
+	Err         string
+}
+
+func (e ErrMalformed) Error() string {
+	return fmt.Sprintf("malformed %s payload: %s", e.ContentType, e.Err)
+}
@@ -0,0 +1,100 @@
+package codec
+
+import (
+	"mime"
+	"strings"
+
+	"wb-L0/structs"
+)
+
+// ContentTypeHeader is the message header selecting the codec
+const ContentTypeHeader = "content-type"
+
+// Supported content types
+const (
+	ContentTypeJson     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeAvro     = "application/vnd.apache.avro+binary"
+)
+
+// aliases maps other common spellings to the supported content types
+var aliases = map[string]string{
+	"text/json":                       ContentTypeJson,
+	"application/protobuf":            ContentTypeProtobuf,
+	"application/vnd.google.protobuf": ContentTypeProtobuf,
+	"avro/binary":                     ContentTypeAvro,
+	"application/avro":                ContentTypeAvro,
+}
+
+var registryInstance = NewDefaultRegistry(NewLocalSchemaStore())
+
+// Codec converts orders from and to one wire format
+type Codec interface {
+	ContentType() string
+	Decode(data []byte) (*structs.Order, error)
+	Encode(order *structs.Order) ([]byte, error)
+}
+
+// Registry selects a codec by content type. Messages without a content type
+// are JSON, the format used before codecs were introduced.
+type Registry struct {
+	codecs map[string]Codec
+}
+
+func NewRegistry(codecs ...Codec) *Registry {
+	registry := &Registry{codecs: make(map[string]Codec, len(codecs))}
+	for _, codec := range codecs {
+		registry.Register(codec)
+	}
+	return registry
+}
+
+// NewDefaultRegistry supports JSON, Protobuf and Avro resolved through the store
+func NewDefaultRegistry(store *LocalSchemaStore) *Registry {
+	return NewRegistry(JsonCodec{}, ProtobufCodec{}, NewAvroCodec(store))
+}
+
+// Register adds a codec, replacing the one registered for its content type
+func (r *Registry) Register(codec Codec) {
+	r.codecs[codec.ContentType()] = codec
+}
+
+func (r *Registry) Lookup(contentType string) (Codec, error) {
+	normalized := normalize(contentType)
+	codec, ok := r.codecs[normalized]
+	if !ok {
+		return nil, ErrUnsupportedContentType{ContentType: contentType}
+	}
+	return codec, nil
+}
+
+func (r *Registry) Decode(contentType string, data []byte) (*structs.Order, error) {
+	codec, err := r.Lookup(contentType)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Decode(data)
+}
+
+// normalize drops parameters and case and resolves aliases
+func normalize(contentType string) string {
+	if contentType == "" {
+		return ContentTypeJson
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if alias, ok := aliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
+
+func SetRegistry(registry *Registry) {
+	registryInstance = registry
+}
+
+func GetRegistry() *Registry {
+	return registryInstance
+}
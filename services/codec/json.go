@@ -0,0 +1,23 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"wb-L0/structs"
+)
+
+type JsonCodec struct{}
+
+func (JsonCodec) ContentType() string {
+	return ContentTypeJson
+}
+
+func (JsonCodec) Decode(data []byte) (*structs.Order, error) {
+	var order structs.Order
+	err := json.Unmarshal(data, &order)
+	return &order, err
+}
+
+func (JsonCodec) Encode(order *structs.Order) ([]byte, error) {
+	return json.Marshal(order)
+}
@@ -0,0 +1,142 @@
+package codec
+
+import (
+	"google.golang.org/protobuf/proto"
+
+	"wb-L0/services/codec/orderpb"
+	"wb-L0/structs"
+)
+
+//go:generate protoc --go_out=../.. --go_opt=module=wb-L0 proto/order.proto
+
+// ProtobufCodec encodes orders as the wb.order.v1.Order message defined in
+// proto/order.proto. Unknown fields are skipped, so producers may add fields
+// without breaking the consumer.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (ProtobufCodec) Decode(data []byte) (*structs.Order, error) {
+	var message orderpb.Order
+	err := proto.Unmarshal(data, &message)
+	if err != nil {
+		return nil, ErrMalformed{ContentType: ContentTypeProtobuf, Err: err.Error()}
+	}
+	return fromProtobuf(&message), nil
+}
+
+func (ProtobufCodec) Encode(order *structs.Order) ([]byte, error) {
+	return proto.Marshal(toProtobuf(order))
+}
+
+func fromProtobuf(message *orderpb.Order) *structs.Order {
+	order := &structs.Order{
+		OrderUid:          message.GetOrderUid(),
+		TrackNumber:       message.GetTrackNumber(),
+		Entry:             message.GetEntry(),
+		Locale:            message.GetLocale(),
+		InternalSignature: message.GetInternalSignature(),
+		CustomerId:        message.GetCustomerId(),
+		DeliveryService:   message.GetDeliveryService(),
+		Shardkey:          message.GetShardkey(),
+		SmId:              int(message.GetSmId()),
+		DateCreated:       message.GetDateCreated(),
+		OofShard:          message.GetOofShard(),
+	}
+	if delivery := message.GetDelivery(); delivery != nil {
+		order.Delivery = structs.Delivery{
+			Name:    delivery.GetName(),
+			Phone:   delivery.GetPhone(),
+			Zip:     delivery.GetZip(),
+			City:    delivery.GetCity(),
+			Address: delivery.GetAddress(),
+			Region:  delivery.GetRegion(),
+			Email:   delivery.GetEmail(),
+		}
+	}
+	if payment := message.GetPayment(); payment != nil {
+		order.Payment = structs.Payment{
+			Transaction:  payment.GetTransaction(),
+			RequestId:    payment.GetRequestId(),
+			Currency:     payment.GetCurrency(),
+			Provider:     payment.GetProvider(),
+			Amount:       int(payment.GetAmount()),
+			PaymentDt:    payment.GetPaymentDt(),
+			Bank:         payment.GetBank(),
+			DeliveryCost: int(payment.GetDeliveryCost()),
+			GoodsTotal:   int(payment.GetGoodsTotal()),
+			CustomFee:    int(payment.GetCustomFee()),
+		}
+	}
+	for _, item := range message.GetItems() {
+		order.Items = append(order.Items, structs.Item{
+			ChrtId:      item.GetChrtId(),
+			TrackNumber: item.GetTrackNumber(),
+			Price:       int(item.GetPrice()),
+			Rid:         item.GetRid(),
+			Name:        item.GetName(),
+			Sale:        int(item.GetSale()),
+			Size:        item.GetSize(),
+			TotalPrice:  int(item.GetTotalPrice()),
+			NmId:        item.GetNmId(),
+			Brand:       item.GetBrand(),
+			Status:      int(item.GetStatus()),
+		})
+	}
+	return order
+}
+
+func toProtobuf(order *structs.Order) *orderpb.Order {
+	message := &orderpb.Order{
+		OrderUid:          order.OrderUid,
+		TrackNumber:       order.TrackNumber,
+		Entry:             order.Entry,
+		Locale:            order.Locale,
+		InternalSignature: order.InternalSignature,
+		CustomerId:        order.CustomerId,
+		DeliveryService:   order.DeliveryService,
+		Shardkey:          order.Shardkey,
+		SmId:              int32(order.SmId),
+		DateCreated:       order.DateCreated,
+		OofShard:          order.OofShard,
+		Delivery: &orderpb.Delivery{
+			Name:    order.Delivery.Name,
+			Phone:   order.Delivery.Phone,
+			Zip:     order.Delivery.Zip,
+			City:    order.Delivery.City,
+			Address: order.Delivery.Address,
+			Region:  order.Delivery.Region,
+			Email:   order.Delivery.Email,
+		},
+		Payment: &orderpb.Payment{
+			Transaction:  order.Payment.Transaction,
+			RequestId:    order.Payment.RequestId,
+			Currency:     order.Payment.Currency,
+			Provider:     order.Payment.Provider,
+			Amount:       int64(order.Payment.Amount),
+			PaymentDt:    order.Payment.PaymentDt,
+			Bank:         order.Payment.Bank,
+			DeliveryCost: int64(order.Payment.DeliveryCost),
+			GoodsTotal:   int64(order.Payment.GoodsTotal),
+			CustomFee:    int64(order.Payment.CustomFee),
+		},
+	}
+	for _, item := range order.Items {
+		message.Items = append(message.Items, &orderpb.Item{
+			ChrtId:      item.ChrtId,
+			TrackNumber: item.TrackNumber,
+			Price:       int64(item.Price),
+			Rid:         item.Rid,
+			Name:        item.Name,
+			Sale:        int32(item.Sale),
+			Size:        item.Size,
+			TotalPrice:  int64(item.TotalPrice),
+			NmId:        item.NmId,
+			Brand:       item.Brand,
+			Status:      int32(item.Status),
+		})
+	}
+	return message
+}
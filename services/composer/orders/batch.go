@@ -0,0 +1,130 @@
+package orders
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"wb-L0/modules/monitoring"
+	"wb-L0/services/broker"
+	"wb-L0/services/cache"
+	"wb-L0/services/database"
+	"wb-L0/services/validation"
+	"wb-L0/structs"
+)
+
+const defaultBatchWait = 200 * time.Millisecond
+
+// consumeBatches accumulates up to size messages, or whatever arrived within
+// wait of the first one, and hands them to handleBatch
+func consumeBatches(ctx context.Context, messageChan <-chan broker.Message, size int, wait time.Duration) {
+	batch := make([]broker.Message, 0, size)
+	timer := time.NewTimer(wait)
+	timer.Stop()
+	defer timer.Stop()
+	flush := func() {
+		timer.Stop()
+		if len(batch) == 0 {
+			return
+		}
+		handleBatch(ctx, batch)
+		batch = make([]broker.Message, 0, size)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messageChan:
+			if !ok {
+				flush()
+				return
+			}
+			if message.Err != nil {
+				log.Println(message.Err)
+				continue
+			}
+			if isStatusMessage(message) {
+				// Orders consumed before the status change are stored first
+				flush()
+				handleStatusMessage(ctx, message)
+				continue
+			}
+			batch = append(batch, message)
+			if len(batch) == 1 {
+				timer.Reset(wait)
+			}
+			if len(batch) >= size {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// handleBatch stores all valid orders of the batch in one transaction and
+// only then acknowledges their messages. Messages that fail decoding or
+// validation, and orders the duplicate policy rejects, are dead-lettered
+// afterwards, so no offset is committed before the orders preceding it are
+// stored. If the transaction fails every message goes through handleMessage
+// to isolate the bad record.
+func handleBatch(ctx context.Context, messages []broker.Message) {
+	orders := make([]*structs.Order, 0, len(messages))
+	// orderIndex maps each message to its order, -1 when it has none
+	orderIndex := make([]int, len(messages))
+	for i, message := range messages {
+		orderIndex[i] = -1
+		order, err := decodeOrder(message)
+		if err != nil || validation.ValidateOrder(order) != nil {
+			continue
+		}
+		orderIndex[i] = len(orders)
+		orders = append(orders, order)
+	}
+	insertCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	results, err := database.GetDatabase().InsertOrders(insertCtx, orders)
+	cancel()
+	if err != nil {
+		log.Printf("Batch insert of %d orders failed: %s. Falling back to single inserts!", len(orders), err.Error())
+		for _, message := range messages {
+			handleMessage(ctx, message)
+		}
+		return
+	}
+	// Stored orders may have replaced cached versions
+	stored := make([]string, 0, len(orders))
+	for i, order := range orders {
+		if results[i] == nil {
+			notFound.forget(order.OrderUid)
+			stored = append(stored, order.OrderUid)
+		}
+	}
+	if len(stored) > 0 {
+		cache.Invalidate(stored...)
+		_, err = cache.GetCache().DeleteOrders(ctx, stored)
+		if err != nil {
+			log.Printf("Cache invalidation of %d orders failed: %s", len(stored), err.Error())
+		}
+	}
+	for i, message := range messages {
+		index := orderIndex[i]
+		if index < 0 {
+			handleMessage(ctx, message)
+			continue
+		}
+		outcome := outcomeStored
+		switch err := results[index]; {
+		case database.IsErrOrderUnchanged(err):
+			outcome = outcomeUnchanged
+		case err != nil:
+			log.Printf("Insert order failed: %s. Message dead-lettered!", err.Error())
+			deadLetter(ctx, message, err)
+			continue
+		}
+		monitoring.IncrementKafkaMessagesProcessed(message.Topic, outcome)
+		err = message.Ack()
+		if err != nil {
+			log.Printf("Ack failed: %s", err.Error())
+		}
+	}
+}
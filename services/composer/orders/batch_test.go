@@ -0,0 +1,171 @@
+package orders
+
+import (
+	contextpkg "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/broker"
+	"wb-L0/services/cache"
+	"wb-L0/services/database"
+	"wb-L0/services/deadletter"
+	"wb-L0/structs"
+)
+
+// ackLog builds broker messages that record their acknowledgements in order
+type ackLog struct {
+	acked  []int64
+	nacked []int64
+}
+
+func (l *ackLog) message(offset int64, value []byte) broker.Message {
+	return broker.Message{
+		Value:     value,
+		Topic:     "orders",
+		Partition: 0,
+		Offset:    offset,
+		Ack: func() error {
+			l.acked = append(l.acked, offset)
+			return nil
+		},
+		Nack: func() error {
+			l.nacked = append(l.nacked, offset)
+			return nil
+		},
+	}
+}
+
+// TestHandleBatchSuccess tests that a batch is stored in one call and acked in offset order
+func TestHandleBatchSuccess(t *testing.T) {
+	deadletter.SetQueue(new(recordingQueue))
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrders", mock.Anything, mock.MatchedBy(func(orders []*structs.Order) bool {
+		return len(orders) == 3
+	})).Return(nil, nil).Once()
+	database.SetDatabase(mockDB)
+	mockCache := new(MockCache)
+	mockCache.On("DeleteOrders", mock.Anything, []string{"a", "b", "c"}).Return(0, nil).Once()
+	cache.SetCache(mockCache)
+	log := new(ackLog)
+
+	handleBatch(contextpkg.Background(), []broker.Message{
+		log.message(1, validOrderPayload("a")),
+		log.message(2, validOrderPayload("b")),
+		log.message(3, validOrderPayload("c")),
+	})
+
+	assert.Equal(t, []int64{1, 2, 3}, log.acked)
+	assert.Empty(t, log.nacked)
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNotCalled(t, "InsertOrder")
+	mockCache.AssertExpectations(t)
+}
+
+// TestHandleBatchDuplicates tests that duplicates are settled per order without falling back
+func TestHandleBatchDuplicates(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrders", mock.Anything, mock.Anything).
+		Return([]error{nil, database.ErrOrderUnchanged{Id: "same"}, database.ErrOrderExists{Id: "dup"}}, nil).Once()
+	database.SetDatabase(mockDB)
+	mockCache := new(MockCache)
+	mockCache.On("DeleteOrders", mock.Anything, []string{"a"}).Return(1, nil).Once()
+	cache.SetCache(mockCache)
+	log := new(ackLog)
+
+	handleBatch(contextpkg.Background(), []broker.Message{
+		log.message(1, validOrderPayload("a")),
+		log.message(2, validOrderPayload("same")),
+		log.message(3, validOrderPayload("dup")),
+	})
+
+	require.Len(t, queue.letters, 1)
+	assert.Equal(t, int64(3), queue.letters[0].Offset)
+	assert.Equal(t, []int64{1, 2, 3}, log.acked)
+	mockDB.AssertNotCalled(t, "InsertOrder")
+	mockCache.AssertExpectations(t)
+}
+
+// TestHandleBatchInvalidMessage tests that invalid messages are dead-lettered without failing the batch
+func TestHandleBatchInvalidMessage(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrders", mock.Anything, mock.MatchedBy(func(orders []*structs.Order) bool {
+		return len(orders) == 2 && orders[0].OrderUid == "a" && orders[1].OrderUid == "c"
+	})).Return(nil, nil).Once()
+	database.SetDatabase(mockDB)
+	cache.SetCache(invalidatingCache())
+	log := new(ackLog)
+
+	handleBatch(contextpkg.Background(), []broker.Message{
+		log.message(1, validOrderPayload("a")),
+		log.message(2, []byte("{not json")),
+		log.message(3, validOrderPayload("c")),
+	})
+
+	require.Len(t, queue.letters, 1)
+	assert.Equal(t, int64(2), queue.letters[0].Offset)
+	assert.Equal(t, []int64{1, 2, 3}, log.acked)
+	mockDB.AssertExpectations(t)
+}
+
+// TestHandleBatchFallback tests that a failed batch is retried message by message to isolate the bad record
+func TestHandleBatchFallback(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrders", mock.Anything, mock.Anything).Return(nil, database.ErrDataInvalid{Err: "duplicate key"}).Once()
+	mockDB.On("InsertOrder", mock.Anything, mock.MatchedBy(func(order *structs.Order) bool {
+		return order.OrderUid == "dup"
+	})).Return(database.ErrOrderExists{Id: "dup"}).Once()
+	mockDB.On("InsertOrder", mock.Anything, mock.Anything).Return(nil).Twice()
+	database.SetDatabase(mockDB)
+	cache.SetCache(invalidatingCache())
+	log := new(ackLog)
+
+	handleBatch(contextpkg.Background(), []broker.Message{
+		log.message(1, validOrderPayload("a")),
+		log.message(2, validOrderPayload("dup")),
+		log.message(3, validOrderPayload("c")),
+	})
+
+	require.Len(t, queue.letters, 1)
+	assert.Equal(t, int64(2), queue.letters[0].Offset)
+	assert.Equal(t, []int64{1, 2, 3}, log.acked)
+	mockDB.AssertExpectations(t)
+}
+
+// TestConsumeBatchesFlush tests that batches are flushed on size and on the wait timeout
+func TestConsumeBatchesFlush(t *testing.T) {
+	deadletter.SetQueue(new(recordingQueue))
+	sizes := make(chan int, 4)
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrders", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		sizes <- len(args.Get(1).([]*structs.Order))
+	}).Return(nil, nil)
+	database.SetDatabase(mockDB)
+	cache.SetCache(invalidatingCache())
+	log := new(ackLog)
+	messageChan := make(chan broker.Message)
+	ctx, cancel := contextpkg.WithCancel(contextpkg.Background())
+	defer cancel()
+	go consumeBatches(ctx, messageChan, 2, 50*time.Millisecond)
+
+	messageChan <- log.message(1, validOrderPayload("a"))
+	messageChan <- log.message(2, validOrderPayload("b"))
+	assert.Equal(t, 2, <-sizes)
+
+	messageChan <- log.message(3, validOrderPayload("c"))
+	select {
+	case size := <-sizes:
+		assert.Equal(t, 1, size)
+	case <-time.After(time.Second):
+		t.Fatal("partial batch was not flushed after the wait timeout")
+	}
+}
@@ -0,0 +1,63 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"wb-L0/services/cache"
+	"wb-L0/structs"
+)
+
+const (
+	defaultCacheKeys = 100
+	// maxCacheKeys bounds how many keys a listing returns and how many orders
+	// a single invalidation deletes
+	maxCacheKeys = 1000
+)
+
+// CacheStats describes what the order cache holds
+func CacheStats(ctx context.Context) (*structs.CacheStats, error) {
+	return cache.GetCache().Stats(ctx)
+}
+
+// ListCachedOrderIds returns up to limit cached order ids matching the glob
+// pattern, any id for an empty pattern
+func ListCachedOrderIds(ctx context.Context, pattern string, limit int) ([]string, error) {
+	if limit < 0 || limit > maxCacheKeys {
+		return nil, ErrInvalidRequest{Err: fmt.Sprintf("limit must be between 1 and %d", maxCacheKeys)}
+	}
+	if limit == 0 {
+		limit = defaultCacheKeys
+	}
+	keys, err := cache.GetCache().Keys(ctx, pattern, limit)
+	if errors.Is(err, path.ErrBadPattern) {
+		return nil, ErrInvalidRequest{Err: fmt.Sprintf("malformed pattern: %s", pattern)}
+	}
+	return keys, err
+}
+
+// InvalidateCachedOrders deletes orders from the cache, and from the cache of
+// unknown ids, so their next read comes from the database. It returns how
+// many of them were cached.
+func InvalidateCachedOrders(ctx context.Context, orderIds []string) (int, error) {
+	if len(orderIds) == 0 {
+		return 0, ErrInvalidRequest{Err: "no order ids"}
+	}
+	if len(orderIds) > maxCacheKeys {
+		return 0, ErrInvalidRequest{Err: fmt.Sprintf("at most %d orders can be invalidated at once", maxCacheKeys)}
+	}
+	for _, orderId := range orderIds {
+		notFound.forget(orderId)
+	}
+	cache.Invalidate(orderIds...)
+	return cache.GetCache().DeleteOrders(ctx, orderIds)
+}
+
+// FlushCache empties the cache and the cache of unknown ids
+func FlushCache(ctx context.Context) error {
+	notFound.reset()
+	cache.InvalidateAll()
+	return cache.GetCache().Flush(ctx)
+}
@@ -0,0 +1,61 @@
+package orders
+
+import (
+	contextpkg "context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/cache"
+	"wb-L0/structs"
+)
+
+func TestListCachedOrderIds(t *testing.T) {
+	orderCache := cache.NewMemoryCache()
+	cache.SetCache(orderCache)
+	ctx := contextpkg.Background()
+	for _, orderId := range []string{"a", "b"} {
+		require.NoError(t, orderCache.PutOrder(ctx, orderId, &structs.Order{OrderUid: orderId}))
+	}
+
+	keys, err := ListCachedOrderIds(ctx, "", 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, keys)
+
+	_, err = ListCachedOrderIds(ctx, "", maxCacheKeys+1)
+	assert.True(t, IsErrInvalidRequest(err))
+	_, err = ListCachedOrderIds(ctx, "[", 0)
+	assert.True(t, IsErrInvalidRequest(err))
+}
+
+// TestInvalidateCachedOrders tests that invalidated ids are read from the database again
+func TestInvalidateCachedOrders(t *testing.T) {
+	notFound.reset()
+	mockCache := new(MockCache)
+	mockCache.On("DeleteOrders", mock.Anything, []string{"a", "b"}).Return(1, nil)
+	cache.SetCache(mockCache)
+	notFound.add("b")
+
+	deleted, err := InvalidateCachedOrders(contextpkg.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.False(t, notFound.contains("b"))
+
+	_, err = InvalidateCachedOrders(contextpkg.Background(), nil)
+	assert.True(t, IsErrInvalidRequest(err))
+	mockCache.AssertExpectations(t)
+}
+
+func TestFlushCache(t *testing.T) {
+	notFound.reset()
+	mockCache := new(MockCache)
+	mockCache.On("Flush", mock.Anything).Return(nil).Once()
+	cache.SetCache(mockCache)
+	notFound.add("a")
+
+	require.NoError(t, FlushCache(contextpkg.Background()))
+	assert.False(t, notFound.contains("a"))
+	mockCache.AssertExpectations(t)
+}
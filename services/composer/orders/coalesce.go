@@ -0,0 +1,97 @@
+package orders
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"wb-L0/modules/config"
+)
+
+const (
+	// defaultNotFoundTtl is how long an order id found nowhere is answered as
+	// not found without asking the database again
+	defaultNotFoundTtl = 5 * time.Second
+	// maxNotFoundEntries bounds the memory a scan for unknown ids can take
+	maxNotFoundEntries = 100000
+)
+
+var (
+	// orderLoads lets concurrent cache misses of one order share a single
+	// database read
+	orderLoads singleflight.Group
+	notFound   = newNotFoundCache()
+)
+
+// notFoundCache remembers order ids the database did not have for a short
+// time. Storing an order forgets its id in this process; other replicas keep
+// answering not found until the entry expires.
+type notFoundCache struct {
+	mutex   sync.Mutex
+	entries map[string]time.Time
+}
+
+func newNotFoundCache() *notFoundCache {
+	return &notFoundCache{entries: make(map[string]time.Time)}
+}
+
+// notFoundTtl reads CACHE_NOT_FOUND_TTL_MS; a negative value disables the
+// cache
+func notFoundTtl() time.Duration {
+	conf := config.GetConfig()
+	if conf == nil || conf.CacheNotFoundTtlMs == 0 {
+		return defaultNotFoundTtl
+	}
+	if conf.CacheNotFoundTtlMs < 0 {
+		return 0
+	}
+	return time.Duration(conf.CacheNotFoundTtlMs) * time.Millisecond
+}
+
+func (c *notFoundCache) contains(orderId string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	expiresAt, ok := c.entries[orderId]
+	if !ok {
+		return false
+	}
+	if !time.Now().Before(expiresAt) {
+		delete(c.entries, orderId)
+		return false
+	}
+	return true
+}
+
+func (c *notFoundCache) add(orderId string) {
+	ttl := notFoundTtl()
+	if ttl == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	if len(c.entries) >= maxNotFoundEntries {
+		for id, expiresAt := range c.entries {
+			if !now.Before(expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+		if len(c.entries) >= maxNotFoundEntries {
+			return
+		}
+	}
+	c.entries[orderId] = now.Add(ttl)
+}
+
+func (c *notFoundCache) forget(orderId string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, orderId)
+}
+
+func (c *notFoundCache) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[string]time.Time)
+}
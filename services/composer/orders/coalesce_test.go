@@ -0,0 +1,153 @@
+package orders
+
+import (
+	contextpkg "context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/cache"
+	"wb-L0/services/database"
+	"wb-L0/structs"
+)
+
+// TestGetOrderByIdCoalescesMisses tests that concurrent misses share one database read
+func TestGetOrderByIdCoalescesMisses(t *testing.T) {
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+	expectedOrder := &structs.Order{OrderUid: "herd-test"}
+	const callers = 10
+
+	var misses atomic.Int32
+	release := make(chan struct{})
+	mockCache.On("GetOrder", mock.Anything, "herd-test").
+		Run(func(mock.Arguments) { misses.Add(1) }).
+		Return(nil, cache.ErrCacheMiss{Key: "herd-test"})
+	mockDB.On("GetOrderById", mock.Anything, "herd-test").
+		Run(func(mock.Arguments) { <-release }).
+		Return(expectedOrder, nil).Once()
+	mockCache.On("PutOrder", mock.Anything, "herd-test", expectedOrder).Return(nil).Once()
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	var wg sync.WaitGroup
+	results := make([]*structs.Order, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			order, err := GetOrderById(contextpkg.Background(), "herd-test")
+			assert.NoError(t, err)
+			results[i] = order
+		}()
+	}
+	require.Eventually(t, func() bool { return misses.Load() == callers }, time.Second, time.Millisecond)
+	// Let the callers reach the read in flight before it completes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, order := range results {
+		assert.Same(t, expectedOrder, order)
+	}
+	mockCache.AssertExpectations(t)
+	mockDB.AssertNumberOfCalls(t, "GetOrderById", 1)
+}
+
+// TestGetOrderByIdCallerCancellation tests that a caller stops waiting for a shared read on cancellation
+func TestGetOrderByIdCallerCancellation(t *testing.T) {
+	notFound.reset()
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+	release := make(chan struct{})
+
+	mockCache.On("GetOrder", mock.Anything, "slow-test").Return(nil, cache.ErrCacheMiss{Key: "slow-test"})
+	mockDB.On("GetOrderById", mock.Anything, "slow-test").
+		Run(func(mock.Arguments) { <-release }).
+		Return(nil, database.ErrOrderNotFound{Id: "slow-test"})
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	ctx, cancel := contextpkg.WithTimeout(contextpkg.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := GetOrderById(ctx, "slow-test")
+	assert.ErrorIs(t, err, contextpkg.DeadlineExceeded)
+
+	// The read carries on for later callers
+	close(release)
+	loaded := <-orderLoads.DoChan("slow-test", func() (interface{}, error) {
+		return nil, nil
+	})
+	assert.True(t, database.IsErrOrderNotFound(loaded.Err))
+}
+
+// TestGetOrderByIdCachesNotFound tests that an unknown id is answered without the database until forgotten
+func TestGetOrderByIdCachesNotFound(t *testing.T) {
+	notFound.reset()
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+	storedOrder := &structs.Order{OrderUid: "negative-test"}
+
+	mockCache.On("GetOrder", mock.Anything, "negative-test").Return(nil, cache.ErrCacheMiss{Key: "negative-test"})
+	mockDB.On("GetOrderById", mock.Anything, "negative-test").
+		Return(nil, database.ErrOrderNotFound{Id: "negative-test"}).Once()
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	for range 3 {
+		_, err := GetOrderById(contextpkg.Background(), "negative-test")
+		assert.True(t, database.IsErrOrderNotFound(err))
+	}
+	mockDB.AssertNumberOfCalls(t, "GetOrderById", 1)
+
+	// Storing the order forgets that it was not found
+	notFound.forget("negative-test")
+	mockDB.On("GetOrderById", mock.Anything, "negative-test").Return(storedOrder, nil).Once()
+	mockCache.On("PutOrder", mock.Anything, "negative-test", storedOrder).Return(nil)
+	order, err := GetOrderById(contextpkg.Background(), "negative-test")
+	require.NoError(t, err)
+	assert.Same(t, storedOrder, order)
+}
+
+// TestGetOrderByIdSkipsInvalidatedFill tests that an order invalidated during the shared read is not cached
+func TestGetOrderByIdSkipsInvalidatedFill(t *testing.T) {
+	notFound.reset()
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+	staleOrder := &structs.Order{OrderUid: "stale-test"}
+
+	mockCache.On("GetOrder", mock.Anything, "stale-test").Return(nil, cache.ErrCacheMiss{Key: "stale-test"})
+	mockDB.On("GetOrderById", mock.Anything, "stale-test").
+		Run(func(args mock.Arguments) {
+			_, ok := args.Get(0).(contextpkg.Context).Deadline()
+			assert.True(t, ok, "shared read has no deadline")
+			// The order changes while the read is in flight
+			cache.Invalidate("stale-test")
+		}).
+		Return(staleOrder, nil).Once()
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	order, err := GetOrderById(contextpkg.Background(), "stale-test")
+	require.NoError(t, err)
+	assert.Same(t, staleOrder, order)
+	mockCache.AssertNotCalled(t, "PutOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestNotFoundCacheExpires(t *testing.T) {
+	c := newNotFoundCache()
+	c.add("a")
+	assert.True(t, c.contains("a"))
+	c.entries["a"] = time.Now()
+	assert.False(t, c.contains("a"))
+	assert.Empty(t, c.entries)
+}
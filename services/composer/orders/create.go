@@ -0,0 +1,59 @@
+package orders
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"wb-L0/modules/monitoring"
+	"wb-L0/services/cache"
+	"wb-L0/services/database"
+	"wb-L0/services/validation"
+	"wb-L0/structs"
+)
+
+// CreateOrder validates and stores an order submitted outside the broker and
+// returns it as stored. The stored order may differ from the request (its
+// status is assigned by the service, an upsert keeps the stored one), so it is
+// read back from the database and cached instead of the request.
+func CreateOrder(ctx context.Context, order *structs.Order) (*structs.Order, error) {
+	ctx, span := monitoring.GetTracer().Start(ctx, "order.create",
+		trace.WithAttributes(
+			attribute.String("order_id", order.OrderUid),
+		),
+	)
+	defer span.End()
+
+	err := validation.ValidateOrder(order)
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.GetDatabase().InsertOrder(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	notFound.forget(order.OrderUid)
+	cache.Invalidate(order.OrderUid)
+
+	fill := cache.StartFill(cache.GetCache())
+	defer fill.Done()
+	stored, err := database.GetDatabase().GetOrderById(ctx, order.OrderUid)
+	if err != nil {
+		// The cached version may predate the insert
+		evictErr := cache.GetCache().DeleteOrder(ctx, order.OrderUid)
+		if evictErr != nil {
+			monitoring.GetLogger().Warn("Unable to evict cached order",
+				zap.String("order_id", order.OrderUid), zap.Error(evictErr))
+		}
+		return nil, err
+	}
+	err = fill.Put(ctx, order.OrderUid, stored)
+	if err != nil {
+		monitoring.GetLogger().Warn("Unable to cache order",
+			zap.String("order_id", order.OrderUid), zap.Error(err))
+	}
+	return stored, nil
+}
@@ -0,0 +1,111 @@
+package orders
+
+import (
+	contextpkg "context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/cache"
+	"wb-L0/services/database"
+	"wb-L0/services/orderstatus"
+	"wb-L0/services/validation"
+)
+
+// TestCreateOrderSuccess tests that a created order is stored, read back and cached as stored
+func TestCreateOrderSuccess(t *testing.T) {
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+	order := validOrder("create-test")
+	order.Status = orderstatus.Delivered
+	stored := validOrder("create-test")
+	stored.Status = orderstatus.Created
+
+	mockDB.On("InsertOrder", mock.Anything, order).Return(nil)
+	mockDB.On("GetOrderById", mock.Anything, "create-test").Return(stored, nil)
+	mockCache.On("PutOrder", mock.Anything, "create-test", stored).Return(nil)
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	created, err := CreateOrder(contextpkg.Background(), order)
+
+	require.NoError(t, err)
+	assert.Same(t, stored, created)
+	mockCache.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "DeleteOrder", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+}
+
+// TestCreateOrderInvalid tests that invalid orders never reach the database
+func TestCreateOrderInvalid(t *testing.T) {
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+	order := validOrder("")
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	_, err := CreateOrder(contextpkg.Background(), order)
+
+	assert.True(t, validation.IsErrValidation(err))
+	mockDB.AssertNotCalled(t, "InsertOrder")
+	mockCache.AssertNotCalled(t, "DeleteOrder", mock.Anything, mock.Anything)
+}
+
+// TestCreateOrderDuplicate tests that a duplicate order is reported and the cache left alone
+func TestCreateOrderDuplicate(t *testing.T) {
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+	order := validOrder("duplicate-test")
+
+	mockDB.On("InsertOrder", mock.Anything, order).Return(database.ErrOrderExists{Id: "duplicate-test"})
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	_, err := CreateOrder(contextpkg.Background(), order)
+
+	assert.True(t, database.IsErrOrderExists(err))
+	mockCache.AssertNotCalled(t, "DeleteOrder", mock.Anything, mock.Anything)
+	mockCache.AssertNotCalled(t, "PutOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCreateOrderCachePutError tests that a cache failure does not fail creation
+func TestCreateOrderCachePutError(t *testing.T) {
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+	order := validOrder("cache-fail-test")
+
+	mockDB.On("InsertOrder", mock.Anything, order).Return(nil)
+	mockDB.On("GetOrderById", mock.Anything, "cache-fail-test").Return(order, nil)
+	mockCache.On("PutOrder", mock.Anything, "cache-fail-test", order).Return(assert.AnError)
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	created, err := CreateOrder(contextpkg.Background(), order)
+	require.NoError(t, err)
+	assert.Same(t, order, created)
+}
+
+// TestCreateOrderReadBackError tests that the cached version is evicted when the stored order cannot be read
+func TestCreateOrderReadBackError(t *testing.T) {
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+	order := validOrder("read-fail-test")
+
+	mockDB.On("InsertOrder", mock.Anything, order).Return(nil)
+	mockDB.On("GetOrderById", mock.Anything, "read-fail-test").Return(nil, assert.AnError)
+	mockCache.On("DeleteOrder", mock.Anything, "read-fail-test").Return(nil)
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	_, err := CreateOrder(contextpkg.Background(), order)
+	assert.ErrorIs(t, err, assert.AnError)
+	mockCache.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "PutOrder", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,38 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ErrReplayUnsupported struct{}
+
+func IsErrReplayUnsupported(err error) bool {
+	return errors.As(err, new(ErrReplayUnsupported))
+}
+
+func (e ErrReplayUnsupported) Error() string {
+	return "current broker does not support offset replay"
+}
+
+type ErrInvalidRequest struct {
+	Err string
+}
+
+func IsErrInvalidRequest(err error) bool {
+	return errors.As(err, new(ErrInvalidRequest))
+}
+
+func (e ErrInvalidRequest) Error() string {
+	return fmt.Sprintf("invalid request: %s", e.Err)
+}
+
+type ErrPushUnsupported struct{}
+
+func IsErrPushUnsupported(err error) bool {
+	return errors.As(err, new(ErrPushUnsupported))
+}
+
+func (e ErrPushUnsupported) Error() string {
+	return "current broker does not accept pushed messages"
+}
@@ -0,0 +1,36 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+
+	"wb-L0/services/database"
+	"wb-L0/services/orderstatus"
+	"wb-L0/structs"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ListOrders returns one page of orders matching filter, newest first. Pass
+// the returned NextCursor back in filter.Cursor to get the following page.
+func ListOrders(ctx context.Context, filter *structs.OrderFilter) (*structs.OrderPage, error) {
+	if filter.Limit < 0 || filter.Limit > maxPageSize {
+		return nil, ErrInvalidRequest{Err: fmt.Sprintf("limit must be between 1 and %d", maxPageSize)}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+	if !filter.DateFrom.IsZero() && !filter.DateTo.IsZero() && filter.DateTo.Before(filter.DateFrom) {
+		return nil, ErrInvalidRequest{Err: "date_to must not be before date_from"}
+	}
+	if filter.NmId < 0 {
+		return nil, ErrInvalidRequest{Err: "nm_id must be positive"}
+	}
+	if filter.Status != "" && !orderstatus.IsKnown(filter.Status) {
+		return nil, ErrInvalidRequest{Err: fmt.Sprintf("unknown status: %s", filter.Status)}
+	}
+	return database.GetDatabase().ListOrders(ctx, filter)
+}
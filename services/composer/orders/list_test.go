@@ -0,0 +1,45 @@
+package orders
+
+import (
+	contextpkg "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/database"
+	"wb-L0/structs"
+)
+
+// TestListOrdersDefaultLimit tests that an unset limit falls back to the default page size
+func TestListOrdersDefaultLimit(t *testing.T) {
+	mockDB := new(MockDatabase)
+	expected := &structs.OrderPage{Orders: []*structs.Order{{OrderUid: "a"}}, NextCursor: "next"}
+	mockDB.On("ListOrders", mock.Anything, mock.MatchedBy(func(filter *structs.OrderFilter) bool {
+		return filter.Limit == defaultPageSize && filter.CustomerId == "customer"
+	})).Return(expected, nil)
+	database.SetDatabase(mockDB)
+
+	page, err := ListOrders(contextpkg.Background(), &structs.OrderFilter{CustomerId: "customer"})
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, page)
+	mockDB.AssertExpectations(t)
+}
+
+// TestListOrdersInvalidFilter tests filter validation
+func TestListOrdersInvalidFilter(t *testing.T) {
+	mockDB := new(MockDatabase)
+	database.SetDatabase(mockDB)
+
+	_, err := ListOrders(contextpkg.Background(), &structs.OrderFilter{Limit: maxPageSize + 1})
+	assert.True(t, IsErrInvalidRequest(err))
+
+	now := time.Now()
+	_, err = ListOrders(contextpkg.Background(), &structs.OrderFilter{DateFrom: now, DateTo: now.Add(-time.Hour)})
+	assert.True(t, IsErrInvalidRequest(err))
+
+	mockDB.AssertNotCalled(t, "ListOrders")
+}
@@ -2,6 +2,7 @@ package orders
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -15,6 +16,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// orderLoadTimeout bounds a shared database read, which no caller can cancel
+const orderLoadTimeout = 10 * time.Second
+
 func GetOrderById(ctx context.Context, orderId string) (*structs.Order, error) {
 	start := time.Now()
 	defer func() {
@@ -41,22 +45,139 @@ func GetOrderById(ctx context.Context, orderId string) (*structs.Order, error) {
 	}
 
 	monitoring.IncrementCacheMisses()
+	if notFound.contains(orderId) {
+		return nil, database.ErrOrderNotFound{Id: orderId}
+	}
 	monitoring.GetLogger().Info("Cache miss, looking in database",
 		zap.String("order_id", orderId))
 
-	order, err = database.GetDatabase().GetOrderById(ctx, orderId)
+	// Concurrent misses of the order wait for one database read, which
+	// outlives the caller that started it but not orderLoadTimeout
+	loadCtx := context.WithoutCancel(ctx)
+	result := orderLoads.DoChan(orderId, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(loadCtx, orderLoadTimeout)
+		defer cancel()
+		return loadOrder(ctx, orderId)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case loaded := <-result:
+		if loaded.Err != nil {
+			return nil, loaded.Err
+		}
+		if loaded.Shared {
+			monitoring.GetLogger().Info("Database read shared",
+				zap.String("order_id", orderId))
+		}
+		return loaded.Val.(*structs.Order), nil
+	}
+}
+
+// loadOrder reads an order from the database into the cache, unless it was
+// invalidated during the read, and remembers ids it does not have
+func loadOrder(ctx context.Context, orderId string) (*structs.Order, error) {
+	fill := cache.StartFill(cache.GetCache())
+	defer fill.Done()
+	order, err := database.GetDatabase().GetOrderById(ctx, orderId)
+	if database.IsErrOrderNotFound(err) {
+		notFound.add(orderId)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	err = cache.GetCache().PutOrder(ctx, orderId, order)
+	err = fill.Put(ctx, orderId, order)
 	if err != nil {
 		monitoring.GetLogger().Warn("Unable to cache order",
 			zap.String("order_id", orderId), zap.Error(err))
-	} else {
-		monitoring.GetLogger().Info("Cache updated",
-			zap.String("order_id", orderId))
 	}
-
 	return order, nil
 }
+
+// MaxLookupIds bounds the number of orders fetched by a single lookup
+const MaxLookupIds = 100
+
+// GetOrdersByIds fetches several orders at once: one multi-get against the
+// cache and one batched database read for the misses. Found orders keep the
+// order of orderIds; ids found nowhere are returned as missing.
+func GetOrdersByIds(ctx context.Context, orderIds []string) ([]*structs.Order, []string, error) {
+	start := time.Now()
+	defer func() {
+		monitoring.ObserveOrderRetrievalDuration(time.Since(start))
+		monitoring.IncrementOrderRetrieval()
+	}()
+
+	uniqueIds := make([]string, 0, len(orderIds))
+	seen := make(map[string]struct{}, len(orderIds))
+	for _, orderId := range orderIds {
+		if _, ok := seen[orderId]; ok {
+			continue
+		}
+		seen[orderId] = struct{}{}
+		uniqueIds = append(uniqueIds, orderId)
+	}
+	if len(uniqueIds) == 0 {
+		return nil, nil, ErrInvalidRequest{Err: "at least one order id is required"}
+	}
+	if len(uniqueIds) > MaxLookupIds {
+		return nil, nil, ErrInvalidRequest{Err: fmt.Sprintf("at most %d order ids can be looked up at once", MaxLookupIds)}
+	}
+
+	ctx, _ = monitoring.GetTracer().Start(ctx, "order.lookup",
+		trace.WithAttributes(
+			attribute.Int("order_count", len(uniqueIds)),
+		),
+	)
+
+	found, err := cache.GetCache().GetOrders(ctx, uniqueIds)
+	if err != nil {
+		return nil, nil, err
+	}
+	misses := make([]string, 0, len(uniqueIds)-len(found))
+	for _, orderId := range uniqueIds {
+		if _, ok := found[orderId]; ok {
+			monitoring.IncrementCacheHits()
+		} else {
+			monitoring.IncrementCacheMisses()
+			if !notFound.contains(orderId) {
+				misses = append(misses, orderId)
+			}
+		}
+	}
+	monitoring.GetLogger().Info("Batch cache lookup",
+		zap.Int("hits", len(found)), zap.Int("misses", len(misses)))
+
+	if len(misses) > 0 {
+		fill := cache.StartFill(cache.GetCache())
+		defer fill.Done()
+		stored, err := database.GetDatabase().GetOrdersByIds(ctx, misses)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, order := range stored {
+			found[order.OrderUid] = order
+			err = fill.Put(ctx, order.OrderUid, order)
+			if err != nil {
+				monitoring.GetLogger().Warn("Unable to cache order",
+					zap.String("order_id", order.OrderUid), zap.Error(err))
+			}
+		}
+		for _, orderId := range misses {
+			if _, ok := found[orderId]; !ok {
+				notFound.add(orderId)
+			}
+		}
+	}
+
+	orders := make([]*structs.Order, 0, len(found))
+	missing := make([]string, 0)
+	for _, orderId := range uniqueIds {
+		if order, ok := found[orderId]; ok {
+			orders = append(orders, order)
+		} else {
+			missing = append(missing, orderId)
+		}
+	}
+	return orders, missing, nil
+}
@@ -2,8 +2,10 @@ package orders
 
 import (
 	contextpkg "context"
+	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -28,11 +30,50 @@ func (m *MockCache) GetOrder(ctx contextpkg.Context, orderId string) (*structs.O
 	return args.Get(0).(*structs.Order), args.Error(1)
 }
 
+func (m *MockCache) GetOrders(ctx contextpkg.Context, orderIds []string) (map[string]*structs.Order, error) {
+	args := m.Called(ctx, orderIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*structs.Order), args.Error(1)
+}
+
 func (m *MockCache) PutOrder(ctx contextpkg.Context, orderId string, order *structs.Order) error {
 	args := m.Called(ctx, orderId, order)
 	return args.Error(0)
 }
 
+func (m *MockCache) DeleteOrder(ctx contextpkg.Context, orderId string) error {
+	args := m.Called(ctx, orderId)
+	return args.Error(0)
+}
+
+func (m *MockCache) DeleteOrders(ctx contextpkg.Context, orderIds []string) (int, error) {
+	args := m.Called(ctx, orderIds)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCache) Flush(ctx contextpkg.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockCache) Stats(ctx contextpkg.Context) (*structs.CacheStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*structs.CacheStats), args.Error(1)
+}
+
+func (m *MockCache) Keys(ctx contextpkg.Context, pattern string, limit int) ([]string, error) {
+	args := m.Called(ctx, pattern, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *MockCache) HealthCheck(ctx contextpkg.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -51,11 +92,88 @@ func (m *MockDatabase) GetOrderById(ctx contextpkg.Context, orderId string) (*st
 	return args.Get(0).(*structs.Order), args.Error(1)
 }
 
+func (m *MockDatabase) GetOrdersByIds(ctx contextpkg.Context, orderIds []string) ([]*structs.Order, error) {
+	args := m.Called(ctx, orderIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*structs.Order), args.Error(1)
+}
+
+func (m *MockDatabase) FindOrderIdsByTrackNumber(ctx contextpkg.Context, trackNumber string) ([]string, error) {
+	args := m.Called(ctx, trackNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockDatabase) FindOrderIdsByTransaction(ctx contextpkg.Context, transaction string) ([]string, error) {
+	args := m.Called(ctx, transaction)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockDatabase) FindOrderIdsByRequestId(ctx contextpkg.Context, requestId string) ([]string, error) {
+	args := m.Called(ctx, requestId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockDatabase) ListOrders(ctx contextpkg.Context, filter *structs.OrderFilter) (*structs.OrderPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*structs.OrderPage), args.Error(1)
+}
+
 func (m *MockDatabase) InsertOrder(ctx contextpkg.Context, order *structs.Order) error {
 	args := m.Called(ctx, order)
 	return args.Error(0)
 }
 
+// InsertOrders reports every order as stored unless the results are given
+func (m *MockDatabase) InsertOrders(ctx contextpkg.Context, orders []*structs.Order) ([]error, error) {
+	args := m.Called(ctx, orders)
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+	results, _ := args.Get(0).([]error)
+	if results == nil {
+		results = make([]error, len(orders))
+	}
+	return results, nil
+}
+
+func (m *MockDatabase) UpdateOrderStatus(ctx contextpkg.Context, oid string, change *structs.OrderStatusChange) error {
+	args := m.Called(ctx, oid, change)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) PublishOutbox(ctx contextpkg.Context, limit int,
+	publish func(contextpkg.Context, []*structs.OrderEvent) error) (int, error) {
+	args := m.Called(ctx, limit, publish)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDatabase) PruneOutbox(ctx contextpkg.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDatabase) GetOrderStatusHistory(ctx contextpkg.Context, oid string) (*structs.OrderStatusHistory, error) {
+	args := m.Called(ctx, oid)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*structs.OrderStatusHistory), args.Error(1)
+}
+
 func (m *MockDatabase) HealthCheck(ctx contextpkg.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -365,6 +483,95 @@ func TestGetOrderByIdEmptyOrderId(t *testing.T) {
 	mockDB.AssertExpectations(t)
 }
 
+// TestGetOrdersByIdsMixed tests batch lookup served partly by cache and partly by database
+func TestGetOrdersByIdsMixed(t *testing.T) {
+	notFound.reset()
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+
+	cached := &structs.Order{OrderUid: "cached"}
+	stored := &structs.Order{OrderUid: "stored"}
+
+	mockCache.On("GetOrders", mock.Anything, []string{"stored", "cached", "absent"}).
+		Return(map[string]*structs.Order{"cached": cached}, nil)
+	mockDB.On("GetOrdersByIds", mock.Anything, []string{"stored", "absent"}).
+		Return([]*structs.Order{stored}, nil)
+	mockCache.On("PutOrder", mock.Anything, "stored", stored).Return(nil)
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	found, missing, err := GetOrdersByIds(contextpkg.Background(), []string{"stored", "cached", "absent", "cached"})
+
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "stored", found[0].OrderUid)
+	assert.Equal(t, "cached", found[1].OrderUid)
+	assert.Equal(t, []string{"absent"}, missing)
+	mockCache.AssertExpectations(t)
+	mockDB.AssertExpectations(t)
+}
+
+// TestGetOrdersByIdsSkipsInvalidatedFill tests that orders invalidated during the batched read are not cached
+func TestGetOrdersByIdsSkipsInvalidatedFill(t *testing.T) {
+	notFound.reset()
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+
+	stale := &structs.Order{OrderUid: "stale"}
+	fresh := &structs.Order{OrderUid: "fresh"}
+
+	mockCache.On("GetOrders", mock.Anything, []string{"stale", "fresh"}).
+		Return(map[string]*structs.Order{}, nil)
+	mockDB.On("GetOrdersByIds", mock.Anything, []string{"stale", "fresh"}).
+		Run(func(mock.Arguments) { cache.Invalidate("stale") }).
+		Return([]*structs.Order{stale, fresh}, nil)
+	mockCache.On("PutOrder", mock.Anything, "fresh", fresh).Return(nil)
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	found, missing, err := GetOrdersByIds(contextpkg.Background(), []string{"stale", "fresh"})
+
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Empty(t, missing)
+	mockCache.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "PutOrder", mock.Anything, "stale", mock.Anything)
+}
+
+// TestGetOrdersByIdsAllCached tests that the database is skipped when every order is cached
+func TestGetOrdersByIdsAllCached(t *testing.T) {
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+
+	mockCache.On("GetOrders", mock.Anything, []string{"a"}).
+		Return(map[string]*structs.Order{"a": {OrderUid: "a"}}, nil)
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	found, missing, err := GetOrdersByIds(contextpkg.Background(), []string{"a"})
+
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Empty(t, missing)
+	mockDB.AssertNotCalled(t, "GetOrdersByIds")
+}
+
+// TestGetOrdersByIdsLimits tests request size validation
+func TestGetOrdersByIdsLimits(t *testing.T) {
+	_, _, err := GetOrdersByIds(contextpkg.Background(), nil)
+	assert.True(t, IsErrInvalidRequest(err))
+
+	tooMany := make([]string, MaxLookupIds+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("order-%d", i)
+	}
+	_, _, err = GetOrdersByIds(contextpkg.Background(), tooMany)
+	assert.True(t, IsErrInvalidRequest(err))
+}
+
 func TestMain(m *testing.M) {
 	// Reset monitoring state before running tests
 	monitoring.ResetForTesting()
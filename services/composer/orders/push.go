@@ -0,0 +1,33 @@
+package orders
+
+import (
+	"context"
+
+	"wb-L0/services/broker"
+	"wb-L0/services/codec"
+	"wb-L0/structs"
+)
+
+// PushMessage publishes a raw message to the configured broker. It is
+// consumed like any other message; an empty topic means the order topic.
+func PushMessage(ctx context.Context, topic, key, contentType string, payload []byte) (*structs.PushedMessage, error) {
+	pusher, ok := broker.GetBroker().(broker.Pusher)
+	if !ok {
+		return nil, ErrPushUnsupported{}
+	}
+	message := broker.Message{
+		Value: payload,
+		Topic: topic,
+	}
+	if key != "" {
+		message.Key = []byte(key)
+	}
+	if contentType != "" {
+		message.Headers = map[string]string{codec.ContentTypeHeader: contentType}
+	}
+	pushed, err := pusher.Push(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return &structs.PushedMessage{Topic: pushed.Topic, Partition: pushed.Partition, Offset: pushed.Offset}, nil
+}
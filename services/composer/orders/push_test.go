@@ -0,0 +1,57 @@
+package orders
+
+import (
+	contextpkg "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/broker"
+	"wb-L0/services/cache"
+	"wb-L0/services/codec"
+	"wb-L0/services/database"
+	"wb-L0/services/deadletter"
+	"wb-L0/structs"
+)
+
+// TestPushMessageUnsupported tests that pushing requires a broker accepting pushed messages
+func TestPushMessageUnsupported(t *testing.T) {
+	broker.SetBroker(newFakeBroker())
+
+	_, err := PushMessage(contextpkg.Background(), "", "", "", []byte("{}"))
+
+	assert.True(t, IsErrPushUnsupported(err))
+}
+
+// TestPushMessageConsumed tests that a pushed order runs through the ingestion pipeline
+func TestPushMessageConsumed(t *testing.T) {
+	ctx, cancel := contextpkg.WithCancel(contextpkg.Background())
+	defer cancel()
+	memoryBroker := broker.NewMemoryBroker(10)
+	broker.SetBroker(memoryBroker)
+	deadletter.SetQueue(new(recordingQueue))
+	stored := make(chan string, 1)
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored <- args.Get(1).(*structs.Order).OrderUid
+	}).Return(nil)
+	database.SetDatabase(mockDB)
+	cache.SetCache(invalidatingCache())
+	payload, err := codec.ProtobufCodec{}.Encode(validOrder("pushed"))
+	require.NoError(t, err)
+
+	pushed, err := PushMessage(ctx, "", "pushed", codec.ContentTypeProtobuf, payload)
+	require.NoError(t, err)
+	go consumeConcurrently(ctx, memoryBroker.StartConsuming(ctx), 2, orderByKey, routeMessage)
+
+	assert.Equal(t, "orders", pushed.Topic)
+	select {
+	case orderUid := <-stored:
+		assert.Equal(t, "pushed", orderUid)
+	case <-time.After(time.Second):
+		t.Fatal("pushed order was not stored")
+	}
+}
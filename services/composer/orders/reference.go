@@ -0,0 +1,56 @@
+package orders
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"wb-L0/modules/monitoring"
+	"wb-L0/services/database"
+	"wb-L0/structs"
+)
+
+// GetOrdersByTrackNumber returns orders whose own or item track number matches
+func GetOrdersByTrackNumber(ctx context.Context, trackNumber string) ([]*structs.Order, error) {
+	return getOrdersByReference(ctx, trackNumber, database.GetDatabase().FindOrderIdsByTrackNumber)
+}
+
+// GetOrdersByTransaction returns orders paid with the given transaction
+func GetOrdersByTransaction(ctx context.Context, transaction string) ([]*structs.Order, error) {
+	return getOrdersByReference(ctx, transaction, database.GetDatabase().FindOrderIdsByTransaction)
+}
+
+// GetOrdersByRequestId returns orders whose payment carries the given request id
+func GetOrdersByRequestId(ctx context.Context, requestId string) ([]*structs.Order, error) {
+	return getOrdersByReference(ctx, requestId, database.GetDatabase().FindOrderIdsByRequestId)
+}
+
+// getOrdersByReference resolves an external reference to order uids with a
+// single indexed query and then serves the orders through the cache like
+// GetOrderById, so only uncached orders are loaded from the database
+func getOrdersByReference(ctx context.Context, reference string,
+	resolve func(context.Context, string) ([]string, error)) ([]*structs.Order, error) {
+	if reference == "" {
+		return nil, ErrInvalidRequest{Err: "reference is required"}
+	}
+	orderIds, err := resolve(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	if len(orderIds) == 0 {
+		return nil, database.ErrOrderNotFound{Id: reference}
+	}
+	if len(orderIds) > MaxLookupIds {
+		monitoring.GetLogger().Warn("Reference matches too many orders, truncating",
+			zap.String("reference", reference), zap.Int("matches", len(orderIds)))
+		orderIds = orderIds[:MaxLookupIds]
+	}
+	found, _, err := GetOrdersByIds(ctx, orderIds)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, database.ErrOrderNotFound{Id: reference}
+	}
+	return found, nil
+}
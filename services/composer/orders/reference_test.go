@@ -0,0 +1,62 @@
+package orders
+
+import (
+	contextpkg "context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/cache"
+	"wb-L0/services/database"
+	"wb-L0/structs"
+)
+
+// TestGetOrdersByTransactionCached tests that a resolved order is served from cache
+func TestGetOrdersByTransactionCached(t *testing.T) {
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+	order := &structs.Order{OrderUid: "order-1"}
+
+	mockDB.On("FindOrderIdsByTransaction", mock.Anything, "tx-1").Return([]string{"order-1"}, nil)
+	mockCache.On("GetOrders", mock.Anything, []string{"order-1"}).
+		Return(map[string]*structs.Order{"order-1": order}, nil)
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	found, err := GetOrdersByTransaction(contextpkg.Background(), "tx-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, []*structs.Order{order}, found)
+	mockDB.AssertNotCalled(t, "GetOrdersByIds")
+	mockDB.AssertExpectations(t)
+}
+
+// TestGetOrdersByTrackNumberNotFound tests that an unknown reference is reported as not found
+func TestGetOrdersByTrackNumberNotFound(t *testing.T) {
+	mockCache := new(MockCache)
+	mockDB := new(MockDatabase)
+
+	mockDB.On("FindOrderIdsByTrackNumber", mock.Anything, "unknown").Return([]string{}, nil)
+
+	cache.SetCache(mockCache)
+	database.SetDatabase(mockDB)
+
+	_, err := GetOrdersByTrackNumber(contextpkg.Background(), "unknown")
+
+	assert.True(t, database.IsErrOrderNotFound(err))
+	mockCache.AssertNotCalled(t, "GetOrders")
+}
+
+// TestGetOrdersByRequestIdEmpty tests that an empty reference never reaches the database
+func TestGetOrdersByRequestIdEmpty(t *testing.T) {
+	mockDB := new(MockDatabase)
+	database.SetDatabase(mockDB)
+
+	_, err := GetOrdersByRequestId(contextpkg.Background(), "")
+
+	assert.True(t, IsErrInvalidRequest(err))
+	mockDB.AssertNotCalled(t, "FindOrderIdsByRequestId")
+}
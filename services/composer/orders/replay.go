@@ -0,0 +1,132 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"wb-L0/services/broker"
+	"wb-L0/services/codec"
+	"wb-L0/services/deadletter"
+	"wb-L0/structs"
+)
+
+const (
+	// maxReplayBatch bounds how many messages a single replay call processes
+	maxReplayBatch = 1000
+
+	outcomeNotFound = "not_found"
+)
+
+// ListDeadLetters returns pending dead letters, oldest first
+func ListDeadLetters(ctx context.Context, limit int) ([]*structs.DeadLetter, error) {
+	if limit <= 0 || limit > maxReplayBatch {
+		limit = maxReplayBatch
+	}
+	return deadletter.GetQueue().List(ctx, limit)
+}
+
+// ReplayDeadLetters feeds dead letters back through the ingestion pipeline.
+// A letter is removed from the queue once it is handled; if it fails again it
+// is dead-lettered anew with the fresh reason. When ids is empty the oldest
+// letters up to limit are replayed.
+func ReplayDeadLetters(ctx context.Context, ids []string, limit int) (*structs.ReplayReport, error) {
+	if len(ids) > maxReplayBatch {
+		return nil, ErrInvalidRequest{Err: fmt.Sprintf("at most %d ids can be replayed at once", maxReplayBatch)}
+	}
+	queue := deadletter.GetQueue()
+	listLimit := limit
+	if len(ids) > 0 {
+		listLimit = 0
+	} else if listLimit <= 0 || listLimit > maxReplayBatch {
+		listLimit = maxReplayBatch
+	}
+	letters, err := queue.List(ctx, listLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &structs.ReplayReport{Results: make([]structs.ReplayResult, 0)}
+	if len(ids) > 0 {
+		byId := make(map[string]*structs.DeadLetter, len(letters))
+		for _, letter := range letters {
+			byId[letter.Id] = letter
+		}
+		letters = letters[:0]
+		for _, id := range ids {
+			letter, exists := byId[id]
+			if !exists {
+				report.Results = append(report.Results, structs.ReplayResult{Id: id, Outcome: outcomeNotFound})
+				continue
+			}
+			letters = append(letters, letter)
+		}
+	}
+
+	for _, letter := range letters {
+		letterId := letter.Id
+		message := broker.Message{
+			Value:     letter.Payload,
+			Headers:   letterHeaders(letter),
+			Topic:     letter.Topic,
+			Partition: letter.Partition,
+			Offset:    letter.Offset,
+			Ack: func() error {
+				return queue.Remove(ctx, letterId)
+			},
+			Nack: func() error {
+				return nil
+			},
+		}
+		outcome := dispatch(ctx, message)
+		report.Replayed++
+		report.Results = append(report.Results, structs.ReplayResult{
+			Id:      letter.Id,
+			Offset:  letter.Offset,
+			Outcome: outcome,
+		})
+	}
+	log.Printf("Replayed %d dead letters", report.Replayed)
+	return report, nil
+}
+
+// ReplayOffsets re-reads an inclusive offset range of a partition from the
+// broker and feeds it through the ingestion pipeline without moving the
+// consumer group offsets.
+func ReplayOffsets(ctx context.Context, request *structs.OffsetReplayRequest) (*structs.ReplayReport, error) {
+	if request.FromOffset < 0 || request.ToOffset < request.FromOffset {
+		return nil, ErrInvalidRequest{Err: "offset range must satisfy 0 <= from_offset <= to_offset"}
+	}
+	if request.ToOffset-request.FromOffset >= maxReplayBatch {
+		return nil, ErrInvalidRequest{Err: fmt.Sprintf("at most %d offsets can be replayed at once", maxReplayBatch)}
+	}
+	replayer, ok := broker.GetBroker().(broker.Replayer)
+	if !ok {
+		return nil, ErrReplayUnsupported{}
+	}
+	messages, err := replayer.ReadRange(ctx, request.Topic, request.Partition, request.FromOffset, request.ToOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &structs.ReplayReport{Results: make([]structs.ReplayResult, 0, len(messages))}
+	for _, message := range messages {
+		outcome := dispatch(ctx, message)
+		report.Replayed++
+		report.Results = append(report.Results, structs.ReplayResult{
+			Offset:  message.Offset,
+			Outcome: outcome,
+		})
+	}
+	log.Printf("Replayed %d messages of partition %d from offset %d",
+		report.Replayed, request.Partition, request.FromOffset)
+	return report, nil
+}
+
+// letterHeaders restores the headers the dead-lettered message was decoded with
+func letterHeaders(letter *structs.DeadLetter) map[string]string {
+	if letter.ContentType == "" {
+		return nil
+	}
+	return map[string]string{codec.ContentTypeHeader: letter.ContentType}
+}
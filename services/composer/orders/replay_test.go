@@ -0,0 +1,117 @@
+package orders
+
+import (
+	contextpkg "context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/broker"
+	"wb-L0/services/cache"
+	"wb-L0/services/database"
+	"wb-L0/services/deadletter"
+	"wb-L0/structs"
+)
+
+// replayBroker is a broker that serves a fixed set of historic messages
+type replayBroker struct {
+	messages []broker.Message
+}
+
+func (b *replayBroker) StartConsuming(_ contextpkg.Context) chan broker.Message {
+	return nil
+}
+
+func (b *replayBroker) HealthCheck(_ contextpkg.Context) error {
+	return nil
+}
+
+func (b *replayBroker) ReadRange(_ contextpkg.Context, _ string, _ int, from, to int64) ([]broker.Message, error) {
+	result := make([]broker.Message, 0)
+	for _, message := range b.messages {
+		if message.Offset >= from && message.Offset <= to {
+			result = append(result, message)
+		}
+	}
+	return result, nil
+}
+
+// TestReplayDeadLettersStored tests that successfully replayed letters leave the queue
+func TestReplayDeadLettersStored(t *testing.T) {
+	queue, err := deadletter.NewMemoryQueue("")
+	require.NoError(t, err)
+	deadletter.SetQueue(queue)
+	require.NoError(t, queue.Publish(contextpkg.Background(), &structs.DeadLetter{
+		Id: "letter-1", Payload: validOrderPayload("fixed"), Offset: 3,
+	}))
+
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrder", mock.Anything, mock.Anything).Return(nil)
+	database.SetDatabase(mockDB)
+	cache.SetCache(invalidatingCache())
+
+	report, err := ReplayDeadLetters(contextpkg.Background(), nil, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Replayed)
+	assert.Equal(t, outcomeStored, report.Results[0].Outcome)
+	pending, err := queue.List(contextpkg.Background(), 0)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	mockDB.AssertExpectations(t)
+}
+
+// TestReplayDeadLettersFailingAgain tests that a letter failing again is replaced by a fresh one
+func TestReplayDeadLettersFailingAgain(t *testing.T) {
+	queue, err := deadletter.NewMemoryQueue("")
+	require.NoError(t, err)
+	deadletter.SetQueue(queue)
+	require.NoError(t, queue.Publish(contextpkg.Background(), &structs.DeadLetter{
+		Id: "letter-1", Payload: []byte("{still broken"),
+	}))
+	database.SetDatabase(new(MockDatabase))
+
+	report, err := ReplayDeadLetters(contextpkg.Background(), []string{"letter-1", "missing"}, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Replayed)
+	require.Len(t, report.Results, 2)
+	assert.Equal(t, outcomeNotFound, report.Results[0].Outcome)
+	assert.Equal(t, outcomeDeadLettered, report.Results[1].Outcome)
+	pending, err := queue.List(contextpkg.Background(), 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.NotEqual(t, "letter-1", pending[0].Id)
+}
+
+// TestReplayOffsets tests that only the requested offset range is replayed
+func TestReplayOffsets(t *testing.T) {
+	broker.SetBroker(&replayBroker{messages: []broker.Message{
+		{Value: validOrderPayload("a"), Offset: 1, Ack: noopAck, Nack: noopAck},
+		{Value: validOrderPayload("b"), Offset: 2, Ack: noopAck, Nack: noopAck},
+		{Value: validOrderPayload("c"), Offset: 3, Ack: noopAck, Nack: noopAck},
+	}})
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrder", mock.Anything, mock.Anything).Return(nil)
+	database.SetDatabase(mockDB)
+	cache.SetCache(invalidatingCache())
+
+	report, err := ReplayOffsets(contextpkg.Background(), &structs.OffsetReplayRequest{FromOffset: 2, ToOffset: 3})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.Replayed)
+	assert.Equal(t, int64(2), report.Results[0].Offset)
+	mockDB.AssertNumberOfCalls(t, "InsertOrder", 2)
+}
+
+// TestReplayOffsetsInvalidRange tests range validation
+func TestReplayOffsetsInvalidRange(t *testing.T) {
+	_, err := ReplayOffsets(contextpkg.Background(), &structs.OffsetReplayRequest{FromOffset: 5, ToOffset: 1})
+	assert.True(t, IsErrInvalidRequest(err))
+}
+
+func noopAck() error {
+	return nil
+}
@@ -0,0 +1,127 @@
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"wb-L0/modules/monitoring"
+	"wb-L0/services/broker"
+	"wb-L0/services/cache"
+	"wb-L0/services/database"
+	"wb-L0/services/orderstatus"
+	"wb-L0/services/validation"
+	"wb-L0/structs"
+)
+
+// UpdateOrderStatus applies a status transition and returns the resulting
+// status history. Requesting the current status is not an error.
+func UpdateOrderStatus(ctx context.Context, orderId string, change *structs.OrderStatusChange) (*structs.OrderStatusHistory, error) {
+	ctx, span := monitoring.GetTracer().Start(ctx, "order.status.update",
+		trace.WithAttributes(
+			attribute.String("order_id", orderId),
+			attribute.String("status", change.Status),
+		),
+	)
+	defer span.End()
+
+	err := changeOrderStatus(ctx, orderId, change)
+	if err != nil && !database.IsErrOrderUnchanged(err) {
+		return nil, err
+	}
+	return database.GetDatabase().GetOrderStatusHistory(ctx, orderId)
+}
+
+// GetOrderStatusHistory returns the current status and the transitions that
+// led to it
+func GetOrderStatusHistory(ctx context.Context, orderId string) (*structs.OrderStatusHistory, error) {
+	ctx, span := monitoring.GetTracer().Start(ctx, "order.status.history",
+		trace.WithAttributes(
+			attribute.String("order_id", orderId),
+		),
+	)
+	defer span.End()
+
+	return database.GetDatabase().GetOrderStatusHistory(ctx, orderId)
+}
+
+// changeOrderStatus validates and stores the transition, then evicts the
+// cached order that still carries the previous status
+func changeOrderStatus(ctx context.Context, orderId string, change *structs.OrderStatusChange) error {
+	err := orderstatus.ValidateChange(change)
+	if err != nil {
+		return err
+	}
+	err = database.GetDatabase().UpdateOrderStatus(ctx, orderId, change)
+	if err != nil {
+		return err
+	}
+	cache.Invalidate(orderId)
+	err = cache.GetCache().DeleteOrder(ctx, orderId)
+	if err != nil {
+		monitoring.GetLogger().Warn("Unable to invalidate cached order",
+			zap.String("order_id", orderId), zap.Error(err))
+	}
+	return nil
+}
+
+// handleStatusMessage applies a status-change message. Changes that can never
+// apply (unknown order, invalid transition) are dead-lettered so they can be
+// replayed once the order arrives or the data is fixed.
+func handleStatusMessage(ctx context.Context, message broker.Message) string {
+	change, err := decodeStatusChange(message)
+	return handleStatusChange(ctx, message, change, err)
+}
+
+// decodeStatusChange reads the status change of a message
+func decodeStatusChange(message broker.Message) (*structs.OrderStatusChange, error) {
+	change := new(structs.OrderStatusChange)
+	err := json.Unmarshal(message.Value, change)
+	if err != nil {
+		return nil, err
+	}
+	if change.OrderUid == "" {
+		return nil, ErrInvalidRequest{Err: "order_uid is required"}
+	}
+	return change, nil
+}
+
+// handleStatusChange applies the change decoded from the message, or
+// dead-letters the message if decoding failed with err
+func handleStatusChange(ctx context.Context, message broker.Message, change *structs.OrderStatusChange, err error) string {
+	if err != nil {
+		log.Printf("Status message rejected: %s. Message dead-lettered!", err.Error())
+		return deadLetter(ctx, message, err)
+	}
+	change.Source = orderstatus.SourceKafka
+	updateCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	err = changeOrderStatus(updateCtx, change.OrderUid, change)
+	cancel()
+	if database.IsErrOrderUnchanged(err) {
+		monitoring.IncrementKafkaMessagesProcessed(message.Topic, outcomeUnchanged)
+		err = message.Ack()
+		if err != nil {
+			log.Printf("Ack failed: %s", err.Error())
+		}
+		return outcomeUnchanged
+	}
+	if err != nil {
+		if validation.IsErrValidation(err) || database.IsErrOrderNotFound(err) || orderstatus.IsErrInvalidTransition(err) {
+			log.Printf("Status change of order %s failed: %s. Message dead-lettered!", change.OrderUid, err.Error())
+			return deadLetter(ctx, message, err)
+		}
+		log.Printf("Status change of order %s failed: %s. Retrying!", change.OrderUid, err.Error())
+		return retry(message)
+	}
+	monitoring.IncrementKafkaMessagesProcessed(message.Topic, outcomeStored)
+	err = message.Ack()
+	if err != nil {
+		log.Printf("Ack failed: %s", err.Error())
+	}
+	return outcomeStored
+}
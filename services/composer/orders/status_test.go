@@ -0,0 +1,121 @@
+package orders
+
+import (
+	contextpkg "context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/cache"
+	"wb-L0/services/database"
+	"wb-L0/services/deadletter"
+	"wb-L0/services/orderstatus"
+	"wb-L0/services/validation"
+	"wb-L0/structs"
+)
+
+// TestUpdateOrderStatusSuccess tests that a transition is stored, the cache evicted and the history returned
+func TestUpdateOrderStatusSuccess(t *testing.T) {
+	change := &structs.OrderStatusChange{Status: orderstatus.Paid, Source: orderstatus.SourceApi}
+	history := &structs.OrderStatusHistory{OrderUid: "status-test", Status: orderstatus.Paid}
+	mockDB := new(MockDatabase)
+	mockDB.On("UpdateOrderStatus", mock.Anything, "status-test", change).Return(nil)
+	mockDB.On("GetOrderStatusHistory", mock.Anything, "status-test").Return(history, nil)
+	database.SetDatabase(mockDB)
+	mockCache := invalidatingCache()
+	cache.SetCache(mockCache)
+
+	result, err := UpdateOrderStatus(contextpkg.Background(), "status-test", change)
+
+	require.NoError(t, err)
+	assert.Equal(t, history, result)
+	mockDB.AssertExpectations(t)
+	mockCache.AssertCalled(t, "DeleteOrder", mock.Anything, "status-test")
+}
+
+// TestUpdateOrderStatusUnchanged tests that requesting the current status returns the history
+func TestUpdateOrderStatusUnchanged(t *testing.T) {
+	change := &structs.OrderStatusChange{Status: orderstatus.Paid}
+	history := &structs.OrderStatusHistory{OrderUid: "status-test", Status: orderstatus.Paid}
+	mockDB := new(MockDatabase)
+	mockDB.On("UpdateOrderStatus", mock.Anything, "status-test", change).Return(database.ErrOrderUnchanged{Id: "status-test"})
+	mockDB.On("GetOrderStatusHistory", mock.Anything, "status-test").Return(history, nil)
+	database.SetDatabase(mockDB)
+	mockCache := invalidatingCache()
+	cache.SetCache(mockCache)
+
+	result, err := UpdateOrderStatus(contextpkg.Background(), "status-test", change)
+
+	require.NoError(t, err)
+	assert.Equal(t, history, result)
+	mockCache.AssertNotCalled(t, "DeleteOrder", mock.Anything, mock.Anything)
+}
+
+// TestUpdateOrderStatusUnknown tests that unknown statuses never reach the database
+func TestUpdateOrderStatusUnknown(t *testing.T) {
+	mockDB := new(MockDatabase)
+	database.SetDatabase(mockDB)
+
+	_, err := UpdateOrderStatus(contextpkg.Background(), "status-test", &structs.OrderStatusChange{Status: "lost"})
+
+	assert.True(t, validation.IsErrValidation(err))
+	mockDB.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestHandleStatusMessageSuccess tests that status messages are applied with the kafka source and acked
+func TestHandleStatusMessageSuccess(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	mockDB.On("UpdateOrderStatus", mock.Anything, "status-test", mock.MatchedBy(func(change *structs.OrderStatusChange) bool {
+		return change.Status == orderstatus.Shipped && change.Source == orderstatus.SourceKafka
+	})).Return(nil)
+	database.SetDatabase(mockDB)
+	cache.SetCache(invalidatingCache())
+	recorder := new(ackRecorder)
+
+	outcome := handleStatusMessage(contextpkg.Background(),
+		recorder.message([]byte(`{"order_uid":"status-test","status":"shipped"}`)))
+
+	assert.Equal(t, outcomeStored, outcome)
+	assert.Empty(t, queue.letters)
+	assert.Equal(t, 1, recorder.acks)
+	mockDB.AssertExpectations(t)
+}
+
+// TestHandleStatusMessageInvalidTransition tests that impossible transitions are dead-lettered
+func TestHandleStatusMessageInvalidTransition(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	mockDB.On("UpdateOrderStatus", mock.Anything, "status-test", mock.Anything).
+		Return(orderstatus.ErrInvalidTransition{From: orderstatus.Cancelled, To: orderstatus.Paid})
+	database.SetDatabase(mockDB)
+	recorder := new(ackRecorder)
+
+	outcome := handleStatusMessage(contextpkg.Background(),
+		recorder.message([]byte(`{"order_uid":"status-test","status":"paid"}`)))
+
+	assert.Equal(t, outcomeDeadLettered, outcome)
+	require.Len(t, queue.letters, 1)
+	assert.Contains(t, queue.letters[0].Reason, "cancelled")
+	assert.Equal(t, 1, recorder.acks)
+}
+
+// TestHandleStatusMessageRetry tests that transient database failures are retried
+func TestHandleStatusMessageRetry(t *testing.T) {
+	deadletter.SetQueue(new(recordingQueue))
+	mockDB := new(MockDatabase)
+	mockDB.On("UpdateOrderStatus", mock.Anything, "status-test", mock.Anything).Return(assert.AnError)
+	database.SetDatabase(mockDB)
+	recorder := new(ackRecorder)
+
+	outcome := handleStatusMessage(contextpkg.Background(),
+		recorder.message([]byte(`{"order_uid":"status-test","status":"paid"}`)))
+
+	assert.Equal(t, outcomeRetry, outcome)
+	assert.Equal(t, 0, recorder.acks)
+	assert.Equal(t, 1, recorder.nacks)
+}
@@ -2,62 +2,208 @@ package orders
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"time"
 
+	"github.com/google/uuid"
+
+	"wb-L0/modules/config"
 	"wb-L0/modules/graceful"
+	"wb-L0/modules/monitoring"
 	"wb-L0/services/broker"
+	"wb-L0/services/cache"
+	"wb-L0/services/codec"
 	"wb-L0/services/database"
+	"wb-L0/services/deadletter"
+	"wb-L0/services/validation"
 	"wb-L0/structs"
 )
 
+// Outcomes of handling a single message
+const (
+	outcomeStored       = "stored"
+	outcomeDeadLettered = "dead_lettered"
+	outcomeRetry        = "retry"
+	outcomeUnchanged    = "unchanged"
+)
+
 func StartDataTransfer() {
 	messageChan := broker.GetBroker().StartConsuming(graceful.GetContext())
+	conf := config.GetConfig()
+	if conf != nil && conf.ConsumerBatchSize > 1 {
+		wait := defaultBatchWait
+		if conf.ConsumerBatchWait > 0 {
+			wait = time.Duration(conf.ConsumerBatchWait) * time.Millisecond
+		}
+		go consumeBatches(graceful.GetContext(), messageChan, conf.ConsumerBatchSize, wait)
+		return
+	}
+	if conf != nil && conf.ConsumerWorkers > 1 {
+		orderBy := conf.ConsumerOrderBy
+		if orderBy != orderByKey {
+			orderBy = orderByPartition
+		}
+		// A status change must not overtake the order it changes
+		if conf.KafkaStatusTopic != "" {
+			orderBy = orderByOrder
+		}
+		go consumeConcurrently(graceful.GetContext(), messageChan, conf.ConsumerWorkers, orderBy, routeMessage)
+		return
+	}
 	go func() {
 		for {
 			select {
 			case <-graceful.GetContext().Done():
 				return
-			case message := <-messageChan:
+			case message, ok := <-messageChan:
+				if !ok {
+					return
+				}
 				if message.Err != nil {
 					log.Println(message.Err)
 					continue
 				}
-				order, err := unmarshall(message.Value)
-				if err != nil {
-					log.Printf("Data marshalling failed: %s. Message skipped!", err.Error())
-					err = message.Ack()
-					if err != nil {
-						log.Printf("Ack failed: %s. Message skipped!", err.Error())
-					}
-					continue
-				}
-				ctx, cancel := context.WithTimeout(graceful.GetContext(), 5*time.Second)
-				err = database.GetDatabase().InsertOrder(ctx, order)
-				cancel()
-				if err != nil {
-					if database.IsErrDataInvalid(err) {
-						log.Printf("Insert order failed: %s. Message skipped!", err.Error())
-						err = message.Ack()
-						if err != nil {
-							log.Printf("Ack failed: %s. Message skipped!", err.Error())
-						}
-					} else {
-						log.Printf("Insert order failed: %s. Retrying!", err.Error())
-						err = message.Nack()
-						if err != nil {
-							log.Printf("Nack failed: %s. Message skipped", err.Error())
-						}
-					}
-				}
+				dispatch(graceful.GetContext(), message)
 			}
 		}
 	}()
 }
 
-func unmarshall(data []byte) (*structs.Order, error) {
-	var order structs.Order
-	err := json.Unmarshal(data, &order)
-	return &order, err
+// dispatch routes a message to the handler of its topic
+func dispatch(ctx context.Context, message broker.Message) string {
+	if isStatusMessage(message) {
+		return handleStatusMessage(ctx, message)
+	}
+	return handleMessage(ctx, message)
+}
+
+// routeMessage routes messages to the handler of their topic. When orderBy is
+// "order" it orders them by the uid of the order they create or change: the
+// message is decoded once and the handler gets the decoded payload.
+// Undecodable messages are ordered by partition.
+func routeMessage(message broker.Message, orderBy string) ([]byte, messageHandler) {
+	if orderBy != orderByOrder {
+		return routeStatic(dispatch)(message, orderBy)
+	}
+	if isStatusMessage(message) {
+		change, err := decodeStatusChange(message)
+		handle := func(ctx context.Context, message broker.Message) string {
+			return handleStatusChange(ctx, message, change, err)
+		}
+		if err != nil {
+			return nil, handle
+		}
+		return []byte(change.OrderUid), handle
+	}
+	order, err := decodeOrder(message)
+	handle := func(ctx context.Context, message broker.Message) string {
+		return handleOrder(ctx, message, order, err)
+	}
+	if err != nil || order.OrderUid == "" {
+		return nil, handle
+	}
+	return []byte(order.OrderUid), handle
+}
+
+func isStatusMessage(message broker.Message) bool {
+	conf := config.GetConfig()
+	return conf != nil && conf.KafkaStatusTopic != "" && message.Topic == conf.KafkaStatusTopic
+}
+
+// handleMessage runs a single message through the ingestion pipeline and
+// acknowledges it accordingly. It is shared by the live consumer and replays.
+func handleMessage(ctx context.Context, message broker.Message) string {
+	order, err := decodeOrder(message)
+	return handleOrder(ctx, message, order, err)
+}
+
+// handleOrder stores the order decoded from the message, or dead-letters the
+// message if decoding failed with err
+func handleOrder(ctx context.Context, message broker.Message, order *structs.Order, err error) string {
+	if err != nil {
+		log.Printf("Data decoding failed: %s. Message dead-lettered!", err.Error())
+		return deadLetter(ctx, message, err)
+	}
+	err = validation.ValidateOrder(order)
+	if err != nil {
+		log.Printf("Order %s rejected: %s. Message dead-lettered!", order.OrderUid, err.Error())
+		return deadLetter(ctx, message, err)
+	}
+	insertCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	err = database.GetDatabase().InsertOrder(insertCtx, order)
+	cancel()
+	if database.IsErrOrderUnchanged(err) {
+		monitoring.IncrementKafkaMessagesProcessed(message.Topic, outcomeUnchanged)
+		err = message.Ack()
+		if err != nil {
+			log.Printf("Ack failed: %s", err.Error())
+		}
+		return outcomeUnchanged
+	}
+	if err != nil {
+		if database.IsErrDataInvalid(err) || database.IsErrOrderExists(err) {
+			log.Printf("Insert order failed: %s. Message dead-lettered!", err.Error())
+			return deadLetter(ctx, message, err)
+		}
+		log.Printf("Insert order failed: %s. Retrying!", err.Error())
+		return retry(message)
+	}
+	// The order may have replaced a cached version
+	notFound.forget(order.OrderUid)
+	cache.Invalidate(order.OrderUid)
+	err = cache.GetCache().DeleteOrder(ctx, order.OrderUid)
+	if err != nil {
+		log.Printf("Cache invalidation of order %s failed: %s", order.OrderUid, err.Error())
+	}
+	monitoring.IncrementKafkaMessagesProcessed(message.Topic, outcomeStored)
+	err = message.Ack()
+	if err != nil {
+		log.Printf("Ack failed: %s", err.Error())
+	}
+	return outcomeStored
+}
+
+func retry(message broker.Message) string {
+	monitoring.IncrementKafkaMessagesProcessed(message.Topic, outcomeRetry)
+	err := message.Nack()
+	if err != nil {
+		log.Printf("Nack failed: %s. Message skipped", err.Error())
+	}
+	return outcomeRetry
+}
+
+// deadLetter moves a message that can never be processed to the dead-letter
+// queue and acknowledges it. If the queue rejects the letter the message is
+// nacked instead, so it is redelivered rather than lost.
+func deadLetter(ctx context.Context, message broker.Message, reason error) string {
+	letter := &structs.DeadLetter{
+		Id:          uuid.New().String(),
+		Payload:     message.Value,
+		ContentType: message.Headers[codec.ContentTypeHeader],
+		Reason:      reason.Error(),
+		Fields:      validation.FieldErrors(reason),
+		Topic:       message.Topic,
+		Partition:   message.Partition,
+		Offset:      message.Offset,
+		Timestamp:   time.Now().UTC(),
+	}
+	publishCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	err := deadletter.GetQueue().Publish(publishCtx, letter)
+	cancel()
+	if err != nil {
+		log.Printf("Dead-letter publish failed: %s. Retrying!", err.Error())
+		return retry(message)
+	}
+	monitoring.IncrementKafkaMessagesProcessed(message.Topic, outcomeDeadLettered)
+	err = message.Ack()
+	if err != nil {
+		log.Printf("Ack failed: %s. Message skipped!", err.Error())
+	}
+	return outcomeDeadLettered
+}
+
+// decodeOrder decodes the message with the codec selected by its content
+// type header
+func decodeOrder(message broker.Message) (*structs.Order, error) {
+	return codec.GetRegistry().Decode(message.Headers[codec.ContentTypeHeader], message.Value)
 }
@@ -0,0 +1,280 @@
+package orders
+
+import (
+	contextpkg "context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/broker"
+	"wb-L0/services/cache"
+	"wb-L0/services/codec"
+	"wb-L0/services/database"
+	"wb-L0/services/deadletter"
+	"wb-L0/structs"
+)
+
+// recordingQueue is a dead-letter queue that remembers published letters
+type recordingQueue struct {
+	letters []*structs.DeadLetter
+	err     error
+}
+
+func (q *recordingQueue) Publish(_ contextpkg.Context, letter *structs.DeadLetter) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.letters = append(q.letters, letter)
+	return nil
+}
+
+func (q *recordingQueue) List(_ contextpkg.Context, _ int) ([]*structs.DeadLetter, error) {
+	return q.letters, nil
+}
+
+func (q *recordingQueue) Remove(_ contextpkg.Context, _ string) error {
+	return nil
+}
+
+func (q *recordingQueue) HealthCheck(_ contextpkg.Context) error {
+	return nil
+}
+
+// ackRecorder builds a broker message that counts Ack and Nack calls
+type ackRecorder struct {
+	acks  int
+	nacks int
+}
+
+func (r *ackRecorder) message(value []byte) broker.Message {
+	return broker.Message{
+		Value:     value,
+		Topic:     "orders",
+		Partition: 1,
+		Offset:    7,
+		Ack: func() error {
+			r.acks++
+			return nil
+		},
+		Nack: func() error {
+			r.nacks++
+			return nil
+		},
+	}
+}
+
+// invalidatingCache returns a cache mock accepting invalidations of stored orders
+func invalidatingCache() *MockCache {
+	mockCache := new(MockCache)
+	mockCache.On("DeleteOrder", mock.Anything, mock.Anything).Return(nil)
+	mockCache.On("DeleteOrders", mock.Anything, mock.Anything).Return(0, nil)
+	return mockCache
+}
+
+// validOrder returns an order that passes validation
+func validOrder(orderUid string) *structs.Order {
+	return &structs.Order{
+		OrderUid:        orderUid,
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerId:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmId:            99,
+		DateCreated:     "2021-11-26T06:22:19Z",
+		OofShard:        "1",
+		Delivery: structs.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: structs.Payment{
+			Transaction:  orderUid,
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []structs.Item{
+			{
+				ChrtId:      9934930,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       453,
+				Rid:         "ab4219087a764ae0btest",
+				Name:        "Mascaras",
+				Sale:        30,
+				Size:        "0",
+				TotalPrice:  317,
+				NmId:        2389212,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+		},
+	}
+}
+
+// validOrderPayload returns a JSON order that passes validation
+func validOrderPayload(orderUid string) []byte {
+	data, _ := json.Marshal(validOrder(orderUid))
+	return data
+}
+
+// TestHandleMessageMalformedPayload tests that undecodable messages are dead-lettered and acked
+func TestHandleMessageMalformedPayload(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	database.SetDatabase(mockDB)
+	recorder := new(ackRecorder)
+
+	handleMessage(contextpkg.Background(), recorder.message([]byte("{not json")))
+
+	require.Len(t, queue.letters, 1)
+	letter := queue.letters[0]
+	assert.Equal(t, []byte("{not json"), letter.Payload)
+	assert.Equal(t, "orders", letter.Topic)
+	assert.Equal(t, 1, letter.Partition)
+	assert.Equal(t, int64(7), letter.Offset)
+	assert.NotEmpty(t, letter.Reason)
+	assert.NotEmpty(t, letter.Id)
+	assert.Equal(t, 1, recorder.acks)
+	assert.Equal(t, 0, recorder.nacks)
+	mockDB.AssertNotCalled(t, "InsertOrder")
+}
+
+// TestHandleMessageInvalidData tests that orders rejected by the database are dead-lettered
+func TestHandleMessageInvalidData(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrder", mock.Anything, mock.Anything).Return(database.ErrDataInvalid{Err: "duplicate key"})
+	database.SetDatabase(mockDB)
+	recorder := new(ackRecorder)
+
+	handleMessage(contextpkg.Background(), recorder.message(validOrderPayload("dup")))
+
+	require.Len(t, queue.letters, 1)
+	assert.Contains(t, queue.letters[0].Reason, "duplicate key")
+	assert.Equal(t, 1, recorder.acks)
+	mockDB.AssertExpectations(t)
+}
+
+// TestHandleMessageDeadLetterFailure tests that a message is nacked when the dead-letter queue is unavailable
+func TestHandleMessageDeadLetterFailure(t *testing.T) {
+	deadletter.SetQueue(&recordingQueue{err: assert.AnError})
+	database.SetDatabase(new(MockDatabase))
+	recorder := new(ackRecorder)
+
+	handleMessage(contextpkg.Background(), recorder.message([]byte("{not json")))
+
+	assert.Equal(t, 0, recorder.acks)
+	assert.Equal(t, 1, recorder.nacks)
+}
+
+// TestHandleMessageSuccess tests that stored orders are acked and not dead-lettered
+func TestHandleMessageSuccess(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrder", mock.Anything, mock.Anything).Return(nil)
+	database.SetDatabase(mockDB)
+	mockCache := invalidatingCache()
+	cache.SetCache(mockCache)
+	recorder := new(ackRecorder)
+
+	handleMessage(contextpkg.Background(), recorder.message(validOrderPayload("ok")))
+
+	assert.Empty(t, queue.letters)
+	assert.Equal(t, 1, recorder.acks)
+	assert.Equal(t, 0, recorder.nacks)
+	mockDB.AssertExpectations(t)
+	mockCache.AssertCalled(t, "DeleteOrder", mock.Anything, "ok")
+}
+
+// TestHandleMessageUnchanged tests that identical redeliveries are acked without cache invalidation
+func TestHandleMessageUnchanged(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrder", mock.Anything, mock.Anything).Return(database.ErrOrderUnchanged{Id: "same"})
+	database.SetDatabase(mockDB)
+	mockCache := invalidatingCache()
+	cache.SetCache(mockCache)
+	recorder := new(ackRecorder)
+
+	outcome := handleMessage(contextpkg.Background(), recorder.message(validOrderPayload("same")))
+
+	assert.Equal(t, outcomeUnchanged, outcome)
+	assert.Empty(t, queue.letters)
+	assert.Equal(t, 1, recorder.acks)
+	mockCache.AssertNotCalled(t, "DeleteOrder", mock.Anything, mock.Anything)
+}
+
+// TestHandleMessageValidationFailure tests that invalid orders are dead-lettered with field errors
+func TestHandleMessageValidationFailure(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	database.SetDatabase(mockDB)
+	recorder := new(ackRecorder)
+
+	handleMessage(contextpkg.Background(), recorder.message([]byte(`{"order_uid":""}`)))
+
+	require.Len(t, queue.letters, 1)
+	assert.NotEmpty(t, queue.letters[0].Fields)
+	assert.Equal(t, 1, recorder.acks)
+	mockDB.AssertNotCalled(t, "InsertOrder")
+}
+
+// TestHandleMessageProtobuf tests that messages are decoded with the codec of their content type header
+func TestHandleMessageProtobuf(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrder", mock.Anything, mock.MatchedBy(func(order *structs.Order) bool {
+		return order.OrderUid == "proto" && len(order.Items) == 1
+	})).Return(nil)
+	database.SetDatabase(mockDB)
+	cache.SetCache(invalidatingCache())
+	recorder := new(ackRecorder)
+	payload, err := codec.ProtobufCodec{}.Encode(validOrder("proto"))
+	require.NoError(t, err)
+	message := recorder.message(payload)
+	message.Headers = map[string]string{codec.ContentTypeHeader: codec.ContentTypeProtobuf}
+
+	outcome := handleMessage(contextpkg.Background(), message)
+
+	assert.Equal(t, outcomeStored, outcome)
+	assert.Empty(t, queue.letters)
+	mockDB.AssertExpectations(t)
+}
+
+// TestHandleMessageUnsupportedContentType tests that unknown formats are dead-lettered with their content type
+func TestHandleMessageUnsupportedContentType(t *testing.T) {
+	queue := new(recordingQueue)
+	deadletter.SetQueue(queue)
+	mockDB := new(MockDatabase)
+	database.SetDatabase(mockDB)
+	recorder := new(ackRecorder)
+	message := recorder.message([]byte("<order/>"))
+	message.Headers = map[string]string{codec.ContentTypeHeader: "application/xml"}
+
+	handleMessage(contextpkg.Background(), message)
+
+	require.Len(t, queue.letters, 1)
+	assert.Equal(t, "application/xml", queue.letters[0].ContentType)
+	assert.Contains(t, queue.letters[0].Reason, "unsupported content type")
+	assert.Equal(t, 1, recorder.acks)
+	mockDB.AssertNotCalled(t, "InsertOrder")
+}
@@ -0,0 +1,264 @@
+package orders
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"wb-L0/services/broker"
+)
+
+// Ordering modes of the worker pool
+const (
+	orderByPartition = "partition"
+	orderByKey       = "key"
+	// orderByOrder keeps the messages of each order uid, on any topic, on
+	// one worker
+	orderByOrder = "order"
+)
+
+const (
+	workerQueueSize = 16
+	minRetryDelay   = 100 * time.Millisecond
+	maxRetryDelay   = 5 * time.Second
+	// maxPartitionInFlight bounds the uncommitted messages of a partition, so
+	// messages do not pile up behind one that is retried
+	maxPartitionInFlight = 1024
+)
+
+// messageHandler processes a message and returns its outcome
+type messageHandler func(ctx context.Context, message broker.Message) string
+
+// messageRouter returns the ordering key of a message, nil to order it by
+// partition, and the handler of the message
+type messageRouter func(message broker.Message, orderBy string) ([]byte, messageHandler)
+
+// job is a message bound to its handler
+type job struct {
+	message broker.Message
+	handle  messageHandler
+}
+
+// routeStatic orders messages by partition, or by key when orderBy is "key",
+// and handles every message with handle
+func routeStatic(handle messageHandler) messageRouter {
+	return func(message broker.Message, orderBy string) ([]byte, messageHandler) {
+		if orderBy == orderByKey {
+			return message.Key, handle
+		}
+		return nil, handle
+	}
+}
+
+// consumeConcurrently fans messages out to a fixed number of workers. Messages
+// with one ordering key, or of one partition when the router gives none,
+// always go to the same worker, so they are handled in the order they were
+// consumed. Offsets are committed only up to the highest contiguous handled
+// offset of each partition, regardless of the order in which workers finish;
+// consumption pauses while a partition has maxPartitionInFlight messages
+// uncommitted.
+func consumeConcurrently(ctx context.Context, messageChan <-chan broker.Message, workers int, orderBy string, route messageRouter) {
+	tracker := newOffsetTracker()
+	queues := make([]chan job, workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan job, workerQueueSize)
+		wg.Add(1)
+		go func(queue <-chan job) {
+			defer wg.Done()
+			for job := range queue {
+				handleWithRetry(ctx, job.message, job.handle)
+			}
+		}(queues[i])
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messageChan:
+			if !ok {
+				return
+			}
+			if message.Err != nil {
+				log.Println(message.Err)
+				continue
+			}
+			if !tracker.wait(ctx, message) {
+				return
+			}
+			key, handle := route(message, orderBy)
+			queue := queues[workerIndex(message, key, workers)]
+			select {
+			case queue <- job{message: tracker.track(message), handle: handle}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// handleWithRetry handles the message until it is stored or dead-lettered.
+// Retrying in place keeps the partition ordered; a message abandoned on
+// shutdown stays uncommitted and is consumed again after restart.
+func handleWithRetry(ctx context.Context, message broker.Message, handle messageHandler) {
+	delay := minRetryDelay
+	for handle(ctx, message) == outcomeRetry {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// workerIndex picks the worker for a message with the ordering key; without
+// one the message falls back to partition ordering
+func workerIndex(message broker.Message, key []byte, workers int) int {
+	hash := fnv.New32a()
+	if len(key) > 0 {
+		hash.Write(key)
+	} else {
+		hash.Write([]byte(message.Topic))
+		hash.Write([]byte(strconv.Itoa(message.Partition)))
+	}
+	return int(hash.Sum32() % uint32(workers))
+}
+
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+// trackedOffset is a consumed message waiting for the messages before it
+type trackedOffset struct {
+	offset int64
+	done   bool
+	ack    func() error
+}
+
+// partitionOffsets holds consumed but uncommitted offsets of a partition in
+// consumption order
+type partitionOffsets struct {
+	mutex   sync.Mutex
+	pending []*trackedOffset
+	// freed is signalled when commits make room in pending
+	freed chan struct{}
+}
+
+// offsetTracker turns out-of-order acknowledgements into in-order commits
+type offsetTracker struct {
+	mutex      sync.Mutex
+	partitions map[partitionKey]*partitionOffsets
+	// window is the most messages of a partition in flight
+	window int
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[partitionKey]*partitionOffsets),
+		window:     maxPartitionInFlight,
+	}
+}
+
+func (t *offsetTracker) partition(message broker.Message) *partitionOffsets {
+	key := partitionKey{topic: message.Topic, partition: message.Partition}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	partition, ok := t.partitions[key]
+	if !ok {
+		partition = &partitionOffsets{freed: make(chan struct{}, 1)}
+		t.partitions[key] = partition
+	}
+	return partition
+}
+
+// wait blocks while the partition of the message has a full window in
+// flight. It returns false if ctx is done first. Messages of brokers with
+// individual acks are bounded by the broker instead.
+func (t *offsetTracker) wait(ctx context.Context, message broker.Message) bool {
+	if message.IndividualAck {
+		return true
+	}
+	partition := t.partition(message)
+	for {
+		partition.mutex.Lock()
+		full := len(partition.pending) >= t.window
+		partition.mutex.Unlock()
+		if !full {
+			return true
+		}
+		select {
+		case <-partition.freed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// track registers the message as in flight and returns a copy whose Ack
+// commits through the tracker. Nack is a no-op: a message that is not handled
+// keeps its offset, and every offset after it, uncommitted. Messages of
+// brokers with individual acks are not tracked; only their Nack is disabled,
+// as retries happen in place instead of through redelivery.
+func (t *offsetTracker) track(message broker.Message) broker.Message {
+	if message.IndividualAck {
+		tracked := message
+		tracked.Nack = func() error {
+			return nil
+		}
+		return tracked
+	}
+	partition := t.partition(message)
+	entry := &trackedOffset{offset: message.Offset, ack: message.Ack}
+	partition.mutex.Lock()
+	partition.pending = append(partition.pending, entry)
+	partition.mutex.Unlock()
+
+	tracked := message
+	tracked.Ack = func() error {
+		return partition.complete(entry)
+	}
+	tracked.Nack = func() error {
+		return nil
+	}
+	return tracked
+}
+
+// complete marks the entry handled and commits the last entry of the handled
+// prefix. Committing an offset commits every offset before it, so only that
+// message is acked. The lock is held while committing to keep commits of the
+// partition monotonic.
+func (p *partitionOffsets) complete(entry *trackedOffset) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	entry.done = true
+	var last *trackedOffset
+	handled := 0
+	for handled < len(p.pending) && p.pending[handled].done {
+		last = p.pending[handled]
+		handled++
+	}
+	if last == nil {
+		return nil
+	}
+	err := last.ack()
+	if err != nil {
+		return err
+	}
+	p.pending = p.pending[handled:]
+	select {
+	case p.freed <- struct{}{}:
+	default:
+	}
+	return nil
+}
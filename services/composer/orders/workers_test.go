@@ -0,0 +1,325 @@
+package orders
+
+import (
+	contextpkg "context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/modules/config"
+	"wb-L0/services/broker"
+	"wb-L0/services/cache"
+	"wb-L0/services/codec"
+	"wb-L0/services/database"
+	"wb-L0/structs"
+)
+
+var _ broker.Broker = (*fakeBroker)(nil)
+
+// fakeBroker delivers a fixed list of messages and records committed offsets
+type fakeBroker struct {
+	mutex     sync.Mutex
+	messages  []broker.Message
+	committed map[int][]int64
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{committed: make(map[int][]int64)}
+}
+
+func (b *fakeBroker) add(partition int, offset int64, key string) {
+	b.messages = append(b.messages, broker.Message{
+		Key:       []byte(key),
+		Value:     []byte(fmt.Sprintf("%d:%d", partition, offset)),
+		Topic:     "orders",
+		Partition: partition,
+		Offset:    offset,
+		Ack: func() error {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+			b.committed[partition] = append(b.committed[partition], offset)
+			return nil
+		},
+		Nack: func() error {
+			return nil
+		},
+	})
+}
+
+func (b *fakeBroker) StartConsuming(_ contextpkg.Context) chan broker.Message {
+	messageChan := make(chan broker.Message, len(b.messages))
+	for _, message := range b.messages {
+		messageChan <- message
+	}
+	close(messageChan)
+	return messageChan
+}
+
+func (b *fakeBroker) HealthCheck(_ contextpkg.Context) error {
+	return nil
+}
+
+// processingLog records the order in which messages were handled per group
+type processingLog struct {
+	mutex   sync.Mutex
+	handled map[string][]int64
+}
+
+func (l *processingLog) handler(group func(broker.Message) string) messageHandler {
+	l.handled = make(map[string][]int64)
+	return func(_ contextpkg.Context, message broker.Message) string {
+		// Uneven work makes workers finish out of order
+		time.Sleep(time.Duration(message.Offset%3) * time.Millisecond)
+		l.mutex.Lock()
+		l.handled[group(message)] = append(l.handled[group(message)], message.Offset)
+		l.mutex.Unlock()
+		_ = message.Ack()
+		return outcomeStored
+	}
+}
+
+func assertAscending(t *testing.T, offsets []int64) {
+	for i := 1; i < len(offsets); i++ {
+		assert.Less(t, offsets[i-1], offsets[i])
+	}
+}
+
+// TestOffsetTrackerContiguousCommit tests that offsets are committed only up to the handled prefix
+func TestOffsetTrackerContiguousCommit(t *testing.T) {
+	fake := newFakeBroker()
+	fake.add(0, 1, "")
+	fake.add(0, 2, "")
+	fake.add(0, 3, "")
+	tracker := newOffsetTracker()
+	first := tracker.track(fake.messages[0])
+	second := tracker.track(fake.messages[1])
+	third := tracker.track(fake.messages[2])
+
+	require.NoError(t, second.Ack())
+	assert.Empty(t, fake.committed[0])
+
+	require.NoError(t, first.Ack())
+	assert.Equal(t, []int64{2}, fake.committed[0])
+
+	require.NoError(t, third.Ack())
+	assert.Equal(t, []int64{2, 3}, fake.committed[0])
+}
+
+// TestOffsetTrackerNackBlocksCommit tests that an unhandled message keeps later offsets uncommitted
+func TestOffsetTrackerNackBlocksCommit(t *testing.T) {
+	fake := newFakeBroker()
+	fake.add(0, 1, "")
+	fake.add(0, 2, "")
+	fake.add(1, 1, "")
+	tracker := newOffsetTracker()
+	first := tracker.track(fake.messages[0])
+	second := tracker.track(fake.messages[1])
+	other := tracker.track(fake.messages[2])
+
+	require.NoError(t, first.Nack())
+	require.NoError(t, second.Ack())
+	require.NoError(t, other.Ack())
+
+	assert.Empty(t, fake.committed[0])
+	assert.Equal(t, []int64{1}, fake.committed[1])
+}
+
+// TestConsumeConcurrentlyPartitionOrder tests that partitions are handled in order and fully committed
+func TestConsumeConcurrentlyPartitionOrder(t *testing.T) {
+	fake := newFakeBroker()
+	for offset := int64(0); offset < 20; offset++ {
+		for partition := 0; partition < 3; partition++ {
+			fake.add(partition, offset, "")
+		}
+	}
+	processing := new(processingLog)
+	handle := processing.handler(func(message broker.Message) string {
+		return fmt.Sprint(message.Partition)
+	})
+
+	consumeConcurrently(contextpkg.Background(), fake.StartConsuming(contextpkg.Background()), 4, orderByPartition, routeStatic(handle))
+
+	for partition := 0; partition < 3; partition++ {
+		handled := processing.handled[fmt.Sprint(partition)]
+		assert.Len(t, handled, 20)
+		assertAscending(t, handled)
+		committed := fake.committed[partition]
+		require.NotEmpty(t, committed)
+		assertAscending(t, committed)
+		assert.Equal(t, int64(19), committed[len(committed)-1])
+	}
+}
+
+// TestConsumeConcurrentlyKeyOrder tests that keys of one partition are handled in order in parallel
+func TestConsumeConcurrentlyKeyOrder(t *testing.T) {
+	fake := newFakeBroker()
+	for offset := int64(0); offset < 40; offset++ {
+		fake.add(0, offset, fmt.Sprintf("order-%d", offset%4))
+	}
+	processing := new(processingLog)
+	handle := processing.handler(func(message broker.Message) string {
+		return string(message.Key)
+	})
+
+	consumeConcurrently(contextpkg.Background(), fake.StartConsuming(contextpkg.Background()), 4, orderByKey, routeStatic(handle))
+
+	require.Len(t, processing.handled, 4)
+	for _, handled := range processing.handled {
+		assert.Len(t, handled, 10)
+		assertAscending(t, handled)
+	}
+	committed := fake.committed[0]
+	require.NotEmpty(t, committed)
+	assertAscending(t, committed)
+	assert.Equal(t, int64(39), committed[len(committed)-1])
+}
+
+// TestWorkerIndexOrder tests that a status change is routed to the worker of the order it changes
+func TestWorkerIndexOrder(t *testing.T) {
+	t.Setenv("APP_PORT", "8080")
+	t.Setenv("KAFKA_STATUS_TOPIC", "")
+	// Runs after the status topic is unset again
+	t.Cleanup(func() {
+		_ = new(config.Config).Init(nil)
+	})
+	t.Setenv("KAFKA_STATUS_TOPIC", "statuses")
+	require.NoError(t, new(config.Config).Init(nil))
+
+	for i := range 16 {
+		uid := fmt.Sprintf("order-%d", i)
+		order := broker.Message{Topic: "orders", Partition: i % 3, Value: validOrderPayload(uid)}
+		status := broker.Message{Topic: "statuses", Value: []byte(`{"order_uid": "` + uid + `", "status": "paid"}`)}
+		orderKey, _ := routeMessage(order, orderByOrder)
+		statusKey, _ := routeMessage(status, orderByOrder)
+		assert.Equal(t, []byte(uid), orderKey)
+		assert.Equal(t, workerIndex(order, orderKey, 8), workerIndex(status, statusKey, 8), uid)
+	}
+	key, _ := routeMessage(broker.Message{Topic: "orders", Value: []byte("{")}, orderByOrder)
+	assert.Nil(t, key)
+}
+
+// countingCodec counts the orders it decodes
+type countingCodec struct {
+	codec.JsonCodec
+	decoded atomic.Int32
+}
+
+func (c *countingCodec) Decode(data []byte) (*structs.Order, error) {
+	c.decoded.Add(1)
+	return c.JsonCodec.Decode(data)
+}
+
+// TestRouteMessageDecodesOnce tests that a message routed by order uid is not decoded again by its handler
+func TestRouteMessageDecodesOnce(t *testing.T) {
+	counting := new(countingCodec)
+	previous := codec.GetRegistry()
+	codec.SetRegistry(codec.NewRegistry(counting))
+	t.Cleanup(func() {
+		codec.SetRegistry(previous)
+	})
+	mockDB := new(MockDatabase)
+	mockDB.On("InsertOrder", mock.Anything, mock.Anything).Return(nil)
+	database.SetDatabase(mockDB)
+	cache.SetCache(invalidatingCache())
+
+	acked := false
+	message := broker.Message{Topic: "orders", Value: validOrderPayload("decode-once"), Ack: func() error {
+		acked = true
+		return nil
+	}}
+	key, handle := routeMessage(message, orderByOrder)
+
+	assert.Equal(t, outcomeStored, handle(contextpkg.Background(), message))
+	assert.Equal(t, []byte("decode-once"), key)
+	assert.Equal(t, int32(1), counting.decoded.Load())
+	assert.True(t, acked)
+}
+
+// TestOffsetTrackerWindow tests that a full partition blocks consumption until its head is committed
+func TestOffsetTrackerWindow(t *testing.T) {
+	fake := newFakeBroker()
+	fake.add(0, 1, "")
+	fake.add(0, 2, "")
+	fake.add(0, 3, "")
+	fake.add(1, 1, "")
+	tracker := newOffsetTracker()
+	tracker.window = 2
+	ctx := contextpkg.Background()
+
+	require.True(t, tracker.wait(ctx, fake.messages[0]))
+	head := tracker.track(fake.messages[0])
+	require.True(t, tracker.wait(ctx, fake.messages[1]))
+	tracker.track(fake.messages[1])
+	// Other partitions have their own window
+	assert.True(t, tracker.wait(ctx, fake.messages[3]))
+
+	cancelled, cancel := contextpkg.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.False(t, tracker.wait(cancelled, fake.messages[2]))
+
+	waited := make(chan bool)
+	go func() {
+		waited <- tracker.wait(ctx, fake.messages[2])
+	}()
+	select {
+	case <-waited:
+		t.Fatal("wait returned while the window was full")
+	case <-time.After(20 * time.Millisecond):
+	}
+	require.NoError(t, head.Ack())
+	assert.True(t, <-waited)
+}
+
+// TestConsumeConcurrentlyRetry tests that a message to retry is handled again before later ones
+func TestConsumeConcurrentlyRetry(t *testing.T) {
+	fake := newFakeBroker()
+	fake.add(0, 1, "")
+	fake.add(0, 2, "")
+	var handled []int64
+	attempts := 0
+	handle := func(_ contextpkg.Context, message broker.Message) string {
+		handled = append(handled, message.Offset)
+		if message.Offset == 1 && attempts == 0 {
+			attempts++
+			_ = message.Nack()
+			return outcomeRetry
+		}
+		_ = message.Ack()
+		return outcomeStored
+	}
+
+	consumeConcurrently(contextpkg.Background(), fake.StartConsuming(contextpkg.Background()), 2, orderByPartition, routeStatic(handle))
+
+	assert.Equal(t, []int64{1, 1, 2}, handled)
+	assert.Equal(t, []int64{1, 2}, fake.committed[0])
+}
+
+// TestOffsetTrackerIndividualAck tests that individually acked messages are acked as soon as they are handled
+func TestOffsetTrackerIndividualAck(t *testing.T) {
+	fake := newFakeBroker()
+	fake.add(0, 1, "")
+	fake.add(0, 2, "")
+	nacks := 0
+	for i := range fake.messages {
+		fake.messages[i].IndividualAck = true
+		fake.messages[i].Nack = func() error {
+			nacks++
+			return nil
+		}
+	}
+	tracker := newOffsetTracker()
+	first := tracker.track(fake.messages[0])
+	second := tracker.track(fake.messages[1])
+
+	require.NoError(t, first.Nack())
+	require.NoError(t, second.Ack())
+
+	assert.Equal(t, []int64{2}, fake.committed[0])
+	assert.Zero(t, nacks)
+}
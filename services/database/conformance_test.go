@@ -0,0 +1,465 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"wb-L0/modules/migrations"
+	"wb-L0/modules/pg"
+	"wb-L0/modules/sqlite"
+	"wb-L0/services/orderstatus"
+	"wb-L0/structs"
+)
+
+// conformanceDSN points at the integration test database from docker-compose.test.yml
+const conformanceDSN = "host=localhost user=test_user password=test_pass dbname=test_db port=5433 sslmode=disable TimeZone=UTC"
+
+// newDatabaseFunc opens an empty database with the given duplicate policy
+type newDatabaseFunc func(t *testing.T, policy DuplicatePolicy) Database
+
+func TestMemoryConformance(t *testing.T) {
+	runConformance(t, func(_ *testing.T, policy DuplicatePolicy) Database {
+		return NewMemory(policy)
+	})
+}
+
+func TestSqliteConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T, policy DuplicatePolicy) Database {
+		db, err := sqlite.Connect(filepath.Join(t.TempDir(), "orders.db"))
+		require.NoError(t, err)
+		db.Logger = logger.Discard
+		require.NoError(t, migrations.Run(context.Background(), db))
+		engine := &sqlite.Sqlite{Db: db}
+		t.Cleanup(func() {
+			engine.Shutdown(context.Background())
+		})
+		return NewSqlite(engine, policy)
+	})
+}
+
+// TestPostgresConformance runs the suite in a fresh schema of the test
+// database so it never touches the tables of other tests
+func TestPostgresConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		dsn = conformanceDSN
+	}
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err == nil {
+		err = admin.Exec("SELECT 1").Error
+	}
+	if err != nil {
+		t.Skipf("Postgres not available, skipping conformance suite: %v", err)
+	}
+	runConformance(t, func(t *testing.T, policy DuplicatePolicy) Database {
+		schema := fmt.Sprintf("conformance_%d", time.Now().UnixNano())
+		require.NoError(t, admin.Exec("CREATE SCHEMA "+schema).Error)
+		t.Cleanup(func() {
+			admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		})
+		db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema),
+			&gorm.Config{TranslateError: true, Logger: logger.Discard})
+		require.NoError(t, err)
+		require.NoError(t, migrations.Run(context.Background(), db))
+		t.Cleanup(func() {
+			if sqlDB, err := db.DB(); err == nil {
+				sqlDB.Close()
+			}
+		})
+		return NewPostgres(&pg.Postgres{Db: db}, policy)
+	})
+}
+
+// runConformance checks the behaviour every Database implementation shares
+func runConformance(t *testing.T, newDatabase newDatabaseFunc) {
+	t.Run("InsertAndGet", func(t *testing.T) {
+		db := newDatabase(t, DuplicateReject)
+		ctx := context.Background()
+		require.NoError(t, db.InsertOrder(ctx, conformanceOrder("a")))
+
+		order, err := db.GetOrderById(ctx, "a")
+		require.NoError(t, err)
+		assertSameOrder(t, conformanceOrder("a"), order)
+		assert.Equal(t, orderstatus.Created, order.Status)
+
+		_, err = db.GetOrderById(ctx, "missing")
+		assert.True(t, IsErrOrderNotFound(err))
+	})
+
+	t.Run("InvalidDate", func(t *testing.T) {
+		db := newDatabase(t, DuplicateReject)
+		order := conformanceOrder("a")
+		order.DateCreated = "yesterday"
+
+		err := db.InsertOrder(context.Background(), order)
+		assert.True(t, IsErrDataInvalid(err))
+	})
+
+	t.Run("DuplicateReject", func(t *testing.T) {
+		db := newDatabase(t, DuplicateReject)
+		ctx := context.Background()
+		require.NoError(t, db.InsertOrder(ctx, conformanceOrder("a")))
+
+		err := db.InsertOrder(ctx, conformanceOrder("a"))
+		assert.True(t, IsErrOrderExists(err))
+	})
+
+	t.Run("DuplicateIgnore", func(t *testing.T) {
+		db := newDatabase(t, DuplicateIgnore)
+		ctx := context.Background()
+		require.NoError(t, db.InsertOrder(ctx, conformanceOrder("a")))
+
+		err := db.InsertOrder(ctx, conformanceOrder("a"))
+		assert.True(t, IsErrOrderUnchanged(err))
+		changed := conformanceOrder("a")
+		changed.Locale = "ru"
+		err = db.InsertOrder(ctx, changed)
+		assert.True(t, IsErrOrderExists(err))
+	})
+
+	t.Run("DuplicateUpsert", func(t *testing.T) {
+		db := newDatabase(t, DuplicateUpsert)
+		ctx := context.Background()
+		require.NoError(t, db.InsertOrder(ctx, conformanceOrder("a")))
+		require.NoError(t, db.UpdateOrderStatus(ctx, "a", &structs.OrderStatusChange{Status: orderstatus.Paid}))
+
+		changed := conformanceOrder("a")
+		changed.Locale = "ru"
+		changed.Items = append(changed.Items, changed.Items[0])
+		changed.Items[1].ChrtId = 2
+		require.NoError(t, db.InsertOrder(ctx, changed))
+
+		order, err := db.GetOrderById(ctx, "a")
+		require.NoError(t, err)
+		assertSameOrder(t, changed, order)
+		assert.Equal(t, orderstatus.Paid, order.Status)
+		history, err := db.GetOrderStatusHistory(ctx, "a")
+		require.NoError(t, err)
+		assert.Len(t, history.History, 2)
+	})
+
+	t.Run("ConcurrentInsert", func(t *testing.T) {
+		for policy, expected := range map[DuplicatePolicy]func(error) bool{
+			DuplicateReject: IsErrOrderExists,
+			DuplicateIgnore: IsErrOrderUnchanged,
+			DuplicateUpsert: IsErrOrderUnchanged,
+		} {
+			t.Run(string(policy), func(t *testing.T) {
+				db := newDatabase(t, policy)
+				errs := make(chan error, 2)
+				for range 2 {
+					go func() {
+						errs <- db.InsertOrder(context.Background(), conformanceOrder("a"))
+					}()
+				}
+				first, second := <-errs, <-errs
+				if first != nil {
+					first, second = second, first
+				}
+				require.NoError(t, first)
+				assert.True(t, expected(second), "unexpected error: %v", second)
+
+				published, err := db.PublishOutbox(context.Background(), 10,
+					func(context.Context, []*structs.OrderEvent) error { return nil })
+				require.NoError(t, err)
+				assert.Equal(t, 1, published)
+			})
+		}
+	})
+
+	t.Run("InsertOrdersAtomic", func(t *testing.T) {
+		db := newDatabase(t, DuplicateReject)
+		ctx := context.Background()
+		invalid := conformanceOrder("c")
+		invalid.DateCreated = "yesterday"
+
+		_, err := db.InsertOrders(ctx, []*structs.Order{conformanceOrder("b"), invalid})
+		assert.True(t, IsErrDataInvalid(err))
+		_, err = db.InsertOrders(ctx, []*structs.Order{conformanceOrder("b"), conformanceOrder("b")})
+		assert.True(t, IsErrOrderExists(err))
+		_, err = db.GetOrderById(ctx, "b")
+		assert.True(t, IsErrOrderNotFound(err))
+
+		results, err := db.InsertOrders(ctx, []*structs.Order{conformanceOrder("b"), conformanceOrder("c")})
+		require.NoError(t, err)
+		assert.Equal(t, []error{nil, nil}, results)
+		results, err = db.InsertOrders(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		orders, err := db.GetOrdersByIds(ctx, []string{"b", "c", "missing"})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"b", "c"}, orderUids(orders))
+	})
+
+	t.Run("InsertOrdersDuplicates", func(t *testing.T) {
+		changed := conformanceOrder("x")
+		changed.Locale = "ru"
+		for policy, expected := range map[DuplicatePolicy][]error{
+			DuplicateReject: {nil, ErrOrderExists{Id: "a"}, ErrOrderExists{Id: "x"}, nil},
+			DuplicateIgnore: {nil, ErrOrderUnchanged{Id: "a"}, ErrOrderExists{Id: "x"}, nil},
+			DuplicateUpsert: {nil, ErrOrderUnchanged{Id: "a"}, nil, nil},
+		} {
+			db := newDatabase(t, policy)
+			ctx := context.Background()
+			require.NoError(t, db.InsertOrder(ctx, conformanceOrder("a")))
+			require.NoError(t, db.InsertOrder(ctx, conformanceOrder("x")))
+			require.NoError(t, db.UpdateOrderStatus(ctx, "x", &structs.OrderStatusChange{Status: orderstatus.Paid}))
+
+			results, err := db.InsertOrders(ctx, []*structs.Order{conformanceOrder("b"), conformanceOrder("a"), changed, conformanceOrder("c")})
+			require.NoError(t, err, policy)
+			assert.Equal(t, expected, results, policy)
+			orders, err := db.GetOrdersByIds(ctx, []string{"b", "c"})
+			require.NoError(t, err)
+			assert.Len(t, orders, 2, policy)
+			order, err := db.GetOrderById(ctx, "x")
+			require.NoError(t, err)
+			assert.Equal(t, orderstatus.Paid, order.Status, policy)
+			if policy == DuplicateUpsert {
+				assertSameOrder(t, changed, order)
+			} else {
+				assertSameOrder(t, conformanceOrder("x"), order)
+			}
+		}
+	})
+
+	t.Run("GetOrdersByIdsEmpty", func(t *testing.T) {
+		db := newDatabase(t, DuplicateReject)
+
+		orders, err := db.GetOrdersByIds(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Empty(t, orders)
+	})
+
+	t.Run("FindOrderIds", func(t *testing.T) {
+		db := newDatabase(t, DuplicateReject)
+		ctx := context.Background()
+		first := conformanceOrder("a")
+		first.Payment.RequestId = "request"
+		second := conformanceOrder("b")
+		second.TrackNumber = "OTHER"
+		second.Payment.RequestId = "request"
+		_, err := db.InsertOrders(ctx, []*structs.Order{first, second})
+		require.NoError(t, err)
+
+		uids, err := db.FindOrderIdsByTrackNumber(ctx, "WBILMTESTTRACK")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, uids)
+		uids, err = db.FindOrderIdsByTrackNumber(ctx, "OTHER")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b"}, uids)
+		uids, err = db.FindOrderIdsByTransaction(ctx, "tx-a")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, uids)
+		uids, err = db.FindOrderIdsByRequestId(ctx, "request")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, uids)
+		uids, err = db.FindOrderIdsByTrackNumber(ctx, "missing")
+		require.NoError(t, err)
+		assert.Empty(t, uids)
+	})
+
+	t.Run("ListOrders", func(t *testing.T) {
+		db := newDatabase(t, DuplicateReject)
+		ctx := context.Background()
+		base := time.Date(2021, 11, 26, 6, 0, 0, 0, time.UTC)
+		for i, uid := range []string{"a", "b", "c", "d", "e"} {
+			order := conformanceOrder(uid)
+			order.DateCreated = base.Add(time.Duration(i%3) * time.Hour).Format(time.RFC3339)
+			if uid == "e" {
+				order.CustomerId = "other"
+				order.Items[0].Brand = "Other"
+			}
+			require.NoError(t, db.InsertOrder(ctx, order))
+		}
+
+		var uids []string
+		filter := &structs.OrderFilter{Limit: 2}
+		for {
+			page, err := db.ListOrders(ctx, filter)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(page.Orders), 2)
+			uids = append(uids, orderUids(page.Orders)...)
+			if page.NextCursor == "" {
+				break
+			}
+			filter.Cursor = page.NextCursor
+		}
+		assert.Equal(t, []string{"c", "e", "b", "d", "a"}, uids)
+
+		page, err := db.ListOrders(ctx, &structs.OrderFilter{CustomerId: "test", DateFrom: base.Add(time.Hour), Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "b"}, orderUids(page.Orders))
+		assert.Empty(t, page.NextCursor)
+		page, err = db.ListOrders(ctx, &structs.OrderFilter{Brand: "Other", Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"e"}, orderUids(page.Orders))
+		page, err = db.ListOrders(ctx, &structs.OrderFilter{Bank: "missing", Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, page.Orders)
+
+		_, err = db.ListOrders(ctx, &structs.OrderFilter{Cursor: "%%%", Limit: 10})
+		assert.True(t, IsErrInvalidCursor(err))
+	})
+
+	t.Run("OrderStatus", func(t *testing.T) {
+		db := newDatabase(t, DuplicateReject)
+		ctx := context.Background()
+		require.NoError(t, db.InsertOrder(ctx, conformanceOrder("a")))
+
+		change := &structs.OrderStatusChange{Status: orderstatus.Paid, Reason: "paid", Source: orderstatus.SourceApi}
+		require.NoError(t, db.UpdateOrderStatus(ctx, "a", change))
+		err := db.UpdateOrderStatus(ctx, "a", change)
+		assert.True(t, IsErrOrderUnchanged(err))
+		err = db.UpdateOrderStatus(ctx, "a", &structs.OrderStatusChange{Status: orderstatus.Delivered})
+		assert.True(t, orderstatus.IsErrInvalidTransition(err))
+		err = db.UpdateOrderStatus(ctx, "missing", change)
+		assert.True(t, IsErrOrderNotFound(err))
+
+		history, err := db.GetOrderStatusHistory(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, orderstatus.Paid, history.Status)
+		require.Len(t, history.History, 2)
+		assert.Equal(t, orderstatus.Created, history.History[0].To)
+		assert.Equal(t, orderstatus.Created, history.History[1].From)
+		assert.Equal(t, orderstatus.Paid, history.History[1].To)
+		assert.Equal(t, "paid", history.History[1].Reason)
+		page, err := db.ListOrders(ctx, &structs.OrderFilter{Status: orderstatus.Paid, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, orderUids(page.Orders))
+
+		_, err = db.GetOrderStatusHistory(ctx, "missing")
+		assert.True(t, IsErrOrderNotFound(err))
+	})
+
+	t.Run("Outbox", func(t *testing.T) {
+		db := newDatabase(t, DuplicateReject)
+		ctx := context.Background()
+		_, err := db.InsertOrders(ctx, []*structs.Order{conformanceOrder("a"), conformanceOrder("b")})
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateOrderStatus(ctx, "a", &structs.OrderStatusChange{Status: orderstatus.Paid}))
+
+		published, err := db.PublishOutbox(ctx, 10, func(context.Context, []*structs.OrderEvent) error {
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Zero(t, published)
+
+		var events []*structs.OrderEvent
+		collect := func(_ context.Context, batch []*structs.OrderEvent) error {
+			events = append(events, batch...)
+			return nil
+		}
+		published, err = db.PublishOutbox(ctx, 2, collect)
+		require.NoError(t, err)
+		assert.Equal(t, 2, published)
+		published, err = db.PublishOutbox(ctx, 2, collect)
+		require.NoError(t, err)
+		assert.Equal(t, 1, published)
+		published, err = db.PublishOutbox(ctx, 2, collect)
+		require.NoError(t, err)
+		assert.Zero(t, published)
+
+		require.Len(t, events, 3)
+		assert.Equal(t, structs.EventOrderCreated, events[0].Type)
+		assert.Equal(t, "a", events[0].OrderUid)
+		assert.Equal(t, "b", events[1].OrderUid)
+		assert.Equal(t, structs.EventOrderUpdated, events[2].Type)
+		assert.Equal(t, orderstatus.Paid, events[2].Status)
+		require.NotNil(t, events[2].StatusChange)
+
+		pruned, err := db.PruneOutbox(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, pruned)
+		pruned, err = db.PruneOutbox(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), pruned)
+	})
+
+	t.Run("HealthCheck", func(t *testing.T) {
+		db := newDatabase(t, DuplicateReject)
+
+		assert.NoError(t, db.HealthCheck(context.Background()))
+	})
+}
+
+// conformanceOrder returns a complete order with the given uid
+func conformanceOrder(uid string) *structs.Order {
+	return &structs.Order{
+		OrderUid:        uid,
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerId:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmId:            99,
+		DateCreated:     "2021-11-26T06:22:19Z",
+		OofShard:        "1",
+		Delivery: structs.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: structs.Payment{
+			Transaction:  "tx-" + uid,
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []structs.Item{{
+			ChrtId:      9934930,
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       453,
+			Rid:         "ab4219087a764ae0btest",
+			Name:        "Mascaras",
+			Sale:        30,
+			Size:        "0",
+			TotalPrice:  317,
+			NmId:        2389212,
+			Brand:       "Vivienne Sabo",
+			Status:      202,
+		}},
+	}
+}
+
+// assertSameOrder compares a stored order with the one that was inserted,
+// ignoring the status and the time zone DateCreated is rendered in
+func assertSameOrder(t *testing.T, expected, actual *structs.Order) {
+	t.Helper()
+	expectedDate, err := time.Parse(time.RFC3339, expected.DateCreated)
+	require.NoError(t, err)
+	actualDate, err := time.Parse(time.RFC3339, actual.DateCreated)
+	require.NoError(t, err)
+	assert.True(t, expectedDate.Equal(actualDate), actual.DateCreated)
+
+	want, got := *expected, *actual
+	want.DateCreated, got.DateCreated = "", ""
+	want.Status, got.Status = "", ""
+	assert.Equal(t, want, got)
+}
+
+func orderUids(orders []*structs.Order) []string {
+	uids := make([]string, len(orders))
+	for i, order := range orders {
+		uids[i] = order.OrderUid
+	}
+	return uids
+}
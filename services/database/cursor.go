@@ -0,0 +1,36 @@
+package database
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EncodeCursor builds an opaque pagination cursor pointing after the order
+// with the given creation time and internal id
+func EncodeCursor(dateCreated int64, id int64) string {
+	raw := fmt.Sprintf("%d:%d", dateCreated, id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor reverses EncodeCursor
+func DecodeCursor(cursor string) (int64, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor{Cursor: cursor}
+	}
+	dateCreatedPart, idPart, found := strings.Cut(string(raw), ":")
+	if !found {
+		return 0, 0, ErrInvalidCursor{Cursor: cursor}
+	}
+	dateCreated, err := strconv.ParseInt(dateCreatedPart, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor{Cursor: cursor}
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, 0, ErrInvalidCursor{Cursor: cursor}
+	}
+	return dateCreated, id, nil
+}
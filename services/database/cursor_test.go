@@ -0,0 +1,24 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := EncodeCursor(1637907739, 42)
+
+	dateCreated, id, err := DecodeCursor(cursor)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1637907739), dateCreated)
+	assert.Equal(t, int64(42), id)
+}
+
+func TestCursorInvalid(t *testing.T) {
+	for _, cursor := range []string{"%%%", "bm9jb2xvbg", "YTox", "MTow"} {
+		_, _, err := DecodeCursor(cursor)
+		assert.True(t, IsErrInvalidCursor(err), cursor)
+	}
+}
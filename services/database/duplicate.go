@@ -0,0 +1,66 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"wb-L0/structs"
+)
+
+// DuplicatePolicy decides what InsertOrder does with an order uid that is
+// already stored
+type DuplicatePolicy string
+
+const (
+	// DuplicateReject fails every duplicate with ErrOrderExists
+	DuplicateReject DuplicatePolicy = "reject"
+	// DuplicateIgnore accepts an identical redelivery with ErrOrderUnchanged
+	// and rejects a differing one with ErrOrderExists
+	DuplicateIgnore DuplicatePolicy = "ignore"
+	// DuplicateUpsert replaces the stored order, delivery, payment and items
+	// when the content differs
+	DuplicateUpsert DuplicatePolicy = "upsert"
+)
+
+// ParseDuplicatePolicy maps a config value to a policy; empty means reject
+func ParseDuplicatePolicy(value string) (DuplicatePolicy, error) {
+	switch policy := DuplicatePolicy(value); policy {
+	case "":
+		return DuplicateReject, nil
+	case DuplicateReject, DuplicateIgnore, DuplicateUpsert:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown duplicate policy: %s", value)
+	}
+}
+
+// outcome applies the policy to an order whose uid is already stored: nil
+// means the stored order is to be replaced, otherwise the error InsertOrder
+// returns
+func (policy DuplicatePolicy) outcome(uid, storedHash, hash string) error {
+	switch {
+	case policy == DuplicateReject:
+		return ErrOrderExists{Id: uid}
+	case storedHash == hash:
+		return ErrOrderUnchanged{Id: uid}
+	case policy == DuplicateUpsert:
+		return nil
+	default:
+		return ErrOrderExists{Id: uid}
+	}
+}
+
+// contentHash fingerprints the order so redeliveries can be compared without
+// loading the stored attributes. The status is not part of the content.
+func contentHash(order *structs.Order) (string, error) {
+	content := *order
+	content.Status = ""
+	data, err := json.Marshal(&content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/structs"
+)
+
+func TestParseDuplicatePolicy(t *testing.T) {
+	policy, err := ParseDuplicatePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, DuplicateReject, policy)
+
+	policy, err = ParseDuplicatePolicy("upsert")
+	require.NoError(t, err)
+	assert.Equal(t, DuplicateUpsert, policy)
+
+	_, err = ParseDuplicatePolicy("merge")
+	assert.Error(t, err)
+}
+
+func TestContentHash(t *testing.T) {
+	order := &structs.Order{OrderUid: "a", Items: []structs.Item{{ChrtId: 1}}}
+	same := &structs.Order{OrderUid: "a", Items: []structs.Item{{ChrtId: 1}}}
+	changed := &structs.Order{OrderUid: "a", Items: []structs.Item{{ChrtId: 2}}}
+
+	hash, err := contentHash(order)
+	require.NoError(t, err)
+	sameHash, err := contentHash(same)
+	require.NoError(t, err)
+	changedHash, err := contentHash(changed)
+	require.NoError(t, err)
+
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, sameHash)
+	assert.NotEqual(t, hash, changedHash)
+}
@@ -1,8 +1,20 @@
 package database
 
 import (
+	"context"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"net"
+
+	"gorm.io/gorm"
+)
+
+// SQLite primary result codes of data the database refuses to store
+const (
+	sqliteTooBig     = 18
+	sqliteConstraint = 19
+	sqliteMismatch   = 20
 )
 
 type ErrDataInvalid struct {
@@ -36,3 +48,97 @@ func IsErrOrderNotFound(err error) bool {
 func (e ErrOrderNotFound) Error() string {
 	return fmt.Sprintf("order with id: %s not found", e.Id)
 }
+
+type ErrOrderExists struct {
+	Id string
+}
+
+func IsErrOrderExists(err error) bool {
+	return errors.As(err, new(ErrOrderExists))
+}
+
+func (e ErrOrderExists) Error() string {
+	return fmt.Sprintf("order with id: %s already exists", e.Id)
+}
+
+// ErrOrderUnchanged reports a redelivered order identical to the stored one
+type ErrOrderUnchanged struct {
+	Id string
+}
+
+func IsErrOrderUnchanged(err error) bool {
+	return errors.As(err, new(ErrOrderUnchanged))
+}
+
+func (e ErrOrderUnchanged) Error() string {
+	return fmt.Sprintf("order with id: %s is already stored unchanged", e.Id)
+}
+
+type ErrInvalidCursor struct {
+	Cursor string
+}
+
+func IsErrInvalidCursor(err error) bool {
+	return errors.As(err, new(ErrInvalidCursor))
+}
+
+func (e ErrInvalidCursor) Error() string {
+	return fmt.Sprintf("invalid pagination cursor: %s", e.Cursor)
+}
+
+// insertError reports a constraint or data violation of an insert as
+// ErrDataInvalid, which a redelivery cannot fix. Other failures, such as a
+// lost connection or an expired context, are returned unchanged so the
+// message is retried.
+func insertError(err error) error {
+	if err == nil || IsErrDataInvalid(err) || IsErrOrderExists(err) || IsErrOrderUnchanged(err) {
+		return err
+	}
+	if isDataViolation(err) {
+		return ErrDataInvalid{err.Error()}
+	}
+	return err
+}
+
+func isDataViolation(err error) bool {
+	for _, violation := range []error{gorm.ErrDuplicatedKey, gorm.ErrForeignKeyViolated,
+		gorm.ErrCheckConstraintViolated, gorm.ErrInvalidData, gorm.ErrInvalidValue} {
+		if errors.Is(err, violation) {
+			return true
+		}
+	}
+	// Postgres data exceptions (class 22) and integrity constraint
+	// violations (class 23)
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		state := pgErr.SQLState()
+		return len(state) == 5 && (state[:2] == "22" || state[:2] == "23")
+	}
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() & 0xff {
+		case sqliteTooBig, sqliteConstraint, sqliteMismatch:
+			return true
+		}
+	}
+	return false
+}
+
+// IsErrUnavailable reports whether err means the database could not be
+// reached or did not answer in time, which a later attempt may not hit
+func IsErrUnavailable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	// Postgres connection exceptions (class 08) and server shutdowns (57P)
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		state := pgErr.SQLState()
+		return len(state) == 5 && (state[:2] == "08" || state[:3] == "57P")
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"wb-L0/modules/migrations"
+	"wb-L0/modules/sqlite"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestInsertError(t *testing.T) {
+	for _, err := range []error{
+		gorm.ErrDuplicatedKey,
+		fmt.Errorf("insert: %w", gorm.ErrForeignKeyViolated),
+		sqlStateError("23502"),
+		sqlStateError("22001"),
+	} {
+		assert.True(t, IsErrDataInvalid(insertError(err)), err.Error())
+	}
+	for _, err := range []error{
+		context.DeadlineExceeded,
+		sqlStateError("08006"),
+		sqlStateError("57P01"),
+		errors.New("connection refused"),
+	} {
+		assert.Equal(t, err, insertError(err), err.Error())
+	}
+	exists := ErrOrderExists{Id: "a"}
+	assert.Equal(t, exists, insertError(exists))
+	assert.NoError(t, insertError(nil))
+}
+
+func TestIsErrUnavailable(t *testing.T) {
+	for _, err := range []error{
+		fmt.Errorf("insert: %w", context.DeadlineExceeded),
+		&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+		sqlStateError("08006"),
+		sqlStateError("57P01"),
+	} {
+		assert.True(t, IsErrUnavailable(err), err.Error())
+	}
+	for _, err := range []error{
+		ErrDataInvalid{"too long"},
+		sqlStateError("23505"),
+		errors.New("unexpected"),
+	} {
+		assert.False(t, IsErrUnavailable(err), err.Error())
+	}
+}
+
+func TestInsertErrorSqlite(t *testing.T) {
+	db, err := sqlite.Connect(filepath.Join(t.TempDir(), "orders.db"))
+	require.NoError(t, err)
+	db.Logger = logger.Discard
+	require.NoError(t, migrations.Run(context.Background(), db))
+	engine := &sqlite.Sqlite{Db: db}
+	t.Cleanup(func() {
+		engine.Shutdown(context.Background())
+	})
+
+	err = db.Exec(`INSERT INTO "order" (uid) VALUES (NULL)`).Error
+	require.Error(t, err)
+	assert.True(t, IsErrDataInvalid(insertError(err)))
+
+	// An insert that cannot reach the database is retried, not rejected
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = NewSqlite(engine, DuplicateReject).InsertOrder(ctx, conformanceOrder("cancelled"))
+	require.Error(t, err)
+	assert.False(t, IsErrDataInvalid(err))
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,463 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"wb-L0/models/pg_models"
+	"wb-L0/modules/convert"
+	"wb-L0/modules/monitoring"
+	"wb-L0/modules/pg"
+	"wb-L0/modules/sqlite"
+	"wb-L0/services/orderstatus"
+	"wb-L0/structs"
+)
+
+// insertBatchSize bounds the rows per INSERT statement so large batches stay
+// under the bind parameter limit
+const insertBatchSize = 100
+
+// Engine is a connected database module
+type Engine interface {
+	GetEngine(ctx context.Context) *gorm.DB
+}
+
+// GormDatabase stores orders through the GORM models. The queries are shared
+// by Postgres and SQLite; SQLite ignores row locks and serializes
+// transactions on its single connection instead.
+type GormDatabase struct {
+	db              Engine
+	duplicatePolicy DuplicatePolicy
+}
+
+func NewPostgres(postgres *pg.Postgres, duplicatePolicy DuplicatePolicy) *GormDatabase {
+	return &GormDatabase{
+		db:              postgres,
+		duplicatePolicy: duplicatePolicy,
+	}
+}
+
+func NewSqlite(sqlite *sqlite.Sqlite, duplicatePolicy DuplicatePolicy) *GormDatabase {
+	return &GormDatabase{
+		db:              sqlite,
+		duplicatePolicy: duplicatePolicy,
+	}
+}
+
+// InsertOrder stores a new order. An already stored uid is handled according
+// to the duplicate policy: ErrOrderExists when rejected, ErrOrderUnchanged
+// when the content is identical and nil when the order was replaced.
+func (p *GormDatabase) InsertOrder(ctx context.Context, order *structs.Order) error {
+	toInsertOrder, err := toPgOrder(order)
+	if err != nil {
+		return err
+	}
+	err = p.db.GetEngine(ctx).Transaction(func(tx *gorm.DB) error {
+		eventType := structs.EventOrderCreated
+		stored, err := pg_models.GetOrderForUpdate(tx, order.OrderUid)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// There is no row to lock, so a concurrent insert of the uid can
+			// win the unique key. The savepoint keeps the transaction usable
+			// to apply the policy to the order that won.
+			err = tx.Transaction(func(tx *gorm.DB) error {
+				return pg_models.InsertOrder(tx, toInsertOrder)
+			})
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				stored, err = pg_models.GetOrderForUpdate(tx, order.OrderUid)
+			} else {
+				stored = nil
+			}
+		}
+		switch {
+		case err != nil:
+			return err
+		case stored != nil:
+			err = p.duplicatePolicy.outcome(order.OrderUid, stored.ContentHash, toInsertOrder.ContentHash)
+			if err != nil {
+				return err
+			}
+			// A replaced order keeps its lifecycle
+			eventType = structs.EventOrderUpdated
+			toInsertOrder.Status = stored.Status
+			toInsertOrder.History = nil
+			err = pg_models.ReplaceOrder(tx, stored.Id, toInsertOrder)
+		}
+		if err != nil {
+			return err
+		}
+		event, err := orderEvent(eventType, order, toInsertOrder.Status)
+		if err != nil {
+			return err
+		}
+		return pg_models.InsertOutboxEvents(tx, []*pg_models.OutboxEvent{event})
+	})
+	return insertError(err)
+}
+
+// toPgOrder converts a new order to its model with the created status and
+// the first history entry
+func toPgOrder(order *structs.Order) (*pg_models.Order, error) {
+	toInsertOrder, err := convert.ApiToPgOrder(order)
+	if err != nil {
+		return nil, ErrDataInvalid{fmt.Sprintf("order %s: %s", order.OrderUid, err.Error())}
+	}
+	toInsertOrder.ContentHash, err = contentHash(order)
+	if err != nil {
+		return nil, ErrDataInvalid{fmt.Sprintf("order %s: %s", order.OrderUid, err.Error())}
+	}
+	toInsertOrder.Status = orderstatus.Created
+	toInsertOrder.History = []*pg_models.OrderStatusHistory{{
+		ToStatus:  orderstatus.Created,
+		Source:    orderstatus.SourceIngest,
+		ChangedAt: time.Now().Unix(),
+	}}
+	return toInsertOrder, nil
+}
+
+// toPgOrders converts a batch of new orders, rejecting a uid that repeats
+func toPgOrders(orders []*structs.Order) ([]*pg_models.Order, error) {
+	toInsertOrders := make([]*pg_models.Order, len(orders))
+	seen := make(map[string]bool, len(orders))
+	for i, order := range orders {
+		if seen[order.OrderUid] {
+			return nil, ErrOrderExists{Id: order.OrderUid}
+		}
+		seen[order.OrderUid] = true
+		toInsertOrder, err := toPgOrder(order)
+		if err != nil {
+			return nil, err
+		}
+		toInsertOrders[i] = toInsertOrder
+	}
+	return toInsertOrders, nil
+}
+
+// UpdateOrderStatus moves the order to change.Status and records the
+// transition. The order row is locked so concurrent changes are applied one
+// after another against the current status.
+func (p *GormDatabase) UpdateOrderStatus(ctx context.Context, oid string, change *structs.OrderStatusChange) error {
+	start := time.Now()
+	defer func() {
+		monitoring.ObserveDatabaseQueryDuration("update_status", "orders", time.Since(start))
+		monitoring.IncrementDatabaseQueries("update_status", "orders")
+	}()
+
+	return p.db.GetEngine(ctx).Transaction(func(tx *gorm.DB) error {
+		stored, err := pg_models.GetOrderForUpdate(tx, oid)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound{Id: oid}
+			}
+			return err
+		}
+		if stored.Status == change.Status {
+			return ErrOrderUnchanged{Id: oid}
+		}
+		if !orderstatus.CanTransition(stored.Status, change.Status) {
+			return orderstatus.ErrInvalidTransition{From: stored.Status, To: change.Status}
+		}
+		err = pg_models.UpdateOrderStatus(tx, stored.Id, change.Status)
+		if err != nil {
+			return err
+		}
+		entry := &pg_models.OrderStatusHistory{
+			OrderId:    stored.Id,
+			FromStatus: stored.Status,
+			ToStatus:   change.Status,
+			Reason:     change.Reason,
+			Source:     change.Source,
+			ChangedAt:  time.Now().Unix(),
+		}
+		err = pg_models.InsertOrderStatusHistory(tx, entry)
+		if err != nil {
+			return err
+		}
+		event, err := newOutboxEvent(&structs.OrderEvent{
+			Type:         structs.EventOrderUpdated,
+			OrderUid:     oid,
+			Status:       change.Status,
+			StatusChange: convert.PgToApiStatusEntry(entry),
+		})
+		if err != nil {
+			return err
+		}
+		return pg_models.InsertOutboxEvents(tx, []*pg_models.OutboxEvent{event})
+	})
+}
+
+func (p *GormDatabase) GetOrderStatusHistory(ctx context.Context, oid string) (*structs.OrderStatusHistory, error) {
+	start := time.Now()
+	defer func() {
+		monitoring.ObserveDatabaseQueryDuration("select_status", "orders", time.Since(start))
+		monitoring.IncrementDatabaseQueries("select_status", "orders")
+	}()
+
+	db := p.db.GetEngine(ctx)
+	order, err := pg_models.GetOrderByUid(db, oid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound{Id: oid}
+		}
+		return nil, err
+	}
+	entries, err := pg_models.GetOrderStatusHistory(db, order.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &structs.OrderStatusHistory{
+		OrderUid: order.Uid,
+		Status:   order.Status,
+		History:  convert.PgToApiStatusEntries(entries),
+	}, nil
+}
+
+// InsertOrders stores new orders with multi-row inserts and replaces changed
+// ones one by one, all in a single transaction. Either every write happens or
+// none does, so a caller can retry the orders one by one to find the
+// offending record.
+func (p *GormDatabase) InsertOrders(ctx context.Context, orders []*structs.Order) ([]error, error) {
+	results := make([]error, len(orders))
+	if len(orders) == 0 {
+		return results, nil
+	}
+	start := time.Now()
+	defer func() {
+		monitoring.ObserveDatabaseQueryDuration("insert_batch", "orders", time.Since(start))
+		monitoring.IncrementDatabaseQueries("insert_batch", "orders")
+	}()
+
+	toInsertOrders, err := toPgOrders(orders)
+	if err != nil {
+		return nil, err
+	}
+	uids := make([]string, len(orders))
+	for i, order := range orders {
+		uids[i] = order.OrderUid
+	}
+	err = p.db.GetEngine(ctx).Transaction(func(tx *gorm.DB) error {
+		stored, err := pg_models.GetOrdersForUpdate(tx, uids)
+		if err != nil {
+			return err
+		}
+		storedByUid := make(map[string]*pg_models.Order, len(stored))
+		for _, order := range stored {
+			storedByUid[order.Uid] = order
+		}
+		inserts := make([]*pg_models.Order, 0, len(orders))
+		events := make([]*pg_models.OutboxEvent, 0, len(orders))
+		for i, order := range orders {
+			toInsertOrder := toInsertOrders[i]
+			eventType := structs.EventOrderCreated
+			existing, ok := storedByUid[order.OrderUid]
+			if ok {
+				results[i] = p.duplicatePolicy.outcome(order.OrderUid, existing.ContentHash, toInsertOrder.ContentHash)
+				if results[i] != nil {
+					continue
+				}
+				eventType = structs.EventOrderUpdated
+				toInsertOrder.Status = existing.Status
+				toInsertOrder.History = nil
+				err = pg_models.ReplaceOrder(tx, existing.Id, toInsertOrder)
+				if err != nil {
+					return err
+				}
+			} else {
+				inserts = append(inserts, toInsertOrder)
+			}
+			event, err := orderEvent(eventType, order, toInsertOrder.Status)
+			if err != nil {
+				return ErrDataInvalid{fmt.Sprintf("order %s: %s", order.OrderUid, err.Error())}
+			}
+			events = append(events, event)
+		}
+		if len(inserts) > 0 {
+			err = pg_models.InsertOrders(tx, inserts, insertBatchSize)
+			if err != nil {
+				return err
+			}
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return pg_models.InsertOutboxEvents(tx, events)
+	})
+	if err != nil {
+		return nil, insertError(err)
+	}
+	return results, nil
+}
+
+func (p *GormDatabase) GetOrderById(ctx context.Context, oid string) (*structs.Order, error) {
+	start := time.Now()
+	defer func() {
+		monitoring.ObserveDatabaseQueryDuration("select", "orders", time.Since(start))
+		monitoring.IncrementDatabaseQueries("select", "orders")
+	}()
+
+	order, err := pg_models.GetOrderById(p.db.GetEngine(ctx), oid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound{Id: oid}
+		}
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound{Id: oid}
+	}
+	if err = checkAttributes(order); err != nil {
+		return nil, err
+	}
+	return convert.PgToApiOrder(order), nil
+}
+
+func (p *GormDatabase) GetOrdersByIds(ctx context.Context, oids []string) ([]*structs.Order, error) {
+	start := time.Now()
+	defer func() {
+		monitoring.ObserveDatabaseQueryDuration("select_batch", "orders", time.Since(start))
+		monitoring.IncrementDatabaseQueries("select_batch", "orders")
+	}()
+
+	if len(oids) == 0 {
+		return make([]*structs.Order, 0), nil
+	}
+	orders, err := pg_models.GetOrdersByUids(p.db.GetEngine(ctx), oids)
+	if err != nil {
+		return nil, ErrInternal{Err: err.Error()}
+	}
+	result := make([]*structs.Order, len(orders))
+	for i, order := range orders {
+		if err = checkAttributes(order); err != nil {
+			return nil, err
+		}
+		result[i] = convert.PgToApiOrder(order)
+	}
+	return result, nil
+}
+
+func (p *GormDatabase) ListOrders(ctx context.Context, filter *structs.OrderFilter) (*structs.OrderPage, error) {
+	start := time.Now()
+	defer func() {
+		monitoring.ObserveDatabaseQueryDuration("list", "orders", time.Since(start))
+		monitoring.IncrementDatabaseQueries("list", "orders")
+	}()
+
+	listFilter, err := toListFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := pg_models.ListOrders(p.db.GetEngine(ctx), listFilter)
+	if err != nil {
+		return nil, ErrInternal{Err: err.Error()}
+	}
+	return listPage(orders, filter.Limit)
+}
+
+// toListFilter converts a listing request to the model filter. One extra row
+// is requested to tell whether another page follows.
+func toListFilter(filter *structs.OrderFilter) (*pg_models.OrderListFilter, error) {
+	listFilter := &pg_models.OrderListFilter{
+		CustomerId:      filter.CustomerId,
+		TrackNumber:     filter.TrackNumber,
+		DeliveryService: filter.DeliveryService,
+		Locale:          filter.Locale,
+		Provider:        filter.Provider,
+		Bank:            filter.Bank,
+		Brand:           filter.Brand,
+		NmId:            filter.NmId,
+		Status:          filter.Status,
+		Limit:           filter.Limit + 1,
+	}
+	if !filter.DateFrom.IsZero() {
+		listFilter.DateFrom = filter.DateFrom.Unix()
+	}
+	if !filter.DateTo.IsZero() {
+		listFilter.DateTo = filter.DateTo.Unix()
+	}
+	if filter.Cursor != "" {
+		dateCreated, id, err := DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		listFilter.AfterDateCreated = dateCreated
+		listFilter.AfterId = id
+	}
+	return listFilter, nil
+}
+
+// listPage cuts the rows fetched for toListFilter to limit and points the
+// cursor at the last returned order when more rows follow
+func listPage(orders []*pg_models.Order, limit int) (*structs.OrderPage, error) {
+	page := &structs.OrderPage{}
+	if len(orders) > limit {
+		orders = orders[:limit]
+		last := orders[len(orders)-1]
+		page.NextCursor = EncodeCursor(last.DateCreated, last.Id)
+	}
+	page.Orders = make([]*structs.Order, len(orders))
+	for i, order := range orders {
+		if err := checkAttributes(order); err != nil {
+			return nil, err
+		}
+		page.Orders[i] = convert.PgToApiOrder(order)
+	}
+	return page, nil
+}
+
+func (p *GormDatabase) FindOrderIdsByTrackNumber(ctx context.Context, trackNumber string) ([]string, error) {
+	start := time.Now()
+	defer func() {
+		monitoring.ObserveDatabaseQueryDuration("select_uid", "orders", time.Since(start))
+		monitoring.IncrementDatabaseQueries("select_uid", "orders")
+	}()
+
+	uids, err := pg_models.GetOrderUidsByTrackNumber(p.db.GetEngine(ctx), trackNumber)
+	if err != nil {
+		return nil, ErrInternal{Err: err.Error()}
+	}
+	return uids, nil
+}
+
+func (p *GormDatabase) FindOrderIdsByTransaction(ctx context.Context, transaction string) ([]string, error) {
+	return p.findOrderIdsByPayment(ctx, &pg_models.OrderPayment{Transaction: transaction})
+}
+
+func (p *GormDatabase) FindOrderIdsByRequestId(ctx context.Context, requestId string) ([]string, error) {
+	return p.findOrderIdsByPayment(ctx, &pg_models.OrderPayment{RequestId: requestId})
+}
+
+func (p *GormDatabase) findOrderIdsByPayment(ctx context.Context, payment *pg_models.OrderPayment) ([]string, error) {
+	start := time.Now()
+	defer func() {
+		monitoring.ObserveDatabaseQueryDuration("select_uid", "order_payment", time.Since(start))
+		monitoring.IncrementDatabaseQueries("select_uid", "order_payment")
+	}()
+
+	uids, err := pg_models.GetOrderUidsByPayment(p.db.GetEngine(ctx), payment)
+	if err != nil {
+		return nil, ErrInternal{Err: err.Error()}
+	}
+	return uids, nil
+}
+
+// checkAttributes guards against orders whose delivery or payment row is
+// missing, which the LEFT JOINs leave as nil
+func checkAttributes(order *pg_models.Order) error {
+	if order.Delivery == nil || order.Payment == nil {
+		return ErrInternal{Err: fmt.Sprintf("order %s has no delivery or payment", order.Uid)}
+	}
+	return nil
+}
+
+// HealthCheck performs a health check on the database
+func (p *GormDatabase) HealthCheck(ctx context.Context) error {
+	// Simple ping query to check database connectivity
+	sqlDB, err := p.db.GetEngine(ctx).DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"wb-L0/structs"
 )
@@ -9,8 +10,34 @@ import (
 var dbInstance Database
 
 type Database interface {
+	// InsertOrder stores the order, applying the duplicate policy to known uids
 	InsertOrder(context.Context, *structs.Order) error
+	// InsertOrders stores the orders atomically, applying the duplicate
+	// policy to uids already stored. The result holds for each order what
+	// InsertOrder would return: nil when it was stored or replaced,
+	// ErrOrderExists or ErrOrderUnchanged. On error none of them is stored; a
+	// uid repeated within orders is an error.
+	InsertOrders(ctx context.Context, orders []*structs.Order) ([]error, error)
 	GetOrderById(ctx context.Context, oid string) (*structs.Order, error)
+	// GetOrdersByIds returns the stored orders among oids; unknown ids are omitted
+	GetOrdersByIds(ctx context.Context, oids []string) ([]*structs.Order, error)
+	// FindOrderIdsByTrackNumber resolves an order or item track number to order uids
+	FindOrderIdsByTrackNumber(ctx context.Context, trackNumber string) ([]string, error)
+	// FindOrderIdsByTransaction resolves a payment transaction id to order uids
+	FindOrderIdsByTransaction(ctx context.Context, transaction string) ([]string, error)
+	// FindOrderIdsByRequestId resolves a payment request id to order uids
+	FindOrderIdsByRequestId(ctx context.Context, requestId string) ([]string, error)
+	// ListOrders returns one page of orders matching filter, newest first
+	ListOrders(ctx context.Context, filter *structs.OrderFilter) (*structs.OrderPage, error)
+	// UpdateOrderStatus applies a status transition and records it in the history
+	UpdateOrderStatus(ctx context.Context, oid string, change *structs.OrderStatusChange) error
+	// GetOrderStatusHistory returns the current status and all recorded transitions
+	GetOrderStatusHistory(ctx context.Context, oid string) (*structs.OrderStatusHistory, error)
+	// PublishOutbox passes up to limit undelivered order events, oldest first,
+	// to publish and marks them delivered when it succeeds
+	PublishOutbox(ctx context.Context, limit int, publish func(context.Context, []*structs.OrderEvent) error) (int, error)
+	// PruneOutbox deletes events delivered before the given time
+	PruneOutbox(ctx context.Context, before time.Time) (int64, error)
 	HealthCheck(ctx context.Context) error
 }
 
@@ -0,0 +1,377 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"wb-L0/models/pg_models"
+	"wb-L0/modules/convert"
+	"wb-L0/services/orderstatus"
+	"wb-L0/structs"
+)
+
+// MemoryDatabase keeps orders in maps for tests and local runs. It stores the
+// same models as GormDatabase and follows the same rules, so callers cannot
+// tell the two apart except that nothing survives a restart.
+type MemoryDatabase struct {
+	mu              sync.RWMutex
+	orders          map[string]*pg_models.Order
+	lastOrderId     int64
+	outbox          []*pg_models.OutboxEvent
+	lastEventId     int64
+	duplicatePolicy DuplicatePolicy
+	// relay is held while events are published so relays never interleave
+	relay sync.Mutex
+}
+
+func NewMemory(duplicatePolicy DuplicatePolicy) *MemoryDatabase {
+	return &MemoryDatabase{
+		orders:          make(map[string]*pg_models.Order),
+		duplicatePolicy: duplicatePolicy,
+	}
+}
+
+// InsertOrder stores a new order, applying the duplicate policy like
+// GormDatabase.InsertOrder
+func (m *MemoryDatabase) InsertOrder(_ context.Context, order *structs.Order) error {
+	toInsertOrder, err := toPgOrder(order)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	eventType := structs.EventOrderCreated
+	stored, ok := m.orders[order.OrderUid]
+	switch {
+	case !ok:
+		m.lastOrderId++
+		toInsertOrder.Id = m.lastOrderId
+	default:
+		err = m.duplicatePolicy.outcome(order.OrderUid, stored.ContentHash, toInsertOrder.ContentHash)
+		if err != nil {
+			return err
+		}
+		// A replaced order keeps its lifecycle
+		eventType = structs.EventOrderUpdated
+		toInsertOrder.Id = stored.Id
+		toInsertOrder.Status = stored.Status
+		toInsertOrder.History = stored.History
+	}
+	event, err := orderEvent(eventType, order, toInsertOrder.Status)
+	if err != nil {
+		return ErrDataInvalid{err.Error()}
+	}
+	m.orders[order.OrderUid] = toInsertOrder
+	m.appendOutbox(event)
+	return nil
+}
+
+// InsertOrders stores the orders, applying the duplicate policy like
+// GormDatabase.InsertOrders
+func (m *MemoryDatabase) InsertOrders(_ context.Context, orders []*structs.Order) ([]error, error) {
+	toInsertOrders, err := toPgOrders(orders)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := make([]error, len(orders))
+	events := make([]*pg_models.OutboxEvent, len(orders))
+	for i, order := range orders {
+		toInsertOrder := toInsertOrders[i]
+		eventType := structs.EventOrderCreated
+		if stored, ok := m.orders[order.OrderUid]; ok {
+			results[i] = m.duplicatePolicy.outcome(order.OrderUid, stored.ContentHash, toInsertOrder.ContentHash)
+			if results[i] != nil {
+				continue
+			}
+			eventType = structs.EventOrderUpdated
+			toInsertOrder.Id = stored.Id
+			toInsertOrder.Status = stored.Status
+			toInsertOrder.History = stored.History
+		}
+		events[i], err = orderEvent(eventType, order, toInsertOrder.Status)
+		if err != nil {
+			return nil, ErrDataInvalid{err.Error()}
+		}
+	}
+	for i, order := range toInsertOrders {
+		if events[i] == nil {
+			continue
+		}
+		if order.Id == 0 {
+			m.lastOrderId++
+			order.Id = m.lastOrderId
+		}
+		m.orders[order.Uid] = order
+		m.appendOutbox(events[i])
+	}
+	return results, nil
+}
+
+func (m *MemoryDatabase) GetOrderById(_ context.Context, oid string) (*structs.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	order, ok := m.orders[oid]
+	if !ok {
+		return nil, ErrOrderNotFound{Id: oid}
+	}
+	return convert.PgToApiOrder(order), nil
+}
+
+func (m *MemoryDatabase) GetOrdersByIds(_ context.Context, oids []string) ([]*structs.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*structs.Order, 0, len(oids))
+	seen := make(map[string]bool, len(oids))
+	for _, oid := range oids {
+		order, ok := m.orders[oid]
+		if !ok || seen[oid] {
+			continue
+		}
+		seen[oid] = true
+		result = append(result, convert.PgToApiOrder(order))
+	}
+	return result, nil
+}
+
+func (m *MemoryDatabase) FindOrderIdsByTrackNumber(_ context.Context, trackNumber string) ([]string, error) {
+	return m.findOrderIds(func(order *pg_models.Order) bool {
+		if order.TrackNumber == trackNumber {
+			return true
+		}
+		for _, item := range order.Items {
+			if item.TrackNumber == trackNumber {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (m *MemoryDatabase) FindOrderIdsByTransaction(_ context.Context, transaction string) ([]string, error) {
+	return m.findOrderIds(func(order *pg_models.Order) bool {
+		return order.Payment.Transaction == transaction
+	}), nil
+}
+
+func (m *MemoryDatabase) FindOrderIdsByRequestId(_ context.Context, requestId string) ([]string, error) {
+	return m.findOrderIds(func(order *pg_models.Order) bool {
+		return order.Payment.RequestId == requestId
+	}), nil
+}
+
+// findOrderIds returns uids of the matching orders in insertion order
+func (m *MemoryDatabase) findOrderIds(match func(*pg_models.Order) bool) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matched := make([]*pg_models.Order, 0)
+	for _, order := range m.orders {
+		if match(order) {
+			matched = append(matched, order)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Id < matched[j].Id
+	})
+	uids := make([]string, len(matched))
+	for i, order := range matched {
+		uids[i] = order.Uid
+	}
+	return uids
+}
+
+func (m *MemoryDatabase) ListOrders(_ context.Context, filter *structs.OrderFilter) (*structs.OrderPage, error) {
+	listFilter, err := toListFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orders := make([]*pg_models.Order, 0)
+	for _, order := range m.orders {
+		if matchesListFilter(order, listFilter) {
+			orders = append(orders, order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].DateCreated != orders[j].DateCreated {
+			return orders[i].DateCreated > orders[j].DateCreated
+		}
+		return orders[i].Id > orders[j].Id
+	})
+	if len(orders) > listFilter.Limit {
+		orders = orders[:listFilter.Limit]
+	}
+	return listPage(orders, filter.Limit)
+}
+
+// matchesListFilter applies the conditions of pg_models.ListOrders to a
+// single order
+func matchesListFilter(order *pg_models.Order, filter *pg_models.OrderListFilter) bool {
+	switch {
+	case filter.CustomerId != "" && order.CustomerId != filter.CustomerId,
+		filter.TrackNumber != "" && order.TrackNumber != filter.TrackNumber,
+		filter.DeliveryService != "" && order.DeliveryService != filter.DeliveryService,
+		filter.Locale != "" && order.Locale != filter.Locale,
+		filter.Status != "" && order.Status != filter.Status,
+		filter.DateFrom != 0 && order.DateCreated < filter.DateFrom,
+		filter.DateTo != 0 && order.DateCreated > filter.DateTo,
+		filter.Provider != "" && order.Payment.Provider != filter.Provider,
+		filter.Bank != "" && order.Payment.Bank != filter.Bank:
+		return false
+	}
+	if filter.AfterId != 0 {
+		if order.DateCreated > filter.AfterDateCreated ||
+			order.DateCreated == filter.AfterDateCreated && order.Id >= filter.AfterId {
+			return false
+		}
+	}
+	if filter.Brand == "" && filter.NmId == 0 {
+		return true
+	}
+	brand, nmId := filter.Brand == "", filter.NmId == 0
+	for _, item := range order.Items {
+		brand = brand || item.Brand == filter.Brand
+		nmId = nmId || item.NmId == filter.NmId
+	}
+	return brand && nmId
+}
+
+// UpdateOrderStatus moves the order to change.Status and records the
+// transition
+func (m *MemoryDatabase) UpdateOrderStatus(_ context.Context, oid string, change *structs.OrderStatusChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.orders[oid]
+	if !ok {
+		return ErrOrderNotFound{Id: oid}
+	}
+	if stored.Status == change.Status {
+		return ErrOrderUnchanged{Id: oid}
+	}
+	if !orderstatus.CanTransition(stored.Status, change.Status) {
+		return orderstatus.ErrInvalidTransition{From: stored.Status, To: change.Status}
+	}
+	entry := &pg_models.OrderStatusHistory{
+		OrderId:    stored.Id,
+		FromStatus: stored.Status,
+		ToStatus:   change.Status,
+		Reason:     change.Reason,
+		Source:     change.Source,
+		ChangedAt:  time.Now().Unix(),
+	}
+	event, err := newOutboxEvent(&structs.OrderEvent{
+		Type:         structs.EventOrderUpdated,
+		OrderUid:     oid,
+		Status:       change.Status,
+		StatusChange: convert.PgToApiStatusEntry(entry),
+	})
+	if err != nil {
+		return err
+	}
+	stored.Status = change.Status
+	stored.History = append(stored.History, entry)
+	m.appendOutbox(event)
+	return nil
+}
+
+func (m *MemoryDatabase) GetOrderStatusHistory(_ context.Context, oid string) (*structs.OrderStatusHistory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	order, ok := m.orders[oid]
+	if !ok {
+		return nil, ErrOrderNotFound{Id: oid}
+	}
+	return &structs.OrderStatusHistory{
+		OrderUid: order.Uid,
+		Status:   order.Status,
+		History:  convert.PgToApiStatusEntries(order.History),
+	}, nil
+}
+
+// appendOutbox queues an event; the caller holds mu
+func (m *MemoryDatabase) appendOutbox(event *pg_models.OutboxEvent) {
+	m.lastEventId++
+	event.Id = m.lastEventId
+	m.outbox = append(m.outbox, event)
+}
+
+// PublishOutbox hands the oldest undelivered events to publish and marks them
+// delivered once it returns without error. A relay that finds another one
+// publishing returns without publishing anything.
+func (m *MemoryDatabase) PublishOutbox(ctx context.Context, limit int,
+	publish func(context.Context, []*structs.OrderEvent) error) (int, error) {
+	if !m.relay.TryLock() {
+		return 0, nil
+	}
+	defer m.relay.Unlock()
+
+	m.mu.RLock()
+	rows := make([]*pg_models.OutboxEvent, 0, limit)
+	for _, row := range m.outbox {
+		if len(rows) == limit {
+			break
+		}
+		if row.PublishedAt == nil {
+			rows = append(rows, row)
+		}
+	}
+	m.mu.RUnlock()
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	events := make([]*structs.OrderEvent, len(rows))
+	for i, row := range rows {
+		events[i] = new(structs.OrderEvent)
+		err := json.Unmarshal(row.Payload, events[i])
+		if err != nil {
+			return 0, ErrInternal{err.Error()}
+		}
+	}
+	err := publish(ctx, events)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	publishedAt := time.Now().Unix()
+	for _, row := range rows {
+		row.PublishedAt = &publishedAt
+	}
+	return len(events), nil
+}
+
+// PruneOutbox deletes events published before the given time
+func (m *MemoryDatabase) PruneOutbox(_ context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.outbox[:0]
+	for _, row := range m.outbox {
+		if row.PublishedAt == nil || *row.PublishedAt >= before.Unix() {
+			kept = append(kept, row)
+		}
+	}
+	deleted := int64(len(m.outbox) - len(kept))
+	clear(m.outbox[len(kept):])
+	m.outbox = kept
+	return deleted, nil
+}
+
+func (m *MemoryDatabase) HealthCheck(_ context.Context) error {
+	return nil
+}
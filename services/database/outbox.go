@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wb-L0/models/pg_models"
+	"wb-L0/modules/monitoring"
+	"wb-L0/structs"
+)
+
+// orderEvent builds the outbox row announcing that the order content was
+// stored with the given status
+func orderEvent(eventType string, order *structs.Order, status string) (*pg_models.OutboxEvent, error) {
+	content := *order
+	content.Status = status
+	return newOutboxEvent(&structs.OrderEvent{
+		Type:     eventType,
+		OrderUid: order.OrderUid,
+		Status:   status,
+		Order:    &content,
+	})
+}
+
+func newOutboxEvent(event *structs.OrderEvent) (*pg_models.OutboxEvent, error) {
+	event.Id = uuid.New().String()
+	event.OccurredAt = time.Now().UTC()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &pg_models.OutboxEvent{
+		EventId:     event.Id,
+		EventType:   event.Type,
+		AggregateId: event.OrderUid,
+		Payload:     payload,
+		CreatedAt:   event.OccurredAt.Unix(),
+	}, nil
+}
+
+// PublishOutbox hands the oldest undelivered events to publish and marks them
+// delivered once it returns without error. Rows stay locked by an advisory
+// lock for the whole call, so concurrent relays never publish out of order;
+// a relay that does not get the lock publishes nothing. A crash between
+// publishing and commit delivers the events again with the same ids.
+func (p *GormDatabase) PublishOutbox(ctx context.Context, limit int,
+	publish func(context.Context, []*structs.OrderEvent) error) (int, error) {
+	start := time.Now()
+	defer func() {
+		monitoring.ObserveDatabaseQueryDuration("publish", "outbox", time.Since(start))
+		monitoring.IncrementDatabaseQueries("publish", "outbox")
+	}()
+
+	published := 0
+	err := p.db.GetEngine(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := pg_models.TryOutboxLock(tx)
+		if err != nil || !locked {
+			return err
+		}
+		rows, err := pg_models.GetUnpublishedOutboxEvents(tx, limit)
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		events := make([]*structs.OrderEvent, len(rows))
+		ids := make([]int64, len(rows))
+		for i, row := range rows {
+			events[i] = new(structs.OrderEvent)
+			err = json.Unmarshal(row.Payload, events[i])
+			if err != nil {
+				return ErrInternal{err.Error()}
+			}
+			ids[i] = row.Id
+		}
+		err = publish(ctx, events)
+		if err != nil {
+			return err
+		}
+		published = len(events)
+		return pg_models.MarkOutboxEventsPublished(tx, ids, time.Now().Unix())
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, nil
+}
+
+// PruneOutbox deletes events published before the given time
+func (p *GormDatabase) PruneOutbox(ctx context.Context, before time.Time) (int64, error) {
+	return pg_models.DeletePublishedOutboxEvents(p.db.GetEngine(ctx), before.Unix())
+}
@@ -0,0 +1,18 @@
+package deadletter
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ErrLetterNotFound struct {
+	Id string
+}
+
+func IsErrLetterNotFound(err error) bool {
+	return errors.As(err, new(ErrLetterNotFound))
+}
+
+func (e ErrLetterNotFound) Error() string {
+	return fmt.Sprintf("dead letter with id: %s not found", e.Id)
+}
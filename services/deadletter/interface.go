@@ -0,0 +1,25 @@
+package deadletter
+
+import (
+	"context"
+
+	"wb-L0/structs"
+)
+
+var queueInstance Queue
+
+type Queue interface {
+	Publish(context.Context, *structs.DeadLetter) error
+	// List returns up to limit pending letters, oldest first; limit <= 0 means all
+	List(ctx context.Context, limit int) ([]*structs.DeadLetter, error)
+	Remove(ctx context.Context, id string) error
+	HealthCheck(context.Context) error
+}
+
+func SetQueue(queue Queue) {
+	queueInstance = queue
+}
+
+func GetQueue() Queue {
+	return queueInstance
+}
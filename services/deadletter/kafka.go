@@ -0,0 +1,177 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	kafka_lib "github.com/segmentio/kafka-go"
+
+	"wb-L0/modules/config"
+	"wb-L0/modules/kafka"
+	"wb-L0/structs"
+)
+
+// KafkaQueue keeps dead letters in a Kafka topic. Letters are keyed by id,
+// and a message with an empty value is a tombstone written by Remove, so the
+// topic can be compacted. The queue remembers the letters it has read and
+// only reads messages published since the previous List.
+type KafkaQueue struct {
+	kafkaConn *kafka.Kafka
+	// reader reads the topic; it is the Kafka connection outside tests
+	reader topicReader
+	topic  string
+	// mutex serializes reads of the topic and guards the state below
+	mutex   sync.Mutex
+	pending map[string]*queuedLetter
+	// next is the offset each partition is read from
+	next map[int]int64
+	// seq numbers letters in the order they were read
+	seq int64
+}
+
+type topicReader interface {
+	Partitions(ctx context.Context, topic string) ([]int, error)
+	ReadFrom(ctx context.Context, topic string, partition int, from int64) ([]kafka_lib.Message, int64, error)
+}
+
+type queuedLetter struct {
+	letter *structs.DeadLetter
+	seq    int64
+}
+
+func NewKafkaQueue(kafkaInstance *kafka.Kafka) *KafkaQueue {
+	topic := config.GetConfig().KafkaDlqTopic
+	if topic == "" {
+		topic = config.GetConfig().KafkaTopic + ".dlq"
+	}
+	return &KafkaQueue{
+		kafkaConn: kafkaInstance,
+		reader:    kafkaInstance,
+		topic:     topic,
+		pending:   make(map[string]*queuedLetter),
+		next:      make(map[int]int64),
+	}
+}
+
+func (q *KafkaQueue) Publish(ctx context.Context, letter *structs.DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("marshaling error: %w", err)
+	}
+	return q.kafkaConn.Writer.WriteMessages(ctx, kafka_lib.Message{
+		Topic: q.topic,
+		Key:   []byte(letter.Id),
+		Value: data,
+	})
+}
+
+// List returns pending letters, oldest first, after reading what was
+// published to the topic since the previous call
+func (q *KafkaQueue) List(ctx context.Context, limit int) ([]*structs.DeadLetter, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	err := q.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queued := make([]*queuedLetter, 0, len(q.pending))
+	for _, entry := range q.pending {
+		queued = append(queued, entry)
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		if !queued[i].letter.Timestamp.Equal(queued[j].letter.Timestamp) {
+			return queued[i].letter.Timestamp.Before(queued[j].letter.Timestamp)
+		}
+		return queued[i].seq < queued[j].seq
+	})
+	if limit > 0 && limit < len(queued) {
+		queued = queued[:limit]
+	}
+	result := make([]*structs.DeadLetter, len(queued))
+	for i, entry := range queued {
+		result[i] = entry.letter
+	}
+	return result, nil
+}
+
+// read folds what was published to the topic since the previous read into
+// the pending letters; the caller holds the mutex
+func (q *KafkaQueue) read(ctx context.Context) error {
+	partitions, err := q.reader.Partitions(ctx, q.topic)
+	if err != nil {
+		return err
+	}
+	for _, partition := range partitions {
+		messages, next, err := q.reader.ReadFrom(ctx, q.topic, partition, q.next[partition])
+		if err != nil {
+			return err
+		}
+		err = q.apply(messages)
+		if err != nil {
+			return err
+		}
+		q.next[partition] = next
+	}
+	return nil
+}
+
+// apply folds messages read from the topic into the pending letters
+func (q *KafkaQueue) apply(messages []kafka_lib.Message) error {
+	for _, msg := range messages {
+		id := string(msg.Key)
+		if len(msg.Value) == 0 {
+			delete(q.pending, id)
+			continue
+		}
+		letter := new(structs.DeadLetter)
+		if err := json.Unmarshal(msg.Value, letter); err != nil {
+			return fmt.Errorf("unmarshaling error: %w", err)
+		}
+		entry, exists := q.pending[id]
+		if !exists {
+			q.seq++
+			entry = &queuedLetter{seq: q.seq}
+			q.pending[id] = entry
+		}
+		entry.letter = letter
+	}
+	return nil
+}
+
+// Remove writes a tombstone for a pending letter. Letters published since the
+// previous List are read first, so only ids the topic does not hold are
+// ErrLetterNotFound.
+func (q *KafkaQueue) Remove(ctx context.Context, id string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if _, ok := q.pending[id]; !ok {
+		err := q.read(ctx)
+		if err != nil {
+			return err
+		}
+		if _, ok = q.pending[id]; !ok {
+			return ErrLetterNotFound{Id: id}
+		}
+	}
+	err := q.kafkaConn.Writer.WriteMessages(ctx, kafka_lib.Message{
+		Topic: q.topic,
+		Key:   []byte(id),
+	})
+	if err != nil {
+		return err
+	}
+	delete(q.pending, id)
+	return nil
+}
+
+// HealthCheck performs a health check on the dead-letter topic writer
+func (q *KafkaQueue) HealthCheck(_ context.Context) error {
+	if q.kafkaConn.Writer == nil {
+		return fmt.Errorf("kafka writer not initialized")
+	}
+	return nil
+}
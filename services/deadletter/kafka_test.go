@@ -0,0 +1,68 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	kafka_lib "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/structs"
+)
+
+// TestKafkaQueueApply tests that messages read in several passes fold into the pending letters
+func TestKafkaQueueApply(t *testing.T) {
+	queue := &KafkaQueue{pending: make(map[string]*queuedLetter), next: make(map[int]int64)}
+	now := time.Now()
+	letterMessage := func(id string, at time.Time) kafka_lib.Message {
+		data, err := json.Marshal(&structs.DeadLetter{Id: id, Timestamp: at})
+		require.NoError(t, err)
+		return kafka_lib.Message{Key: []byte(id), Value: data}
+	}
+
+	require.NoError(t, queue.apply([]kafka_lib.Message{
+		letterMessage("a", now),
+		letterMessage("b", now.Add(-time.Minute)),
+		letterMessage("c", now),
+	}))
+	require.NoError(t, queue.apply([]kafka_lib.Message{
+		{Key: []byte("b")},
+		letterMessage("d", now),
+	}))
+
+	assert.Len(t, queue.pending, 3)
+	assert.NotContains(t, queue.pending, "b")
+	assert.Less(t, queue.pending["a"].seq, queue.pending["c"].seq)
+	assert.Less(t, queue.pending["c"].seq, queue.pending["d"].seq)
+	assert.Error(t, queue.apply([]kafka_lib.Message{{Key: []byte("e"), Value: []byte("{")}}))
+}
+
+// fakeTopic serves the messages of a single partition
+type fakeTopic struct {
+	messages []kafka_lib.Message
+}
+
+func (t *fakeTopic) Partitions(context.Context, string) ([]int, error) {
+	return []int{0}, nil
+}
+
+func (t *fakeTopic) ReadFrom(_ context.Context, _ string, _ int, from int64) ([]kafka_lib.Message, int64, error) {
+	return t.messages[from:], int64(len(t.messages)), nil
+}
+
+// TestKafkaQueueRemoveUnknown tests that removing an id the topic does not hold is reported as not found
+func TestKafkaQueueRemoveUnknown(t *testing.T) {
+	data, err := json.Marshal(&structs.DeadLetter{Id: "a", Timestamp: time.Now()})
+	require.NoError(t, err)
+	topic := &fakeTopic{messages: []kafka_lib.Message{{Key: []byte("a"), Value: data}, {Key: []byte("b")}}}
+	queue := &KafkaQueue{reader: topic, pending: make(map[string]*queuedLetter), next: make(map[int]int64)}
+
+	err = queue.Remove(context.Background(), "b")
+	assert.True(t, IsErrLetterNotFound(err))
+	// The letter published before the removal was read
+	assert.Contains(t, queue.pending, "a")
+	assert.Equal(t, int64(2), queue.next[0])
+}
@@ -0,0 +1,149 @@
+package deadletter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"wb-L0/structs"
+)
+
+// MemoryQueue keeps dead letters in process memory and, when a file path is
+// given, mirrors them to a JSON Lines file so they survive restarts
+type MemoryQueue struct {
+	letters  []*structs.DeadLetter
+	filePath string
+	mutex    sync.Mutex
+}
+
+// NewMemoryQueue creates a dead-letter queue, restoring previously stored
+// letters from filePath if it is not empty
+func NewMemoryQueue(filePath string) (*MemoryQueue, error) {
+	q := &MemoryQueue{
+		letters:  make([]*structs.DeadLetter, 0),
+		filePath: filePath,
+	}
+	if filePath == "" {
+		return q, nil
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return q, nil
+		}
+		return nil, fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		letter := new(structs.DeadLetter)
+		if err := json.Unmarshal(scanner.Bytes(), letter); err != nil {
+			return nil, fmt.Errorf("corrupted dead-letter file: %w", err)
+		}
+		q.letters = append(q.letters, letter)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter file: %w", err)
+	}
+	return q, nil
+}
+
+func (q *MemoryQueue) Publish(_ context.Context, letter *structs.DeadLetter) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.filePath != "" {
+		if err := q.appendToFile(letter); err != nil {
+			return err
+		}
+	}
+	q.letters = append(q.letters, letter)
+	return nil
+}
+
+func (q *MemoryQueue) List(_ context.Context, limit int) ([]*structs.DeadLetter, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if limit <= 0 || limit > len(q.letters) {
+		limit = len(q.letters)
+	}
+	result := make([]*structs.DeadLetter, limit)
+	copy(result, q.letters)
+	return result, nil
+}
+
+func (q *MemoryQueue) Remove(_ context.Context, id string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for i, letter := range q.letters {
+		if letter.Id != id {
+			continue
+		}
+		remaining := make([]*structs.DeadLetter, 0, len(q.letters)-1)
+		remaining = append(remaining, q.letters[:i]...)
+		remaining = append(remaining, q.letters[i+1:]...)
+		if q.filePath != "" {
+			if err := q.rewriteFile(remaining); err != nil {
+				return err
+			}
+		}
+		q.letters = remaining
+		return nil
+	}
+	return ErrLetterNotFound{Id: id}
+}
+
+// rewriteFile atomically replaces the backing file with the given letters
+func (q *MemoryQueue) rewriteFile(letters []*structs.DeadLetter) error {
+	tmpPath := q.filePath + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	for _, letter := range letters {
+		data, err := json.Marshal(letter)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("marshaling error: %w", err)
+		}
+		writer.Write(data)
+		writer.WriteByte('\n')
+	}
+	err = writer.Flush()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write dead-letter file: %w", err)
+	}
+	return os.Rename(tmpPath, q.filePath)
+}
+
+func (q *MemoryQueue) appendToFile(letter *structs.DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("marshaling error: %w", err)
+	}
+	file, err := os.OpenFile(q.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// HealthCheck performs a health check on memory dead-letter queue
+func (q *MemoryQueue) HealthCheck(_ context.Context) error {
+	return nil
+}
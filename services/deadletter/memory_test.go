@@ -0,0 +1,67 @@
+package deadletter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/structs"
+)
+
+func TestMemoryQueuePublish(t *testing.T) {
+	queue, err := NewMemoryQueue("")
+	require.NoError(t, err)
+
+	err = queue.Publish(context.Background(), &structs.DeadLetter{Id: "letter-1", Reason: "bad json"})
+	require.NoError(t, err)
+
+	assert.Len(t, queue.letters, 1)
+	assert.Equal(t, "letter-1", queue.letters[0].Id)
+}
+
+func TestMemoryQueueFileBacked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlq.jsonl")
+
+	queue, err := NewMemoryQueue(path)
+	require.NoError(t, err)
+
+	letter := &structs.DeadLetter{
+		Id:        "letter-1",
+		Payload:   []byte(`{"order_uid":`),
+		Reason:    "unexpected end of JSON input",
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	require.NoError(t, queue.Publish(context.Background(), letter))
+
+	// A fresh queue on the same file restores what was published
+	restored, err := NewMemoryQueue(path)
+	require.NoError(t, err)
+	require.Len(t, restored.letters, 1)
+	assert.Equal(t, letter, restored.letters[0])
+}
+
+func TestMemoryQueueRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlq.jsonl")
+	queue, err := NewMemoryQueue(path)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, queue.Publish(ctx, &structs.DeadLetter{Id: "letter-1"}))
+	require.NoError(t, queue.Publish(ctx, &structs.DeadLetter{Id: "letter-2"}))
+
+	require.NoError(t, queue.Remove(ctx, "letter-1"))
+	assert.True(t, IsErrLetterNotFound(queue.Remove(ctx, "letter-1")))
+
+	restored, err := NewMemoryQueue(path)
+	require.NoError(t, err)
+	letters, err := restored.List(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, "letter-2", letters[0].Id)
+}
@@ -0,0 +1,23 @@
+package events
+
+import (
+	"context"
+
+	"wb-L0/structs"
+)
+
+var publisherInstance Publisher
+
+// Publisher delivers order events to subscribers. Publish returns only after
+// every event is accepted, or an error if any of them may not have been.
+type Publisher interface {
+	Publish(ctx context.Context, events []*structs.OrderEvent) error
+}
+
+func SetPublisher(publisher Publisher) {
+	publisherInstance = publisher
+}
+
+func GetPublisher() Publisher {
+	return publisherInstance
+}
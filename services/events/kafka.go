@@ -0,0 +1,50 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	kafka_lib "github.com/segmentio/kafka-go"
+
+	"wb-L0/modules/config"
+	"wb-L0/modules/kafka"
+	"wb-L0/structs"
+)
+
+// KafkaPublisher writes events to a topic keyed by order uid, so the events
+// of one order stay in one partition and in order
+type KafkaPublisher struct {
+	kafkaConn *kafka.Kafka
+	topic     string
+}
+
+func NewKafkaPublisher(kafkaInstance *kafka.Kafka) *KafkaPublisher {
+	topic := config.GetConfig().KafkaEventsTopic
+	if topic == "" {
+		topic = config.GetConfig().KafkaTopic + ".events"
+	}
+	return &KafkaPublisher{
+		kafkaConn: kafkaInstance,
+		topic:     topic,
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, events []*structs.OrderEvent) error {
+	messages := make([]kafka_lib.Message, len(events))
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages[i] = kafka_lib.Message{
+			Topic: p.topic,
+			Key:   []byte(event.OrderUid),
+			Value: value,
+			Headers: []kafka_lib.Header{
+				{Key: "event-id", Value: []byte(event.Id)},
+				{Key: "event-type", Value: []byte(event.Type)},
+			},
+		}
+	}
+	return p.kafkaConn.Writer.WriteMessages(ctx, messages...)
+}
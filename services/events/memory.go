@@ -0,0 +1,39 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"wb-L0/structs"
+)
+
+// MemoryPublisher keeps published events in process so tests can subscribe
+// to events. Only the latest capacity events are kept.
+type MemoryPublisher struct {
+	capacity int
+	events   []*structs.OrderEvent
+	mutex    sync.Mutex
+}
+
+func NewMemoryPublisher(capacity int) *MemoryPublisher {
+	return &MemoryPublisher{
+		capacity: capacity,
+	}
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, events []*structs.OrderEvent) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.events = append(p.events, events...)
+	if overflow := len(p.events) - p.capacity; overflow > 0 {
+		p.events = append([]*structs.OrderEvent(nil), p.events[overflow:]...)
+	}
+	return nil
+}
+
+// Events returns the retained events, oldest first
+func (p *MemoryPublisher) Events() []*structs.OrderEvent {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]*structs.OrderEvent(nil), p.events...)
+}
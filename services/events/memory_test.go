@@ -0,0 +1,23 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/structs"
+)
+
+func TestMemoryPublisherCapacity(t *testing.T) {
+	publisher := NewMemoryPublisher(2)
+	ctx := context.Background()
+	require.NoError(t, publisher.Publish(ctx, []*structs.OrderEvent{{Id: "1"}, {Id: "2"}}))
+	require.NoError(t, publisher.Publish(ctx, []*structs.OrderEvent{{Id: "3"}}))
+
+	published := publisher.Events()
+	require.Len(t, published, 2)
+	assert.Equal(t, "2", published[0].Id)
+	assert.Equal(t, "3", published[1].Id)
+}
@@ -0,0 +1,19 @@
+package orderstatus
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ErrInvalidTransition struct {
+	From string
+	To   string
+}
+
+func IsErrInvalidTransition(err error) bool {
+	return errors.As(err, new(ErrInvalidTransition))
+}
+
+func (e ErrInvalidTransition) Error() string {
+	return fmt.Sprintf("order status cannot change from %s to %s", e.From, e.To)
+}
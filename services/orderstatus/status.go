@@ -0,0 +1,72 @@
+package orderstatus
+
+import (
+	"unicode/utf8"
+
+	"wb-L0/services/validation"
+	"wb-L0/structs"
+)
+
+// Order statuses
+const (
+	Created    = "created"
+	Paid       = "paid"
+	Assembling = "assembling"
+	Shipped    = "shipped"
+	Delivered  = "delivered"
+	Cancelled  = "cancelled"
+	Returned   = "returned"
+)
+
+// Sources of a status change
+const (
+	SourceIngest = "ingest"
+	SourceApi    = "api"
+	SourceKafka  = "kafka"
+)
+
+// Mirrors the order_status_history.reason column size
+const reasonSize = 255
+
+// transitions lists the statuses reachable from each status. Cancelled and
+// returned are final.
+var transitions = map[string][]string{
+	Created:    {Paid, Cancelled},
+	Paid:       {Assembling, Cancelled},
+	Assembling: {Shipped, Cancelled},
+	Shipped:    {Delivered, Returned},
+	Delivered:  {Returned},
+	Cancelled:  {},
+	Returned:   {},
+}
+
+func IsKnown(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// CanTransition reports whether an order may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateChange checks the requested status and reason before any state is
+// read
+func ValidateChange(change *structs.OrderStatusChange) error {
+	var fields []structs.FieldError
+	if !IsKnown(change.Status) {
+		fields = append(fields, structs.FieldError{Field: "status", Message: "unknown status"})
+	}
+	if utf8.RuneCountInString(change.Reason) > reasonSize {
+		fields = append(fields, structs.FieldError{Field: "reason", Message: "must be at most 255 characters"})
+	}
+	if len(fields) > 0 {
+		return validation.ErrValidation{Fields: fields}
+	}
+	return nil
+}
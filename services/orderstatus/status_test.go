@@ -0,0 +1,37 @@
+package orderstatus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"wb-L0/services/validation"
+	"wb-L0/structs"
+)
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, CanTransition(Created, Paid))
+	assert.True(t, CanTransition(Paid, Assembling))
+	assert.True(t, CanTransition(Assembling, Shipped))
+	assert.True(t, CanTransition(Shipped, Delivered))
+	assert.True(t, CanTransition(Delivered, Returned))
+	assert.True(t, CanTransition(Assembling, Cancelled))
+
+	assert.False(t, CanTransition(Created, Shipped))
+	assert.False(t, CanTransition(Shipped, Cancelled))
+	assert.False(t, CanTransition(Cancelled, Created))
+	assert.False(t, CanTransition(Returned, Delivered))
+	assert.False(t, CanTransition("", Paid))
+}
+
+func TestValidateChange(t *testing.T) {
+	assert.NoError(t, ValidateChange(&structs.OrderStatusChange{Status: Paid, Reason: "card"}))
+
+	err := ValidateChange(&structs.OrderStatusChange{Status: "lost", Reason: strings.Repeat("r", 256)})
+	fields := validation.FieldErrors(err)
+	if assert.Len(t, fields, 2) {
+		assert.Equal(t, "status", fields[0].Field)
+		assert.Equal(t, "reason", fields[1].Field)
+	}
+}
@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"wb-L0/modules/config"
+	"wb-L0/modules/graceful"
+	"wb-L0/services/events"
+	"wb-L0/structs"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultRetention    = 24 * time.Hour
+	pruneInterval       = time.Hour
+)
+
+// Store is the part of the database the relay works on
+type Store interface {
+	PublishOutbox(ctx context.Context, limit int, publish func(context.Context, []*structs.OrderEvent) error) (int, error)
+	PruneOutbox(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Relay periodically moves order events from the outbox table to the
+// publisher. Events are delivered at least once and in the order they were
+// written; consumers deduplicate by event id.
+type Relay struct {
+	store        Store
+	publisher    events.Publisher
+	pollInterval time.Duration
+	batchSize    int
+	retention    time.Duration
+	done         chan struct{}
+}
+
+func NewRelay(store Store, publisher events.Publisher) *Relay {
+	relay := &Relay{
+		store:        store,
+		publisher:    publisher,
+		pollInterval: defaultPollInterval,
+		batchSize:    defaultBatchSize,
+		retention:    defaultRetention,
+	}
+	if conf := config.GetConfig(); conf != nil {
+		if conf.OutboxPollMs > 0 {
+			relay.pollInterval = time.Duration(conf.OutboxPollMs) * time.Millisecond
+		}
+		if conf.OutboxBatchSize > 0 {
+			relay.batchSize = conf.OutboxBatchSize
+		}
+		if conf.OutboxRetention > 0 {
+			relay.retention = time.Duration(conf.OutboxRetention) * time.Hour
+		}
+	}
+	return relay
+}
+
+func (r *Relay) Init(_ chan error) error {
+	r.done = make(chan struct{})
+	go r.run(graceful.GetContext())
+	return nil
+}
+
+func (r *Relay) SuccessfulMessage() string {
+	return "Outbox relay successfully started"
+}
+
+// Shutdown waits for the publish in flight; undelivered events stay in the
+// outbox for the next start
+func (r *Relay) Shutdown(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Relay) run(ctx context.Context) {
+	defer close(r.done)
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	lastPrune := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.drain(ctx)
+		if time.Since(lastPrune) >= pruneInterval {
+			r.prune(ctx)
+			lastPrune = time.Now()
+		}
+	}
+}
+
+// drain publishes full batches until the outbox is empty or publishing fails
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := r.store.PublishOutbox(ctx, r.batchSize, r.publisher.Publish)
+		if err != nil {
+			log.Printf("Outbox publish failed: %s. Retrying later!", err.Error())
+			return
+		}
+		if published < r.batchSize {
+			return
+		}
+	}
+}
+
+func (r *Relay) prune(ctx context.Context) {
+	deleted, err := r.store.PruneOutbox(ctx, time.Now().Add(-r.retention))
+	if err != nil {
+		log.Printf("Outbox prune failed: %s", err.Error())
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Pruned %d published outbox events", deleted)
+	}
+}
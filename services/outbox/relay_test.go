@@ -0,0 +1,119 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/services/events"
+	"wb-L0/structs"
+)
+
+// memoryStore is an outbox kept in a slice with the semantics of the database
+// implementation: events are only removed when publish succeeds
+type memoryStore struct {
+	mutex   sync.Mutex
+	pending []*structs.OrderEvent
+	calls   int
+}
+
+func newMemoryStore(count int) *memoryStore {
+	store := new(memoryStore)
+	for i := 0; i < count; i++ {
+		store.pending = append(store.pending, &structs.OrderEvent{
+			Id:       fmt.Sprint(i),
+			Type:     structs.EventOrderCreated,
+			OrderUid: fmt.Sprintf("order-%d", i),
+		})
+	}
+	return store
+}
+
+func (s *memoryStore) PublishOutbox(ctx context.Context, limit int,
+	publish func(context.Context, []*structs.OrderEvent) error) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.calls++
+	batch := s.pending[:min(limit, len(s.pending))]
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	err := publish(ctx, batch)
+	if err != nil {
+		return 0, err
+	}
+	s.pending = s.pending[len(batch):]
+	return len(batch), nil
+}
+
+func (s *memoryStore) PruneOutbox(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+// flakyPublisher fails the first publish and delegates afterwards
+type flakyPublisher struct {
+	failed bool
+	next   events.Publisher
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, published []*structs.OrderEvent) error {
+	if !p.failed {
+		p.failed = true
+		return assert.AnError
+	}
+	return p.next.Publish(ctx, published)
+}
+
+func TestRelayDrainsInOrder(t *testing.T) {
+	store := newMemoryStore(250)
+	publisher := events.NewMemoryPublisher(1000)
+	relay := NewRelay(store, publisher)
+
+	relay.drain(context.Background())
+
+	published := publisher.Events()
+	require.Len(t, published, 250)
+	for i, event := range published {
+		assert.Equal(t, fmt.Sprint(i), event.Id)
+	}
+	assert.Equal(t, 3, store.calls)
+	assert.Empty(t, store.pending)
+}
+
+func TestRelayKeepsEventsOnFailure(t *testing.T) {
+	store := newMemoryStore(3)
+	publisher := events.NewMemoryPublisher(1000)
+	relay := NewRelay(store, &flakyPublisher{next: publisher})
+
+	relay.drain(context.Background())
+	assert.Empty(t, publisher.Events())
+	assert.Len(t, store.pending, 3)
+
+	relay.drain(context.Background())
+	assert.Len(t, publisher.Events(), 3)
+	assert.Empty(t, store.pending)
+}
+
+func TestRelayRunStopsWithContext(t *testing.T) {
+	store := newMemoryStore(5)
+	publisher := events.NewMemoryPublisher(1000)
+	relay := NewRelay(store, publisher)
+	relay.pollInterval = 10 * time.Millisecond
+	relay.done = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go relay.run(ctx)
+	assert.Eventually(t, func() bool {
+		return len(publisher.Events()) == 5
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Second)
+	defer shutdownCancel()
+	assert.NoError(t, relay.Shutdown(shutdownCtx))
+}
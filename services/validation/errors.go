@@ -0,0 +1,35 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"wb-L0/structs"
+)
+
+type ErrValidation struct {
+	Fields []structs.FieldError
+}
+
+func IsErrValidation(err error) bool {
+	return errors.As(err, new(ErrValidation))
+}
+
+// FieldErrors extracts per-field errors from err, or nil if err is not a
+// validation error
+func FieldErrors(err error) []structs.FieldError {
+	var validationErr ErrValidation
+	if errors.As(err, &validationErr) {
+		return validationErr.Fields
+	}
+	return nil
+}
+
+func (e ErrValidation) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = fmt.Sprintf("%s: %s", field.Field, field.Message)
+	}
+	return fmt.Sprintf("order validation failed: %s", strings.Join(parts, "; "))
+}
@@ -0,0 +1,219 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"wb-L0/structs"
+)
+
+// maxColumnInt is the largest value of the 32-bit integer columns of the
+// pg_models tables
+const maxColumnInt = math.MaxInt32
+
+// Column sizes of the pg_models tables. Values longer than these are
+// rejected here instead of failing the insert with an opaque database error.
+const (
+	uidLength             = 50
+	orderTrackLength      = 100
+	entryLength           = 50
+	localeLength          = 5
+	customerIdLength      = 50
+	deliveryServiceLength = 50
+	shardkeyLength        = 50
+	oofShardLength        = 5
+
+	deliveryNameLength    = 50
+	deliveryPhoneLength   = 12
+	deliveryZipLength     = 12
+	deliveryCityLength    = 50
+	deliveryAddressLength = 50
+	deliveryRegionLength  = 50
+	deliveryEmailLength   = 50
+
+	transactionLength = 50
+	requestIdLength   = 50
+	currencyLength    = 5
+	providerLength    = 50
+	bankLength        = 50
+
+	itemTrackLength = 50
+	itemRidLength   = 50
+	itemNameLength  = 50
+	itemSizeLength  = 5
+	itemBrandLength = 50
+)
+
+var (
+	phonePattern  = regexp.MustCompile(`^\+?[0-9]{6,14}$`)
+	zipPattern    = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z -]{1,10}[0-9A-Za-z]$`)
+	localePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+)
+
+type validator struct {
+	fields []structs.FieldError
+}
+
+// ValidateOrder checks an incoming order before it is stored and returns
+// ErrValidation listing every offending field
+func ValidateOrder(order *structs.Order) error {
+	v := new(validator)
+
+	v.text("order_uid", order.OrderUid, uidLength, true)
+	v.text("track_number", order.TrackNumber, orderTrackLength, true)
+	v.text("entry", order.Entry, entryLength, true)
+	v.text("locale", order.Locale, localeLength, false)
+	if order.Locale != "" {
+		v.match("locale", order.Locale, localePattern, "must be a language code such as en or ru-RU")
+	}
+	v.text("customer_id", order.CustomerId, customerIdLength, true)
+	v.text("delivery_service", order.DeliveryService, deliveryServiceLength, false)
+	v.text("shardkey", order.Shardkey, shardkeyLength, false)
+	v.text("oof_shard", order.OofShard, oofShardLength, false)
+	v.nonNegative("sm_id", order.SmId)
+	if order.DateCreated == "" {
+		v.add("date_created", "is required")
+	} else if _, err := time.Parse(time.RFC3339, order.DateCreated); err != nil {
+		v.add("date_created", "must be an RFC3339 timestamp")
+	}
+
+	v.delivery(&order.Delivery)
+	v.payment(&order.Payment)
+	v.items(order.Items)
+	v.totals(order)
+
+	if len(v.fields) > 0 {
+		return ErrValidation{Fields: v.fields}
+	}
+	return nil
+}
+
+func (v *validator) delivery(delivery *structs.Delivery) {
+	v.text("delivery.name", delivery.Name, deliveryNameLength, true)
+	v.text("delivery.phone", delivery.Phone, deliveryPhoneLength, true)
+	if delivery.Phone != "" {
+		v.match("delivery.phone", delivery.Phone, phonePattern, "must contain digits with an optional leading +")
+	}
+	v.text("delivery.zip", delivery.Zip, deliveryZipLength, true)
+	if delivery.Zip != "" {
+		v.match("delivery.zip", delivery.Zip, zipPattern, "must be a postal code")
+	}
+	v.text("delivery.city", delivery.City, deliveryCityLength, true)
+	v.text("delivery.address", delivery.Address, deliveryAddressLength, true)
+	v.text("delivery.region", delivery.Region, deliveryRegionLength, true)
+	v.text("delivery.email", delivery.Email, deliveryEmailLength, true)
+	if delivery.Email != "" {
+		if address, err := mail.ParseAddress(delivery.Email); err != nil || address.Address != delivery.Email {
+			v.add("delivery.email", "must be an email address")
+		}
+	}
+}
+
+func (v *validator) payment(payment *structs.Payment) {
+	v.text("payment.transaction", payment.Transaction, transactionLength, true)
+	v.text("payment.request_id", payment.RequestId, requestIdLength, false)
+	v.text("payment.currency", payment.Currency, currencyLength, true)
+	if payment.Currency != "" && !IsCurrencyCode(payment.Currency) {
+		v.add("payment.currency", "must be an ISO 4217 currency code")
+	}
+	v.text("payment.provider", payment.Provider, providerLength, true)
+	v.text("payment.bank", payment.Bank, bankLength, true)
+	if payment.PaymentDt <= 0 {
+		v.add("payment.payment_dt", "must be a positive unix timestamp")
+	} else {
+		v.atMostColumnInt("payment.payment_dt", payment.PaymentDt)
+	}
+	v.nonNegative("payment.amount", payment.Amount)
+	v.nonNegative("payment.delivery_cost", payment.DeliveryCost)
+	v.nonNegative("payment.goods_total", payment.GoodsTotal)
+	v.nonNegative("payment.custom_fee", payment.CustomFee)
+}
+
+func (v *validator) items(items []structs.Item) {
+	if len(items) == 0 {
+		v.add("items", "must contain at least one item")
+		return
+	}
+	for i := range items {
+		item := &items[i]
+		prefix := fmt.Sprintf("items[%d].", i)
+		v.positive(prefix+"chrt_id", item.ChrtId)
+		v.positive(prefix+"nm_id", item.NmId)
+		v.text(prefix+"track_number", item.TrackNumber, itemTrackLength, true)
+		v.text(prefix+"rid", item.Rid, itemRidLength, true)
+		v.text(prefix+"name", item.Name, itemNameLength, true)
+		v.text(prefix+"size", item.Size, itemSizeLength, false)
+		v.text(prefix+"brand", item.Brand, itemBrandLength, true)
+		v.nonNegative(prefix+"price", item.Price)
+		v.nonNegative(prefix+"total_price", item.TotalPrice)
+		v.nonNegative(prefix+"status", item.Status)
+		if item.Sale < 0 || item.Sale > 100 {
+			v.add(prefix+"sale", "must be a percentage between 0 and 100")
+		}
+	}
+}
+
+// totals cross-checks payment sums against the items
+func (v *validator) totals(order *structs.Order) {
+	goodsTotal := 0
+	for _, item := range order.Items {
+		goodsTotal += item.TotalPrice
+	}
+	payment := &order.Payment
+	if payment.GoodsTotal != goodsTotal {
+		v.add("payment.goods_total", fmt.Sprintf("must equal the sum of item total prices (%d)", goodsTotal))
+	}
+	amount := payment.GoodsTotal + payment.DeliveryCost + payment.CustomFee
+	if payment.Amount != amount {
+		v.add("payment.amount", fmt.Sprintf("must equal goods_total + delivery_cost + custom_fee (%d)", amount))
+	}
+}
+
+func (v *validator) add(field, message string) {
+	v.fields = append(v.fields, structs.FieldError{Field: field, Message: message})
+}
+
+func (v *validator) text(field, value string, maxLength int, required bool) {
+	if value == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return
+	}
+	if utf8.RuneCountInString(value) > maxLength {
+		v.add(field, fmt.Sprintf("must be at most %d characters", maxLength))
+	}
+}
+
+func (v *validator) match(field, value string, pattern *regexp.Regexp, message string) {
+	if !pattern.MatchString(value) {
+		v.add(field, message)
+	}
+}
+
+func (v *validator) nonNegative(field string, value int) {
+	if value < 0 {
+		v.add(field, "must not be negative")
+	} else {
+		v.atMostColumnInt(field, int64(value))
+	}
+}
+
+func (v *validator) positive(field string, value int64) {
+	if value <= 0 {
+		v.add(field, "must be positive")
+	} else {
+		v.atMostColumnInt(field, value)
+	}
+}
+
+// atMostColumnInt rejects values the integer column cannot hold
+func (v *validator) atMostColumnInt(field string, value int64) {
+	if value > maxColumnInt {
+		v.add(field, fmt.Sprintf("must be at most %d", maxColumnInt))
+	}
+}
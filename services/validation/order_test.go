@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/structs"
+)
+
+func validOrder() *structs.Order {
+	return &structs.Order{
+		OrderUid:        "b563feb7b2b84b6test",
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerId:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmId:            99,
+		DateCreated:     "2021-11-26T06:22:19Z",
+		OofShard:        "1",
+		Delivery: structs.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: structs.Payment{
+			Transaction:  "b563feb7b2b84b6test",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []structs.Item{
+			{
+				ChrtId:      9934930,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       453,
+				Rid:         "ab4219087a764ae0btest",
+				Name:        "Mascaras",
+				Sale:        30,
+				Size:        "0",
+				TotalPrice:  317,
+				NmId:        2389212,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+		},
+	}
+}
+
+func fieldNames(err error) []string {
+	fields := FieldErrors(err)
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Field
+	}
+	return names
+}
+
+func TestValidateOrderValid(t *testing.T) {
+	assert.NoError(t, ValidateOrder(validOrder()))
+}
+
+func TestValidateOrderRequiredFields(t *testing.T) {
+	order := validOrder()
+	order.OrderUid = ""
+	order.Delivery.Email = ""
+	order.Items = nil
+
+	err := ValidateOrder(order)
+	require.True(t, IsErrValidation(err))
+	names := fieldNames(err)
+	assert.Contains(t, names, "order_uid")
+	assert.Contains(t, names, "delivery.email")
+	assert.Contains(t, names, "items")
+}
+
+func TestValidateOrderColumnLengths(t *testing.T) {
+	order := validOrder()
+	order.Delivery.Phone = "+7900123456789"
+	order.Items[0].Size = "XXXXXL"
+	// Lengths are counted in characters like varchar, not in bytes
+	order.Delivery.City = strings.Repeat("ж", 50)
+
+	names := fieldNames(ValidateOrder(order))
+	assert.Contains(t, names, "delivery.phone")
+	assert.Contains(t, names, "items[0].size")
+	assert.NotContains(t, names, "delivery.city")
+}
+
+func TestValidateOrderFormats(t *testing.T) {
+	order := validOrder()
+	order.Delivery.Email = "not-an-email"
+	order.Delivery.Phone = "call me"
+	order.Delivery.Zip = "!!"
+	order.Payment.Currency = "XYZ"
+	order.DateCreated = "26.11.2021"
+
+	names := fieldNames(ValidateOrder(order))
+	assert.ElementsMatch(t, []string{
+		"delivery.email", "delivery.phone", "delivery.zip", "payment.currency", "date_created",
+	}, names)
+}
+
+func TestValidateOrderArithmetic(t *testing.T) {
+	order := validOrder()
+	order.Items[0].TotalPrice = 300
+	order.Payment.Amount = -1
+
+	names := fieldNames(ValidateOrder(order))
+	assert.Contains(t, names, "payment.goods_total")
+	assert.Contains(t, names, "payment.amount")
+}
+
+func TestValidateOrderIntegerColumns(t *testing.T) {
+	order := validOrder()
+	order.Payment.PaymentDt = math.MaxInt32 + 1
+	order.Items[0].ChrtId = math.MaxInt32 + 1
+	order.Items[0].NmId = math.MaxInt32 + 1
+	order.SmId = math.MaxInt32 + 1
+
+	names := fieldNames(ValidateOrder(order))
+	assert.ElementsMatch(t, []string{
+		"payment.payment_dt", "items[0].chrt_id", "items[0].nm_id", "sm_id",
+	}, names)
+
+	order = validOrder()
+	order.Payment.PaymentDt = math.MaxInt32
+	order.Items[0].ChrtId = math.MaxInt32
+	order.Items[0].NmId = math.MaxInt32
+	assert.NoError(t, ValidateOrder(order))
+}
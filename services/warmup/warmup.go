@@ -0,0 +1,177 @@
+package warmup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"wb-L0/modules/config"
+	"wb-L0/modules/graceful"
+	"wb-L0/services/cache"
+	"wb-L0/structs"
+)
+
+const (
+	defaultCount = 1000
+	// batchSize is the number of orders read and cached at a time
+	batchSize = 100
+)
+
+// Store is the part of the database the warm-up reads from
+type Store interface {
+	GetOrdersByIds(ctx context.Context, oids []string) ([]*structs.Order, error)
+	ListOrders(ctx context.Context, filter *structs.OrderFilter) (*structs.OrderPage, error)
+}
+
+// Warmer preloads orders from the database into the cache after a start so
+// the first requests do not all reach the database. It caches the orders of
+// CACHE_WARMUP_IDS when set, otherwise the CACHE_WARMUP_COUNT most recently
+// created ones, at most as many as the cache holds. Orders invalidated while
+// they are read are not cached. The service reports not ready until it is
+// done; a failed warm-up is logged and leaves the cache as far as it got.
+type Warmer struct {
+	store Store
+	cache cache.Cache
+	count int
+	ids   []string
+	done  chan struct{}
+	ready atomic.Bool
+}
+
+func NewWarmer(store Store, orderCache cache.Cache) *Warmer {
+	warmer := &Warmer{
+		store: store,
+		cache: orderCache,
+		count: defaultCount,
+	}
+	if conf := config.GetConfig(); conf != nil {
+		if conf.CacheWarmupCount != 0 {
+			warmer.count = max(conf.CacheWarmupCount, 0)
+		}
+		for _, id := range strings.Split(conf.CacheWarmupIds, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				warmer.ids = append(warmer.ids, id)
+			}
+		}
+	}
+	return warmer
+}
+
+func (w *Warmer) Init(_ chan error) error {
+	w.done = make(chan struct{})
+	go w.run(graceful.GetContext())
+	return nil
+}
+
+func (w *Warmer) SuccessfulMessage() string {
+	return "Cache warm-up started"
+}
+
+// Shutdown waits for the batch in flight
+func (w *Warmer) Shutdown(ctx context.Context) error {
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HealthCheck fails until the warm-up is done, which holds readiness back
+func (w *Warmer) HealthCheck(_ context.Context) error {
+	if !w.ready.Load() {
+		return fmt.Errorf("cache warm-up in progress")
+	}
+	return nil
+}
+
+func (w *Warmer) run(ctx context.Context) {
+	defer close(w.done)
+	defer w.ready.Store(true)
+	start := time.Now()
+	var (
+		cached int
+		err    error
+	)
+	if len(w.ids) > 0 {
+		cached, err = w.warmIds(ctx)
+	} else {
+		cached, err = w.warmRecent(ctx)
+	}
+	if err != nil {
+		log.Printf("[WARMUP] stopped after caching %d orders: %v", cached, err)
+		return
+	}
+	log.Printf("[WARMUP] cached %d orders in %s", cached, time.Since(start).Round(time.Millisecond))
+}
+
+// warmIds caches the configured orders
+func (w *Warmer) warmIds(ctx context.Context) (int, error) {
+	cached := 0
+	for from := 0; from < len(w.ids); from += batchSize {
+		fill := cache.StartFill(w.cache)
+		orders, err := w.store.GetOrdersByIds(ctx, w.ids[from:min(from+batchSize, len(w.ids))])
+		if err == nil {
+			err = w.put(ctx, fill, orders)
+		}
+		fill.Done()
+		if err != nil {
+			return cached, err
+		}
+		cached += len(orders)
+		log.Printf("[WARMUP] cached %d orders, looked up %d/%d ids", cached, min(from+batchSize, len(w.ids)), len(w.ids))
+	}
+	return cached, nil
+}
+
+// warmRecent caches the most recently created orders. They are listed newest
+// first and cached oldest first, so an LRU that fills up keeps the newest.
+func (w *Warmer) warmRecent(ctx context.Context) (int, error) {
+	count := w.count
+	if stats, err := w.cache.Stats(ctx); err == nil && stats.Capacity > 0 && stats.Capacity < count {
+		log.Printf("[WARMUP] caching %d orders, the cache capacity", stats.Capacity)
+		count = stats.Capacity
+	}
+	fill := cache.StartFill(w.cache)
+	defer fill.Done()
+	orders := make([]*structs.Order, 0, min(count, defaultCount))
+	filter := &structs.OrderFilter{}
+	for len(orders) < count {
+		filter.Limit = min(batchSize, count-len(orders))
+		page, err := w.store.ListOrders(ctx, filter)
+		if err != nil {
+			return 0, err
+		}
+		orders = append(orders, page.Orders...)
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	slices.Reverse(orders)
+	cached := 0
+	for from := 0; from < len(orders); from += batchSize {
+		batch := orders[from:min(from+batchSize, len(orders))]
+		err := w.put(ctx, fill, batch)
+		if err != nil {
+			return cached, err
+		}
+		cached += len(batch)
+		log.Printf("[WARMUP] cached %d/%d orders", cached, len(orders))
+	}
+	return cached, nil
+}
+
+func (w *Warmer) put(ctx context.Context, fill *cache.Fill, orders []*structs.Order) error {
+	for _, order := range orders {
+		err := fill.Put(ctx, order.OrderUid, order)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
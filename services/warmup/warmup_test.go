@@ -0,0 +1,189 @@
+package warmup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wb-L0/modules/config"
+	"wb-L0/services/cache"
+	"wb-L0/services/database"
+	"wb-L0/structs"
+)
+
+// newStore returns a memory database holding orders o0..o{n-1}, created a
+// minute apart with o{n-1} the newest
+func newStore(t *testing.T, n int) *database.MemoryDatabase {
+	db := database.NewMemory(database.DuplicateReject)
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range n {
+		order := &structs.Order{
+			OrderUid:    fmt.Sprintf("o%d", i),
+			DateCreated: created.Add(time.Duration(i) * time.Minute).Format(time.RFC3339),
+			Items:       []structs.Item{{ChrtId: int64(i)}},
+		}
+		require.NoError(t, db.InsertOrder(context.Background(), order))
+	}
+	return db
+}
+
+func cachedUids(t *testing.T, orderCache cache.Cache, n int) []string {
+	uids := make([]string, n)
+	for i := range n {
+		uids[i] = fmt.Sprintf("o%d", i)
+	}
+	found, err := orderCache.GetOrders(context.Background(), uids)
+	require.NoError(t, err)
+	cached := make([]string, 0, len(found))
+	for _, uid := range uids {
+		if _, ok := found[uid]; ok {
+			cached = append(cached, uid)
+		}
+	}
+	return cached
+}
+
+func runWarmer(t *testing.T, warmer *Warmer) {
+	warmer.done = make(chan struct{})
+	assert.Error(t, warmer.HealthCheck(context.Background()))
+	warmer.run(context.Background())
+	assert.NoError(t, warmer.HealthCheck(context.Background()))
+}
+
+func TestWarmerCachesMostRecentOrders(t *testing.T) {
+	orderCache := cache.NewMemoryCache()
+	warmer := NewWarmer(newStore(t, 250), orderCache)
+	warmer.count = 150
+
+	runWarmer(t, warmer)
+
+	cached := cachedUids(t, orderCache, 250)
+	require.Len(t, cached, 150)
+	assert.Equal(t, "o100", cached[0])
+	assert.Equal(t, "o249", cached[149])
+}
+
+// useCacheCapacity configures memory caches created by the test to hold capacity orders
+func useCacheCapacity(t *testing.T, capacity int) {
+	t.Setenv("APP_PORT", "8080")
+	t.Cleanup(func() {
+		_ = new(config.Config).Init(nil)
+	})
+	t.Setenv("CACHE_CAPACITY", fmt.Sprint(capacity))
+	require.NoError(t, new(config.Config).Init(nil))
+}
+
+// unboundedStats hides the capacity of the memory cache from the warmer
+type unboundedStats struct {
+	*cache.MemoryCache
+}
+
+func (unboundedStats) Stats(context.Context) (*structs.CacheStats, error) {
+	return &structs.CacheStats{Type: "memory"}, nil
+}
+
+func TestWarmerStopsAtCacheCapacity(t *testing.T) {
+	useCacheCapacity(t, 50)
+	orderCache := cache.NewMemoryCache()
+	store := &countingStore{MemoryDatabase: newStore(t, 250)}
+	warmer := NewWarmer(store, orderCache)
+	warmer.count = 150
+
+	runWarmer(t, warmer)
+
+	cached := cachedUids(t, orderCache, 250)
+	require.Len(t, cached, 50)
+	assert.Equal(t, "o200", cached[0])
+	assert.Equal(t, "o249", cached[49])
+	assert.Equal(t, 50, store.listed)
+}
+
+func TestWarmerKeepsNewestOrdersOnEviction(t *testing.T) {
+	useCacheCapacity(t, 50)
+	orderCache := cache.NewMemoryCache()
+	warmer := NewWarmer(newStore(t, 250), unboundedStats{orderCache})
+	warmer.count = 150
+
+	runWarmer(t, warmer)
+
+	cached := cachedUids(t, orderCache, 250)
+	require.Len(t, cached, 50)
+	assert.Equal(t, "o200", cached[0])
+	assert.Equal(t, "o249", cached[49])
+}
+
+func TestWarmerSkipsInvalidatedOrders(t *testing.T) {
+	orderCache := cache.NewMemoryCache()
+	store := &countingStore{MemoryDatabase: newStore(t, 5), invalidate: "o4"}
+	warmer := NewWarmer(store, orderCache)
+
+	runWarmer(t, warmer)
+
+	assert.Equal(t, []string{"o0", "o1", "o2", "o3"}, cachedUids(t, orderCache, 5))
+}
+
+func TestWarmerStopsAtLastOrder(t *testing.T) {
+	orderCache := cache.NewMemoryCache()
+	warmer := NewWarmer(newStore(t, 5), orderCache)
+
+	runWarmer(t, warmer)
+
+	assert.Len(t, cachedUids(t, orderCache, 5), 5)
+}
+
+func TestWarmerCachesConfiguredOrders(t *testing.T) {
+	orderCache := cache.NewMemoryCache()
+	warmer := NewWarmer(newStore(t, 5), orderCache)
+	warmer.ids = []string{"o1", "o3", "unknown"}
+
+	runWarmer(t, warmer)
+
+	assert.Equal(t, []string{"o1", "o3"}, cachedUids(t, orderCache, 5))
+}
+
+func TestWarmerBecomesReadyOnFailure(t *testing.T) {
+	orderCache := cache.NewMemoryCache()
+	warmer := NewWarmer(newStore(t, 5), orderCache)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	warmer.ids = []string{"o1"}
+	warmer.store = failingStore{}
+
+	warmer.done = make(chan struct{})
+	warmer.run(ctx)
+	assert.NoError(t, warmer.HealthCheck(context.Background()))
+	assert.Empty(t, cachedUids(t, orderCache, 5))
+}
+
+// countingStore counts the listed orders and invalidates an order while
+// listing, as if it changed during the read
+type countingStore struct {
+	*database.MemoryDatabase
+	invalidate string
+	listed     int
+}
+
+func (s *countingStore) ListOrders(ctx context.Context, filter *structs.OrderFilter) (*structs.OrderPage, error) {
+	page, err := s.MemoryDatabase.ListOrders(ctx, filter)
+	if err == nil {
+		s.listed += len(page.Orders)
+	}
+	if s.invalidate != "" {
+		cache.Invalidate(s.invalidate)
+	}
+	return page, err
+}
+
+type failingStore struct{}
+
+func (failingStore) GetOrdersByIds(ctx context.Context, _ []string) ([]*structs.Order, error) {
+	return nil, ctx.Err()
+}
+
+func (failingStore) ListOrders(ctx context.Context, _ *structs.OrderFilter) (*structs.OrderPage, error) {
+	return nil, ctx.Err()
+}
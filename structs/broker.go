@@ -0,0 +1,8 @@
+package structs
+
+// PushedMessage locates a message pushed into the in-memory broker
+type PushedMessage struct {
+	Topic     string `json:"topic"`
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
@@ -0,0 +1,27 @@
+package structs
+
+// CacheStats describes what the order cache holds
+type CacheStats struct {
+	Type    string `json:"type"`
+	Entries int64  `json:"entries"`
+	// SizeBytes is the approximate memory of the entries of a memory cache
+	// and the memory used by the whole Redis instance
+	SizeBytes  int64 `json:"size_bytes"`
+	Capacity   int   `json:"capacity,omitempty"`
+	MaxBytes   int64 `json:"max_bytes,omitempty"`
+	TtlSeconds int64 `json:"ttl_seconds,omitempty"`
+	// Local describes the in-process tier of a tiered cache
+	Local *CacheStats `json:"local,omitempty"`
+}
+
+type CacheKeys struct {
+	Keys []string `json:"keys"`
+}
+
+type CacheInvalidateRequest struct {
+	Ids []string `json:"ids"`
+}
+
+type CacheInvalidateReport struct {
+	Deleted int `json:"deleted"`
+}
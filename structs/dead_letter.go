@@ -0,0 +1,16 @@
+package structs
+
+import "time"
+
+type DeadLetter struct {
+	Id      string `json:"id"`
+	Payload []byte `json:"payload"`
+	// ContentType is the content type header of the message, empty for JSON
+	ContentType string       `json:"content_type,omitempty"`
+	Reason      string       `json:"reason"`
+	Fields      []FieldError `json:"fields,omitempty"`
+	Topic       string       `json:"topic"`
+	Partition   int          `json:"partition"`
+	Offset      int64        `json:"offset"`
+	Timestamp   time.Time    `json:"timestamp"`
+}
@@ -3,3 +3,13 @@ package structs
 type ApiError struct {
 	Message string `json:"message"`
 }
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields"`
+}
@@ -0,0 +1,23 @@
+package structs
+
+import "time"
+
+// Order event types published through the outbox
+const (
+	EventOrderCreated = "order.created"
+	EventOrderUpdated = "order.updated"
+)
+
+// OrderEvent notifies subscribers about a stored order. Id is unique per event
+// and lets consumers drop redelivered events.
+type OrderEvent struct {
+	Id       string `json:"id"`
+	Type     string `json:"type"`
+	OrderUid string `json:"order_uid"`
+	Status   string `json:"status"`
+	// Order is set when the order content was created or replaced
+	Order *Order `json:"order,omitempty"`
+	// StatusChange is set when only the status changed
+	StatusChange *OrderStatusEntry `json:"status_change,omitempty"`
+	OccurredAt   time.Time         `json:"occurred_at"`
+}
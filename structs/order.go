@@ -1,5 +1,7 @@
 package structs
 
+import "time"
+
 type Order struct {
 	OrderUid          string   `json:"order_uid"`
 	TrackNumber       string   `json:"track_number"`
@@ -15,6 +17,8 @@ type Order struct {
 	SmId              int      `json:"sm_id"`
 	DateCreated       string   `json:"date_created"`
 	OofShard          string   `json:"oof_shard"`
+	// Status is maintained by the service; it is ignored on input
+	Status string `json:"status,omitempty"`
 }
 
 type Delivery struct {
@@ -53,3 +57,33 @@ type Item struct {
 	Brand       string `json:"brand"`
 	Status      int    `json:"status"`
 }
+
+type OrdersLookupRequest struct {
+	OrderIds []string `json:"order_ids"`
+}
+
+type OrdersLookupResponse struct {
+	Orders  []*Order `json:"orders"`
+	Missing []string `json:"missing"`
+}
+
+type OrderFilter struct {
+	CustomerId      string    `form:"customer_id"`
+	TrackNumber     string    `form:"track_number"`
+	DeliveryService string    `form:"delivery_service"`
+	Locale          string    `form:"locale"`
+	DateFrom        time.Time `form:"date_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	DateTo          time.Time `form:"date_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Provider        string    `form:"provider"`
+	Bank            string    `form:"bank"`
+	Brand           string    `form:"brand"`
+	NmId            int64     `form:"nm_id"`
+	Status          string    `form:"status"`
+	Cursor          string    `form:"cursor"`
+	Limit           int       `form:"limit"`
+}
+
+type OrderPage struct {
+	Orders     []*Order `json:"orders"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,26 @@
+package structs
+
+import "time"
+
+// OrderStatusChange requests a status transition. OrderUid is only read from
+// status messages; the HTTP endpoint takes it from the path.
+type OrderStatusChange struct {
+	OrderUid string `json:"order_uid,omitempty"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+	Source   string `json:"-"`
+}
+
+type OrderStatusEntry struct {
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason,omitempty"`
+	Source    string    `json:"source"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type OrderStatusHistory struct {
+	OrderUid string              `json:"order_uid"`
+	Status   string              `json:"status"`
+	History  []*OrderStatusEntry `json:"history"`
+}
@@ -0,0 +1,24 @@
+package structs
+
+type DeadLetterReplayRequest struct {
+	Ids   []string `json:"ids"`
+	Limit int      `json:"limit"`
+}
+
+type OffsetReplayRequest struct {
+	Topic      string `json:"topic"`
+	Partition  int    `json:"partition"`
+	FromOffset int64  `json:"from_offset"`
+	ToOffset   int64  `json:"to_offset"`
+}
+
+type ReplayResult struct {
+	Id      string `json:"id,omitempty"`
+	Offset  int64  `json:"offset"`
+	Outcome string `json:"outcome"`
+}
+
+type ReplayReport struct {
+	Replayed int            `json:"replayed"`
+	Results  []ReplayResult `json:"results"`
+}